@@ -90,6 +90,18 @@ func (self *holderBuilder) SetHeapsterHost(heapsterHost string) *holderBuilder {
 	return self
 }
 
+// SetPrometheusHost 'prometheus-host' argument of Dashboard binary.
+func (self *holderBuilder) SetPrometheusHost(prometheusHost string) *holderBuilder {
+	self.holder.prometheusHost = prometheusHost
+	return self
+}
+
+// SetMetricClientProvider 'metric-client-provider' argument of Dashboard binary.
+func (self *holderBuilder) SetMetricClientProvider(provider string) *holderBuilder {
+	self.holder.metricClientProvider = provider
+	return self
+}
+
 // SetKubeConfigFile 'kubeconfig' argument of Dashboard binary.
 func (self *holderBuilder) SetKubeConfigFile(kubeConfigFile string) *holderBuilder {
 	self.holder.kubeConfigFile = kubeConfigFile
@@ -138,6 +150,18 @@ func (self *holderBuilder) SetEnableAnonymous(enableAnonymous bool) *holderBuild
 	return self
 }
 
+// SetEnableResourceCache 'enable-resource-cache' argument of Dashboard binary.
+func (self *holderBuilder) SetEnableResourceCache(enableResourceCache bool) *holderBuilder {
+	self.holder.enableResourceCache = enableResourceCache
+	return self
+}
+
+// SetEnablePipelineSchedules 'enable-pipeline-schedules' argument of Dashboard binary.
+func (self *holderBuilder) SetEnablePipelineSchedules(enablePipelineSchedules bool) *holderBuilder {
+	self.holder.enablePipelineSchedules = enablePipelineSchedules
+	return self
+}
+
 func (self *holderBuilder) SetMultiClusterHost(multiclusterhost string) *holderBuilder {
 	self.holder.multiClusterHost = multiclusterhost
 	return self
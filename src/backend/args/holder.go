@@ -38,6 +38,8 @@ type holder struct {
 	keyFile              string
 	apiServerHost        string
 	heapsterHost         string
+	prometheusHost       string
+	metricClientProvider string
 	kubeConfigFile       string
 	systemBanner         string
 	systemBannerSeverity string
@@ -48,6 +50,8 @@ type holder struct {
 	enableInsecureLogin       bool
 	disableSettingsAuthorizer bool
 	enableAnonymous           bool
+	enableResourceCache       bool
+	enablePipelineSchedules   bool
 	multiClusterHost          string
 }
 
@@ -114,6 +118,16 @@ func (self *holder) GetHeapsterHost() string {
 	return self.heapsterHost
 }
 
+// GetPrometheusHost 'prometheus-host' argument of Dashboard binary.
+func (self *holder) GetPrometheusHost() string {
+	return self.prometheusHost
+}
+
+// GetMetricClientProvider 'metric-client-provider' argument of Dashboard binary.
+func (self *holder) GetMetricClientProvider() string {
+	return self.metricClientProvider
+}
+
 // GetKubeConfigFile 'kubeconfig' argument of Dashboard binary.
 func (self *holder) GetKubeConfigFile() string {
 	return self.kubeConfigFile
@@ -154,6 +168,16 @@ func (self *holder) GetEnableAnonymous() bool {
 	return self.enableAnonymous
 }
 
+// GetEnableResourceCache 'enable-resource-cache' argument of Dashboard binary.
+func (self *holder) GetEnableResourceCache() bool {
+	return self.enableResourceCache
+}
+
+// GetEnablePipelineSchedules 'enable-pipeline-schedules' argument of Dashboard binary.
+func (self *holder) GetEnablePipelineSchedules() bool {
+	return self.enablePipelineSchedules
+}
+
 func (self *holder) GetMultiClusterHost() string {
 	return self.multiClusterHost
 }
@@ -0,0 +1,38 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package api
+
+import (
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Authorizer returns true when the user that owns a client is allowed to perform the action
+// described by given SelfSubjectAccessReview.
+type Authorizer func(ssar *authv1.SelfSubjectAccessReview) bool
+
+// ResourceCache is responsible for keeping an informer-backed, cluster wide copy of most
+// frequently listed resources. Cache is filled using dashboard's own credentials, so every
+// read has to be authorized on behalf of the user before it is served.
+type ResourceCache interface {
+	// Start starts all informers in separate goroutines. Does not block thread that calls it.
+	Start(stopCh <-chan struct{})
+	// HasSynced returns true when all informers finished initial list.
+	HasSynced() bool
+	// Wrap returns client that reads supported lists from the cache. Every read is checked
+	// with given authorizer and falls back to the wrapped client when it is denied or can not
+	// be served from the cache.
+	Wrap(client kubernetes.Interface, authorizer Authorizer) kubernetes.Interface
+}
@@ -0,0 +1,217 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"sync"
+
+	cacheApi "alauda.io/diablo/src/backend/cache/api"
+	apps "k8s.io/api/apps/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	batch "k8s.io/api/batch/v1"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+var (
+	podsResource         = schema.GroupResource{Resource: "pods"}
+	servicesResource     = schema.GroupResource{Resource: "services"}
+	deploymentsResource  = schema.GroupResource{Group: "apps", Resource: "deployments"}
+	replicaSetsResource  = schema.GroupResource{Group: "apps", Resource: "replicasets"}
+	statefulSetsResource = schema.GroupResource{Group: "apps", Resource: "statefulsets"}
+	daemonSetsResource   = schema.GroupResource{Group: "apps", Resource: "daemonsets"}
+	jobsResource         = schema.GroupResource{Group: "batch", Resource: "jobs"}
+)
+
+// cachedClient is a kubernetes client of a single user that can serve list calls from the
+// resource cache. All other calls go directly to the wrapped client.
+type cachedClient struct {
+	kubernetes.Interface
+
+	cache      *resourceCache
+	authorizer cacheApi.Authorizer
+
+	mux sync.Mutex
+	// decisions keeps authorization results for the lifetime of the client, which is a
+	// single request.
+	decisions map[string]bool
+}
+
+// canList checks whether the user is allowed to list given resource in the namespace.
+func (self *cachedClient) canList(resource schema.GroupResource, namespace string) bool {
+	key := resource.String() + "/" + namespace
+
+	self.mux.Lock()
+	allowed, exists := self.decisions[key]
+	self.mux.Unlock()
+	if exists {
+		return allowed
+	}
+
+	allowed = self.authorizer(&authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "list",
+				Group:     resource.Group,
+				Resource:  resource.Resource,
+			},
+		},
+	})
+
+	self.mux.Lock()
+	self.decisions[key] = allowed
+	self.mux.Unlock()
+	return allowed
+}
+
+// fromCache returns cached client and parsed label selector when the list described by
+// arguments can be served from the cache.
+func fromCache(client kubernetes.Interface, resource schema.GroupResource, namespace string,
+	options metaV1.ListOptions) (*cachedClient, labels.Selector, bool) {
+	c, ok := client.(*cachedClient)
+	if !ok {
+		return nil, nil, false
+	}
+
+	// Field selectors are not supported by listers.
+	if !c.cache.HasSynced() || len(options.FieldSelector) > 0 {
+		trackRequest(resource, resultMiss)
+		return nil, nil, false
+	}
+
+	selector, err := labels.Parse(options.LabelSelector)
+	if err != nil {
+		trackRequest(resource, resultMiss)
+		return nil, nil, false
+	}
+
+	if !c.canList(resource, namespace) {
+		trackRequest(resource, resultForbidden)
+		return nil, nil, false
+	}
+
+	trackRequest(resource, resultHit)
+	return c, selector, true
+}
+
+// ListPods returns list of pods. It is served from the cache when given client was created by
+// ResourceCache and the user is allowed to list pods in the namespace.
+func ListPods(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*v1.PodList, error) {
+	if c, selector, ok := fromCache(client, podsResource, namespace, options); ok {
+		if items, err := c.cache.pods.Pods(namespace).List(selector); err == nil {
+			list := &v1.PodList{Items: make([]v1.Pod, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.CoreV1().Pods(namespace).List(options)
+}
+
+// ListServices returns list of services. See ListPods for more information.
+func ListServices(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*v1.ServiceList, error) {
+	if c, selector, ok := fromCache(client, servicesResource, namespace, options); ok {
+		if items, err := c.cache.services.Services(namespace).List(selector); err == nil {
+			list := &v1.ServiceList{Items: make([]v1.Service, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.CoreV1().Services(namespace).List(options)
+}
+
+// ListDeployments returns list of deployments. See ListPods for more information.
+func ListDeployments(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*apps.DeploymentList, error) {
+	if c, selector, ok := fromCache(client, deploymentsResource, namespace, options); ok {
+		if items, err := c.cache.deployments.Deployments(namespace).List(selector); err == nil {
+			list := &apps.DeploymentList{Items: make([]apps.Deployment, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.AppsV1().Deployments(namespace).List(options)
+}
+
+// ListReplicaSets returns list of replica sets. See ListPods for more information.
+func ListReplicaSets(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*apps.ReplicaSetList, error) {
+	if c, selector, ok := fromCache(client, replicaSetsResource, namespace, options); ok {
+		if items, err := c.cache.replicaSets.ReplicaSets(namespace).List(selector); err == nil {
+			list := &apps.ReplicaSetList{Items: make([]apps.ReplicaSet, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.AppsV1().ReplicaSets(namespace).List(options)
+}
+
+// ListStatefulSets returns list of stateful sets. See ListPods for more information.
+func ListStatefulSets(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*apps.StatefulSetList, error) {
+	if c, selector, ok := fromCache(client, statefulSetsResource, namespace, options); ok {
+		if items, err := c.cache.statefulSets.StatefulSets(namespace).List(selector); err == nil {
+			list := &apps.StatefulSetList{Items: make([]apps.StatefulSet, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.AppsV1().StatefulSets(namespace).List(options)
+}
+
+// ListDaemonSets returns list of daemon sets. See ListPods for more information.
+func ListDaemonSets(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*apps.DaemonSetList, error) {
+	if c, selector, ok := fromCache(client, daemonSetsResource, namespace, options); ok {
+		if items, err := c.cache.daemonSets.DaemonSets(namespace).List(selector); err == nil {
+			list := &apps.DaemonSetList{Items: make([]apps.DaemonSet, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.AppsV1().DaemonSets(namespace).List(options)
+}
+
+// ListJobs returns list of jobs. See ListPods for more information.
+func ListJobs(client kubernetes.Interface, namespace string, options metaV1.ListOptions) (*batch.JobList, error) {
+	if c, selector, ok := fromCache(client, jobsResource, namespace, options); ok {
+		if items, err := c.cache.jobs.Jobs(namespace).List(selector); err == nil {
+			list := &batch.JobList{Items: make([]batch.Job, 0, len(items))}
+			for _, item := range items {
+				list.Items = append(list.Items, *item.DeepCopy())
+			}
+			return list, nil
+		}
+	}
+
+	return client.BatchV1().Jobs(namespace).List(options)
+}
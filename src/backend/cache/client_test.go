@@ -0,0 +1,151 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"testing"
+	"time"
+
+	cacheApi "alauda.io/diablo/src/backend/cache/api"
+	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func newPod(namespace, name string, labels map[string]string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metaV1.ObjectMeta{Namespace: namespace, Name: name, Labels: labels}}
+}
+
+func startCache(t *testing.T, stopCh chan struct{}) cacheApi.ResourceCache {
+	c := NewResourceCache(fake.NewSimpleClientset(
+		newPod("ns-1", "cached-a", map[string]string{"app": "a"}),
+		newPod("ns-1", "cached-b", map[string]string{"app": "b"}),
+		newPod("ns-2", "cached-c", nil),
+	), 0)
+	c.Start(stopCh)
+
+	err := wait.PollImmediate(10*time.Millisecond, 5*time.Second, func() (bool, error) {
+		return c.HasSynced(), nil
+	})
+	if err != nil {
+		t.Fatalf("Resource cache did not sync: %v", err)
+	}
+	return c
+}
+
+func podNames(list *v1.PodList) []string {
+	result := make([]string, 0)
+	for _, pod := range list.Items {
+		result = append(result, pod.Name)
+	}
+	return result
+}
+
+func TestListPods(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	resourceCache := startCache(t, stopCh)
+	live := fake.NewSimpleClientset(newPod("ns-1", "live", nil))
+
+	cases := []struct {
+		info       string
+		allowed    bool
+		namespace  string
+		options    metaV1.ListOptions
+		expected   []string
+		authorized bool
+	}{
+		{"Allowed user reads namespace from cache", true, "ns-1",
+			metaV1.ListOptions{}, []string{"cached-a", "cached-b"}, true},
+		{"Label selector is applied to cache", true, "ns-1",
+			metaV1.ListOptions{LabelSelector: "app=b"}, []string{"cached-b"}, true},
+		{"All namespaces are read from cache", true, "",
+			metaV1.ListOptions{}, []string{"cached-a", "cached-b", "cached-c"}, true},
+		{"Denied user falls back to live list", false, "ns-1",
+			metaV1.ListOptions{}, []string{"live"}, true},
+		{"Field selector falls back to live list", true, "ns-1",
+			metaV1.ListOptions{FieldSelector: "metadata.name=live"}, []string{"live"}, false},
+	}
+
+	for _, c := range cases {
+		var attributes *authv1.ResourceAttributes
+		client := resourceCache.Wrap(live, func(ssar *authv1.SelfSubjectAccessReview) bool {
+			attributes = ssar.Spec.ResourceAttributes
+			return c.allowed
+		})
+
+		list, err := ListPods(client, c.namespace, c.options)
+		if err != nil {
+			t.Fatalf("Test Case: %s. Unexpected error: %v", c.info, err)
+		}
+
+		names := podNames(list)
+		if len(names) != len(c.expected) {
+			t.Errorf("Test Case: %s. Expected %v, but got %v.", c.info, c.expected, names)
+			continue
+		}
+		expected := map[string]bool{}
+		for _, name := range c.expected {
+			expected[name] = true
+		}
+		for _, name := range names {
+			if !expected[name] {
+				t.Errorf("Test Case: %s. Expected %v, but got %v.", c.info, c.expected, names)
+			}
+		}
+
+		if c.authorized && (attributes == nil || attributes.Verb != "list" ||
+			attributes.Resource != "pods" || attributes.Namespace != c.namespace) {
+			t.Errorf("Test Case: %s. Expected list pods in %q to be authorized, but got %v.",
+				c.info, c.namespace, attributes)
+		}
+	}
+}
+
+func TestListPodsWithoutCache(t *testing.T) {
+	client := fake.NewSimpleClientset(newPod("ns-1", "live", nil))
+
+	list, err := ListPods(client, "ns-1", metaV1.ListOptions{})
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+	if names := podNames(list); len(names) != 1 || names[0] != "live" {
+		t.Errorf("Expected [live], but got %v.", names)
+	}
+}
+
+func TestCachedClientAuthorizationIsMemoized(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	resourceCache := startCache(t, stopCh)
+
+	calls := 0
+	client := resourceCache.Wrap(fake.NewSimpleClientset(), func(ssar *authv1.SelfSubjectAccessReview) bool {
+		calls++
+		return true
+	})
+
+	for i := 0; i < 3; i++ {
+		if _, err := ListPods(client, "ns-1", metaV1.ListOptions{}); err != nil {
+			t.Fatalf("Unexpected error: %v", err)
+		}
+	}
+
+	if calls != 1 {
+		t.Errorf("Expected authorizer to be called once, but got %d calls.", calls)
+	}
+}
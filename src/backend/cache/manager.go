@@ -0,0 +1,103 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"log"
+	"time"
+
+	cacheApi "alauda.io/diablo/src/backend/cache/api"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	appslisters "k8s.io/client-go/listers/apps/v1"
+	batchlisters "k8s.io/client-go/listers/batch/v1"
+	corelisters "k8s.io/client-go/listers/core/v1"
+	toolscache "k8s.io/client-go/tools/cache"
+)
+
+// DefaultResyncPeriod is the resync period of all informers started by the resource cache.
+const DefaultResyncPeriod = 10 * time.Minute
+
+// Implements ResourceCache interface.
+type resourceCache struct {
+	factory informers.SharedInformerFactory
+	synced  []toolscache.InformerSynced
+
+	pods         corelisters.PodLister
+	services     corelisters.ServiceLister
+	deployments  appslisters.DeploymentLister
+	replicaSets  appslisters.ReplicaSetLister
+	statefulSets appslisters.StatefulSetLister
+	daemonSets   appslisters.DaemonSetLister
+	jobs         batchlisters.JobLister
+}
+
+// Start implements resource cache interface. See ResourceCache for more information.
+func (self *resourceCache) Start(stopCh <-chan struct{}) {
+	self.factory.Start(stopCh)
+	go func() {
+		if toolscache.WaitForCacheSync(stopCh, self.synced...) {
+			log.Print("Resource cache synced")
+		}
+	}()
+}
+
+// HasSynced implements resource cache interface. See ResourceCache for more information.
+func (self *resourceCache) HasSynced() bool {
+	for _, synced := range self.synced {
+		if !synced() {
+			return false
+		}
+	}
+	return true
+}
+
+// Wrap implements resource cache interface. See ResourceCache for more information.
+func (self *resourceCache) Wrap(client kubernetes.Interface, authorizer cacheApi.Authorizer) kubernetes.Interface {
+	return &cachedClient{
+		Interface:  client,
+		cache:      self,
+		authorizer: authorizer,
+		decisions:  make(map[string]bool),
+	}
+}
+
+// NewResourceCache creates resource cache that fills informers using given client. The client
+// should have permissions to list and watch all cached resources in all namespaces.
+func NewResourceCache(client kubernetes.Interface, resync time.Duration) cacheApi.ResourceCache {
+	factory := informers.NewSharedInformerFactory(client, resync)
+	result := &resourceCache{
+		factory:      factory,
+		pods:         factory.Core().V1().Pods().Lister(),
+		services:     factory.Core().V1().Services().Lister(),
+		deployments:  factory.Apps().V1().Deployments().Lister(),
+		replicaSets:  factory.Apps().V1().ReplicaSets().Lister(),
+		statefulSets: factory.Apps().V1().StatefulSets().Lister(),
+		daemonSets:   factory.Apps().V1().DaemonSets().Lister(),
+		jobs:         factory.Batch().V1().Jobs().Lister(),
+	}
+
+	result.synced = []toolscache.InformerSynced{
+		factory.Core().V1().Pods().Informer().HasSynced,
+		factory.Core().V1().Services().Informer().HasSynced,
+		factory.Apps().V1().Deployments().Informer().HasSynced,
+		factory.Apps().V1().ReplicaSets().Informer().HasSynced,
+		factory.Apps().V1().StatefulSets().Informer().HasSynced,
+		factory.Apps().V1().DaemonSets().Informer().HasSynced,
+		factory.Batch().V1().Jobs().Informer().HasSynced,
+	}
+
+	return result
+}
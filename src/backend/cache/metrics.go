@@ -0,0 +1,47 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cache
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Results of a list request made through the resource cache.
+const (
+	resultHit       = "hit"
+	resultMiss      = "miss"
+	resultForbidden = "forbidden"
+)
+
+var (
+	cacheRequestCounter = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "diable_resource_cache_request_count",
+			Help: "Counter of list requests made through the resource cache broken out for each resource and result (hit, miss, forbidden).",
+		},
+		[]string{"resource", "result"},
+	)
+)
+
+// Initialize all metrics in prometheus
+func init() {
+	prometheus.MustRegister(cacheRequestCounter)
+}
+
+// Track list request in prometheus
+func trackRequest(resource schema.GroupResource, result string) {
+	cacheRequestCounter.WithLabelValues(resource.String(), result).Inc()
+}
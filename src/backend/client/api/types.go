@@ -16,6 +16,7 @@ package api
 
 import (
 	authApi "alauda.io/diablo/src/backend/auth/api"
+	cacheApi "alauda.io/diablo/src/backend/cache/api"
 	settingsapi "alauda.io/diablo/src/backend/settings/api"
 	restful "github.com/emicklei/go-restful"
 	"k8s.io/api/authorization/v1"
@@ -41,6 +42,7 @@ type ClientManager interface {
 	VerberClient(req *restful.Request) (ResourceVerber, error)
 	SetTokenManager(manager authApi.TokenManager)
 	SetAuthSettings(*settingsapi.AuthSettings)
+	SetResourceCache(cacheApi.ResourceCache)
 }
 
 // ResourceVerber is responsible for performing generic CRUD operations on all supported resources.
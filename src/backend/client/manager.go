@@ -21,6 +21,7 @@ import (
 	"strings"
 
 	authApi "alauda.io/diablo/src/backend/auth/api"
+	cacheApi "alauda.io/diablo/src/backend/cache/api"
 	clientapi "alauda.io/diablo/src/backend/client/api"
 	settingsapi "alauda.io/diablo/src/backend/settings/api"
 	dc "github.com/alauda/cyborg/pkg/client"
@@ -77,6 +78,9 @@ type clientManager struct {
 	enableAnounymous bool
 
 	multiClusterHost string
+
+	// Optional informer-backed cache used to serve list calls of the local cluster.
+	resourceCache cacheApi.ResourceCache
 }
 
 // Client returns kubernetes client that is created based on authentication information extracted
@@ -92,6 +96,13 @@ func (self *clientManager) Client(req *restful.Request) (kubernetes.Interface, e
 		return nil, err
 	}
 
+	// Cache holds only resources of the local cluster.
+	if self.resourceCache != nil && getClusterName(req) == "" {
+		return self.resourceCache.Wrap(client, func(ssar *v1.SelfSubjectAccessReview) bool {
+			return canI(client, ssar)
+		}), nil
+	}
+
 	return client, nil
 }
 
@@ -136,6 +147,11 @@ func (self *clientManager) CanI(req *restful.Request, ssar *v1.SelfSubjectAccess
 		return false
 	}
 
+	return canI(client, ssar)
+}
+
+// canI returns true when owner of the client is allowed to access data provided within SelfSubjectAccessReview.
+func canI(client kubernetes.Interface, ssar *v1.SelfSubjectAccessReview) bool {
 	response, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(ssar)
 	if err != nil {
 		log.Println(err)
@@ -255,6 +271,25 @@ func (self *clientManager) SetAuthSettings(authsettings *settingsapi.AuthSetting
 	self.authSettings = authsettings
 }
 
+// SetResourceCache sets the resource cache that will be used to serve list calls.
+func (self *clientManager) SetResourceCache(resourceCache cacheApi.ResourceCache) {
+	self.resourceCache = resourceCache
+}
+
+// getClusterName returns name of the remote cluster targeted by the request or empty string
+// for the local cluster.
+func getClusterName(req *restful.Request) string {
+	if req == nil {
+		return ""
+	}
+
+	clusterName := req.PathParameter("cluster")
+	if clusterName == "" {
+		clusterName = req.QueryParameter("cluster")
+	}
+	return clusterName
+}
+
 // Initializes config with default values
 func (self *clientManager) initConfig(cfg *rest.Config) {
 	cfg.QPS = DefaultQPS
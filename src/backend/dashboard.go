@@ -22,14 +22,18 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"time"
 
 	"alauda.io/diablo/src/backend/args"
 	authApi "alauda.io/diablo/src/backend/auth/api"
+	"alauda.io/diablo/src/backend/cache"
 	"alauda.io/diablo/src/backend/cert"
 	"alauda.io/diablo/src/backend/cert/ecdsa"
 	"alauda.io/diablo/src/backend/client"
 	"alauda.io/diablo/src/backend/handler"
 	"alauda.io/diablo/src/backend/integration"
+	integrationapi "alauda.io/diablo/src/backend/integration/api"
+	"alauda.io/diablo/src/backend/resource/pipelineconfig"
 	"alauda.io/diablo/src/backend/settings"
 	"alauda.io/diablo/src/backend/systembanner"
 	"alauda.io/diablo/src/backend/thirdparty"
@@ -40,6 +44,7 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/spf13/pflag"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 var (
@@ -58,6 +63,12 @@ var (
 		"to connect to in the format of protocol://address:port, e.g., "+
 		"http://localhost:8082. If not specified, the assumption is that the binary runs inside a "+
 		"Kubernetes cluster and service proxy will be used.")
+	argPrometheusHost = pflag.String("prometheus-host", "", "The address of the Prometheus server "+
+		"to connect to in the format of protocol://address:port, e.g., "+
+		"http://localhost:9090. If not specified, the PROMETHEUS_URL environment variable or the "+
+		"in-cluster Prometheus service will be used.")
+	argMetricClientProvider = pflag.String("metric-client-provider", "none", "The metric client used to "+
+		"download CPU and memory metrics. Supported values: none, heapster, prometheus. Default: none, metrics are disabled.")
 	argKubeConfigFile     = pflag.String("kubeconfig", "", "Path to kubeconfig file with authorization and master location information.")
 	argTokenTTL           = pflag.Int("token-ttl", int(authApi.DefaultTokenTTL), "Expiration time (in seconds) of JWE tokens generated by dashboard. Default: 15 min. 0 - never expires")
 	argAuthenticationMode = pflag.StringSlice("authentication-mode", []string{authApi.Token.String()}, "Enables authentication options that will be reflected on login screen. Supported values: token, basic. Default: token."+
@@ -69,6 +80,8 @@ var (
 	argSystemBannerSeverity      = pflag.String("system-banner-severity", "INFO", "Severity of system banner. Should be one of 'INFO|WARNING|ERROR'. Default: 'INFO'.")
 	argDisableSettingsAuthorizer = pflag.Bool("disable-settings-authorizer", false, "When enabled, Dashboard settings page will not require user to be logged in and authorized to access settings page.")
 	argEnableAnonymous           = pflag.Bool("enable-anonymous", false, "When enabled this settings will use the kubeconfig auth info or service account info instead of user login")
+	argEnableResourceCache       = pflag.Bool("enable-resource-cache", false, "When enabled, most frequently listed resources are served from an informer-backed cache. User permissions are still checked on every request. Default: false.")
+	argEnablePipelineSchedules   = pflag.Bool("enable-pipeline-schedules", false, "When enabled, Dashboard triggers pipeline configs on the cron schedules saved through its API, on behalf of the users who saved them. It should be enabled on all replicas. Default: false.")
 	argMultiClusterHost          = pflag.String("multi-clusterhost", "https://erebus:443", "It is the endpoint of the Erebus")
 )
 
@@ -116,6 +129,25 @@ func main() {
 
 	log.Printf("Successful initial request to the apiserver, version: %s", versionInfo.String())
 
+	if args.Holder.GetEnableResourceCache() {
+		log.Print("Starting resource cache")
+		resourceCache := cache.NewResourceCache(clientManager.InsecureClient(), cache.DefaultResyncPeriod)
+		resourceCache.Start(wait.NeverStop)
+		clientManager.SetResourceCache(resourceCache)
+	}
+
+	if args.Holder.GetEnablePipelineSchedules() {
+		devopsClient, err := clientManager.DevOpsClient(nil)
+		if err != nil {
+			handleFatalInitError(err)
+		}
+		if err := pipelineconfig.LoadScheduleKey(clientManager.InsecureClient()); err != nil {
+			handleFatalInitError(err)
+		}
+		log.Print("Starting pipeline scheduler")
+		pipelineconfig.NewScheduler(devopsClient, clientManager.InsecureClient(), pipelineconfig.DefaultScheduleSyncPeriod).Start(wait.NeverStop)
+	}
+
 	// Init settings manager
 	settingsManager := settings.NewSettingsManager(clientManager)
 
@@ -125,6 +157,7 @@ func main() {
 
 	// Init integrations
 	integrationManager := integration.NewIntegrationManager(clientManager)
+	configureMetricClient(integrationManager)
 	thirpartyManager := thirdparty.NewThirdPartyManager()
 
 	apiHandler, err := handler.CreateHTTPAPIHandler(
@@ -191,6 +224,8 @@ func initArgHolder() {
 	builder.SetKeyFile(*argKeyFile)
 	builder.SetApiServerHost(*argApiserverHost)
 	builder.SetHeapsterHost(*argHeapsterHost)
+	builder.SetPrometheusHost(*argPrometheusHost)
+	builder.SetMetricClientProvider(*argMetricClientProvider)
 	builder.SetKubeConfigFile(*argKubeConfigFile)
 	builder.SetSystemBanner(*argSystemBanner)
 	builder.SetSystemBannerSeverity(*argSystemBannerSeverity)
@@ -199,10 +234,30 @@ func initArgHolder() {
 	builder.SetEnableInsecureLogin(*argEnableInsecureLogin)
 	builder.SetDisableSettingsAuthorizer(*argDisableSettingsAuthorizer)
 	builder.SetEnableAnonymous(*argEnableAnonymous)
+	builder.SetEnableResourceCache(*argEnableResourceCache)
+	builder.SetEnablePipelineSchedules(*argEnablePipelineSchedules)
 	builder.SetMultiClusterHost(*argMultiClusterHost)
 
 }
 
+// configureMetricClient registers metric client selected by 'metric-client-provider' argument
+// and keeps enabling it in the background until it becomes healthy.
+func configureMetricClient(integrationManager integration.IntegrationManager) {
+	period := time.Duration(args.Holder.GetMetricClientCheckPeriod())
+	switch integrationapi.IntegrationID(args.Holder.GetMetricClientProvider()) {
+	case "", "none":
+		log.Print("No metric client provider configured. Metrics will be disabled.")
+	case integrationapi.PrometheusIntegrationID:
+		integrationManager.Metric().ConfigurePrometheus(args.Holder.GetPrometheusHost()).
+			EnableWithRetry(integrationapi.PrometheusIntegrationID, period)
+	case integrationapi.HeapsterIntegrationID:
+		integrationManager.Metric().ConfigureHeapster(args.Holder.GetHeapsterHost()).
+			EnableWithRetry(integrationapi.HeapsterIntegrationID, period)
+	default:
+		log.Printf("Unknown metric client provider: %s. Metrics will be disabled.", args.Holder.GetMetricClientProvider())
+	}
+}
+
 /**
  * Handles fatal init error that prevents server from doing any work. Prints verbose error
  * message and quits the server.
@@ -19,6 +19,7 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	clientapi "alauda.io/diablo/src/backend/client/api"
 	kdErrors "alauda.io/diablo/src/backend/errors"
@@ -32,10 +33,14 @@ import (
 	"github.com/emicklei/go-restful"
 	appsv1 "k8s.io/api/apps/v1"
 	authv1 "k8s.io/api/authorization/v1"
+	"k8s.io/api/core/v1"
 	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/tools/remotecommand"
 
+	"alauda.io/diablo/src/backend/resource/application"
 	"alauda.io/diablo/src/backend/resource/cronjob"
 	"alauda.io/diablo/src/backend/resource/daemonset"
 	"alauda.io/diablo/src/backend/resource/dataselect"
@@ -1706,6 +1711,90 @@ func (apiHandler *APIHandler) handleUpdateSecretBelongApp(request *restful.Reque
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+func (apiHandler *APIHandler) handleRotateSecret(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	rotation := new(secret.SecretRotation)
+	if err := request.ReadEntity(rotation); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	appCoreClient, err := apiHandler.cManager.AppCoreClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter(PathParameterNamespace)
+	name := request.PathParameter(PathParameterName)
+	result, err := secret.RotateSecret(k8sClient, appCoreClient, devopsClient, namespace, name, rotation)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetExpiringSecrets(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	days := 30
+	if value := request.QueryParameter("days"); value != "" {
+		if days, err = strconv.Atoi(value); err != nil || days < 0 {
+			kdErrors.HandleInternalError(response, errors.NewBadRequest("days must be a non-negative integer"))
+			return
+		}
+	}
+
+	namespace := parseNamespacePathParameter(request)
+	result, err := secret.GetExpiringSecrets(k8sClient, devopsClient, namespace, days)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetUnusedSecrets(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := parseNamespacePathParameter(request)
+	result, err := secret.GetUnusedSecrets(k8sClient, devopsClient, namespace)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
 func (apiHandler *APIHandler) handleDeleteSecret(request *restful.Request, response *restful.Response) {
 	k8sClient, err := apiHandler.cManager.Client(request)
 	if err != nil {
@@ -1719,15 +1808,64 @@ func (apiHandler *APIHandler) handleDeleteSecret(request *restful.Request, respo
 		return
 	}
 
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
 	namespace := parseNamespacePathParameter(request)
 	name := request.PathParameter("name")
+	impact, err := secret.GetSecretImpact(k8sClient, appCoreClient, devopsClient, common.GetCurNamespace(namespace), name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	if request.QueryParameter("dryRun") == "true" {
+		response.WriteHeaderAndEntity(http.StatusOK, impact)
+		return
+	}
+	// the impact is incomplete when some resources could not be checked, it is not known to be safe either
+	if (len(impact.Resources) > 0 || len(impact.Errors) > 0) && request.QueryParameter("force") != "true" {
+		response.WriteHeaderAndEntity(http.StatusConflict, impact)
+		return
+	}
+
 	err = secret.DeleteSecret(k8sClient, appCoreClient, namespace, name)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
 
-	response.WriteHeaderAndEntity(http.StatusOK, struct{}{})
+	impact.Deleted = true
+	response.WriteHeaderAndEntity(http.StatusOK, impact)
+}
+
+func (apiHandler *APIHandler) handleGetSecretImpact(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	appCoreClient, err := apiHandler.cManager.AppCoreClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := parseNamespacePathParameter(request)
+	name := request.PathParameter("name")
+	result, err := secret.GetSecretImpact(k8sClient, appCoreClient, devopsClient, common.GetCurNamespace(namespace), name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
 func (apiHandler *APIHandler) handleGetConfigMapList(request *restful.Request, response *restful.Response) {
@@ -2746,32 +2884,8 @@ func (apiHandler *APIHandler) handleLogs(request *restful.Request, response *res
 	podID := request.PathParameter("pod")
 	containerID := request.PathParameter(PathParameterContainer)
 
-	refTimestamp := request.QueryParameter("referenceTimestamp")
-	if refTimestamp == "" {
-		refTimestamp = logs.NewestTimestamp
-	}
-
-	refLineNum, err := strconv.Atoi(request.QueryParameter("referenceLineNum"))
-	if err != nil {
-		refLineNum = 0
-	}
 	usePreviousLogs := request.QueryParameter("previous") == "true"
-	offsetFrom, err1 := strconv.Atoi(request.QueryParameter("offsetFrom"))
-	offsetTo, err2 := strconv.Atoi(request.QueryParameter("offsetTo"))
-	logFilePosition := request.QueryParameter("logFilePosition")
-
-	logSelector := logs.DefaultSelection
-	if err1 == nil && err2 == nil {
-		logSelector = &logs.Selection{
-			ReferencePoint: logs.LogLineId{
-				LogTimestamp: logs.LogTimestamp(refTimestamp),
-				LineNum:      refLineNum,
-			},
-			OffsetFrom:      offsetFrom,
-			OffsetTo:        offsetTo,
-			LogFilePosition: logFilePosition,
-		}
-	}
+	logSelector := parseLogSelection(request)
 
 	result, err := container.GetLogDetails(k8sClient, namespace, podID, containerID, logSelector, usePreviousLogs)
 	if err != nil {
@@ -2800,6 +2914,113 @@ func (apiHandler *APIHandler) handleLogFile(request *restful.Request, response *
 	handleDownload(response, logStream)
 }
 
+func (apiHandler *APIHandler) handleSearchLogs(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	query, err := parseLogSearchQuery(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errors.NewBadRequest(err.Error()))
+		return
+	}
+
+	namespace := request.PathParameter(PathParameterNamespace)
+	podID := request.PathParameter("pod")
+	containerID := request.PathParameter(PathParameterContainer)
+	usePreviousLogs := request.QueryParameter("previous") == "true"
+	logFilePosition := request.QueryParameter("logFilePosition")
+
+	result, err := container.SearchLogs(k8sClient, namespace, podID, containerID, query, logFilePosition, usePreviousLogs)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+// logPodsGetter returns pods of the resource from the request whose logs should be aggregated.
+type logPodsGetter func(apiHandler *APIHandler, k8sClient kubernetes.Interface, request *restful.Request) ([]v1.Pod, error)
+
+func getDeploymentLogPods(apiHandler *APIHandler, k8sClient kubernetes.Interface, request *restful.Request) ([]v1.Pod, error) {
+	return deployment.GetRawDeploymentPods(k8sClient, request.PathParameter("namespace"), request.PathParameter("deployment"))
+}
+
+func getStatefulSetLogPods(apiHandler *APIHandler, k8sClient kubernetes.Interface, request *restful.Request) ([]v1.Pod, error) {
+	return statefulset.GetRawStatefulSetPods(k8sClient, request.PathParameter("statefulset"), request.PathParameter("namespace"))
+}
+
+func getDaemonSetLogPods(apiHandler *APIHandler, k8sClient kubernetes.Interface, request *restful.Request) ([]v1.Pod, error) {
+	return daemonset.GetRawDaemonSetPods(k8sClient, request.PathParameter("daemonset"), request.PathParameter("namespace"))
+}
+
+func getApplicationLogPods(apiHandler *APIHandler, k8sClient kubernetes.Interface, request *restful.Request) ([]v1.Pod, error) {
+	appCoreClient, err := apiHandler.cManager.AppCoreClient(request)
+	if err != nil {
+		return nil, err
+	}
+	return application.GetApplicationPods(k8sClient, appCoreClient, request.PathParameter("namespace"), request.PathParameter("name"))
+}
+
+// handleAggregatedLogs returns handler of logs merged from all pods of a resource.
+func (apiHandler *APIHandler) handleAggregatedLogs(getPods logPodsGetter) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		k8sClient, err := apiHandler.cManager.Client(request)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		pods, err := getPods(apiHandler, k8sClient, request)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		namespace := request.PathParameter(PathParameterNamespace)
+		containerID := request.QueryParameter(PathParameterContainer)
+		usePreviousLogs := request.QueryParameter("previous") == "true"
+		logSelector := parseLogSelection(request)
+
+		result, err := container.GetAggregatedLogDetails(k8sClient, namespace, pods, containerID, logSelector, usePreviousLogs)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+		response.WriteHeaderAndEntity(http.StatusOK, result)
+	}
+}
+
+// handleAggregatedLogFile returns handler of a downloadable file with logs merged from all pods of a resource.
+func (apiHandler *APIHandler) handleAggregatedLogFile(getPods logPodsGetter) restful.RouteFunction {
+	return func(request *restful.Request, response *restful.Response) {
+		k8sClient, err := apiHandler.cManager.Client(request)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		pods, err := getPods(apiHandler, k8sClient, request)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		namespace := request.PathParameter(PathParameterNamespace)
+		containerID := request.QueryParameter(PathParameterContainer)
+		usePreviousLogs := request.QueryParameter("previous") == "true"
+
+		logStream, err := container.GetAggregatedLogFile(k8sClient, namespace, pods, containerID, usePreviousLogs)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+		handleDownload(response, logStream)
+	}
+}
+
 // RBAC
 func (apiHandler *APIHandler) handleListRoleBindings(request *restful.Request, response *restful.Response) {
 	k8sClient, err := apiHandler.cManager.Client(request)
@@ -2907,6 +3128,65 @@ func parseFilterPathParameter(request *restful.Request) *dataselect.FilterQuery
 	return dataselect.NewFilterQuery(strings.Split(request.QueryParameter("filterBy"), ","))
 }
 
+// Parses query parameters of the request and returns a log Selection object
+func parseLogSelection(request *restful.Request) *logs.Selection {
+	refTimestamp := request.QueryParameter("referenceTimestamp")
+	if refTimestamp == "" {
+		refTimestamp = logs.NewestTimestamp
+	}
+
+	refLineNum, err := strconv.Atoi(request.QueryParameter("referenceLineNum"))
+	if err != nil {
+		refLineNum = 0
+	}
+	offsetFrom, err1 := strconv.Atoi(request.QueryParameter("offsetFrom"))
+	offsetTo, err2 := strconv.Atoi(request.QueryParameter("offsetTo"))
+	logFilePosition := request.QueryParameter("logFilePosition")
+
+	logSelector := logs.DefaultSelection
+	if err1 == nil && err2 == nil {
+		logSelector = &logs.Selection{
+			ReferencePoint: logs.LogLineId{
+				LogTimestamp: logs.LogTimestamp(refTimestamp),
+				LineNum:      refLineNum,
+			},
+			OffsetFrom:      offsetFrom,
+			OffsetTo:        offsetTo,
+			LogFilePosition: logFilePosition,
+		}
+	}
+	return logSelector
+}
+
+// Parses query parameters of the request and returns a log SearchQuery object
+func parseLogSearchQuery(request *restful.Request) (*logs.SearchQuery, error) {
+	before, err := strconv.Atoi(request.QueryParameter("before"))
+	if err != nil {
+		before = 0
+	}
+	after, err := strconv.Atoi(request.QueryParameter("after"))
+	if err != nil {
+		after = 0
+	}
+
+	var since, until time.Time
+	if value := request.QueryParameter("since"); len(value) > 0 {
+		if since, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+	if value := request.QueryParameter("until"); len(value) > 0 {
+		if until, err = time.Parse(time.RFC3339, value); err != nil {
+			return nil, err
+		}
+	}
+
+	return logs.NewSearchQuery(request.QueryParameter("q"),
+		request.QueryParameter("regex") == "true",
+		request.QueryParameter("caseSensitive") == "true",
+		before, after, since, until)
+}
+
 // Parses query parameters of the request and returns a SortQuery object
 func parseSortPathParameter(request *restful.Request) *dataselect.SortQuery {
 	return dataselect.NewSortQuery(strings.Split(request.QueryParameter("sortBy"), ","))
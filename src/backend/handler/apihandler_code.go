@@ -469,3 +469,62 @@ func (apiHandler *APIHandler) HandleGetCodeRepositoryBranches(request *restful.R
 	}
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
+
+func (apiHandler *APIHandler) handleGetCodeRepositoryCommits(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	name := request.PathParameter("name")
+	namespace := request.PathParameter("namespace")
+	branch := request.QueryParameter("branch")
+	pQuery := parsePaginationPathParameter(request)
+
+	result, err := coderepository.GetCodeRepositoryCommits(devopsClient, apiHandler.cManager.InsecureClient(), namespace, name, branch, pQuery)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetCodeRepositoryTags(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	name := request.PathParameter("name")
+	namespace := request.PathParameter("namespace")
+	pQuery := parsePaginationPathParameter(request)
+
+	result, err := coderepository.GetCodeRepositoryTags(devopsClient, apiHandler.cManager.InsecureClient(), namespace, name, pQuery)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetCodeRepositoryPullRequests(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	name := request.PathParameter("name")
+	namespace := request.PathParameter("namespace")
+	state := request.QueryParameter("state")
+	pQuery := parsePaginationPathParameter(request)
+
+	result, err := coderepository.GetCodeRepositoryPullRequests(devopsClient, apiHandler.cManager.InsecureClient(), namespace, name, state, pQuery)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
@@ -8,16 +8,21 @@ import (
 	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
+	"time"
 
 	"alauda.io/diablo/src/backend/resource/statistics"
 	"github.com/emicklei/go-restful"
 	"github.com/golang/glog"
 
+	"alauda.io/diablo/src/backend/client"
 	kdErrors "alauda.io/diablo/src/backend/errors"
 	"alauda.io/diablo/src/backend/resource/clusterpipelinetemplate"
 	"alauda.io/diablo/src/backend/resource/common"
 	"alauda.io/diablo/src/backend/resource/configmap"
+	"alauda.io/diablo/src/backend/resource/dataselect"
 	"alauda.io/diablo/src/backend/resource/jenkinsbinding"
+	ns "alauda.io/diablo/src/backend/resource/namespace"
 	"alauda.io/diablo/src/backend/resource/pipeline"
 	"alauda.io/diablo/src/backend/resource/pipelineconfig"
 	"alauda.io/diablo/src/backend/resource/pipelinetasktemplate"
@@ -31,6 +36,13 @@ import (
 	"alauda.io/diablo/src/backend/resource/toolchain"
 
 	"alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	authnv1 "k8s.io/api/authentication/v1"
+	authv1 "k8s.io/api/authorization/v1"
+	errorsK8s "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 )
 
 const (
@@ -468,7 +480,7 @@ func (apiHandler *APIHandler) handleUpdatePipelineConfig(request *restful.Reques
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
-	result, err := pipelineconfig.UpdatePipelineConfigDetail(devopsClient, k8sClient, spec)
+	result, err := pipelineconfig.UpdatePipelineConfigDetail(devopsClient, k8sClient, spec, getUserName(request))
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
@@ -527,6 +539,575 @@ func (apiHandler *APIHandler) handleTriggerPipelineConfig(request *restful.Reque
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+func (apiHandler *APIHandler) handleValidatePipelineConfigParameters(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	spec := new(pipelineconfig.PipelineConfigTrigger)
+	if err := request.ReadEntity(spec); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	spec.Namespace = request.PathParameter("namespace")
+	spec.Name = request.PathParameter("name")
+	result, err := pipelineconfig.ValidateTriggerParameters(devopsClient, spec)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetParameterPresets(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.GetParameterPresets(devopsClient, namespace, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleSaveParameterPreset(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	values := make(map[string]string, 0)
+	if err := request.ReadEntity(&values); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	preset := request.PathParameter("preset")
+	result, err := pipelineconfig.SaveParameterPreset(devopsClient, namespace, name, preset, values)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleDeleteParameterPreset(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	preset := request.PathParameter("preset")
+	result, err := pipelineconfig.DeleteParameterPreset(devopsClient, namespace, name, preset)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetPipelineTemplateUpgrades(request *restful.Request, response *restful.Response) {
+	apiHandler.handleGetTemplateUpgrades(request, response, "PipelineTemplate", parseNamespacePathParameter(request))
+}
+
+func (apiHandler *APIHandler) handleGetClusterPipelineTemplateUpgrades(request *restful.Request, response *restful.Response) {
+	apiHandler.handleGetTemplateUpgrades(request, response, "ClusterPipelineTemplate", parseNamespaceQueryParameter(request))
+}
+
+func (apiHandler *APIHandler) handleGetTemplateUpgrades(request *restful.Request, response *restful.Response, kind string,
+	namespace *common.NamespaceQuery) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.GetTemplateUpgrades(devopsClient, namespace, kind, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleUpgradePipelineTemplateConfigs(request *restful.Request, response *restful.Response) {
+	apiHandler.handleUpgradeTemplateConfigs(request, response, "PipelineTemplate", parseNamespacePathParameter(request))
+}
+
+func (apiHandler *APIHandler) handleUpgradeClusterPipelineTemplateConfigs(request *restful.Request, response *restful.Response) {
+	apiHandler.handleUpgradeTemplateConfigs(request, response, "ClusterPipelineTemplate", parseNamespaceQueryParameter(request))
+}
+
+func (apiHandler *APIHandler) handleUpgradeTemplateConfigs(request *restful.Request, response *restful.Response, kind string,
+	namespace *common.NamespaceQuery) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	targets := make([]common.BatchTarget, 0)
+	if request.Request.ContentLength != 0 {
+		if err := request.ReadEntity(&targets); err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+	}
+	options := parseBatchOptions(request)
+	if err := common.CheckBatchSelection(len(targets) > 0, options); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	name := request.PathParameter("name")
+	list, err := pipelineconfig.GetTemplateUpgrades(devopsClient, namespace, kind, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	targets, err = pipelineconfig.SelectTemplateUpgradeTargets(list, targets)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result := pipelineconfig.UpgradePipelineConfigs(devopsClient, k8sClient, targets, options, getUserName(request))
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handlePreviewTemplateUpgrade(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.PreviewTemplateUpgrade(devopsClient, namespace, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+// parseNamespaceQueryParameter returns namespaces of the comma separated namespace query parameter,
+// all namespaces when it is empty
+func parseNamespaceQueryParameter(request *restful.Request) *common.NamespaceQuery {
+	namespaces := make([]string, 0)
+	for _, namespace := range strings.Split(request.QueryParameter("namespace"), ",") {
+		if namespace = strings.TrimSpace(namespace); namespace != "" {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return common.NewNamespaceQuery(namespaces)
+}
+
+func (apiHandler *APIHandler) handleClonePipelineConfig(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	clone := new(pipelineconfig.PipelineConfigClone)
+	if err := request.ReadEntity(clone); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.ClonePipelineConfig(devopsClient, namespace, name, clone)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleImportPipelineConfigs(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	opts := new(pipelineconfig.BundleImportOptions)
+	if err := request.ReadEntity(opts); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	dryRun := request.QueryParameter("dryRun") == "true"
+	result, err := pipelineconfig.ImportPipelineConfigs(devopsClient, k8sClient, namespace, opts, dryRun)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetPipelineConfigRevisions(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.GetRevisions(k8sClient, namespace, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetPipelineConfigRevision(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	revision, err := parseRevision(request, "revision")
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.GetRevision(k8sClient, namespace, name, revision)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleDiffPipelineConfigRevisions(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	from, err := parseRevision(request, "revision")
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	to, err := parseRevision(request, "to")
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.DiffRevisions(devopsClient, k8sClient, namespace, name, from, to)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleRollbackPipelineConfig(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	revision, err := parseRevision(request, "revision")
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.RollbackPipelineConfig(devopsClient, k8sClient, namespace, name, revision, getUserName(request))
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+// parseRevision parses a revision number from the path parameter
+func parseRevision(request *restful.Request, parameter string) (int, error) {
+	value := request.PathParameter(parameter)
+	revision, err := strconv.Atoi(value)
+	if err != nil || revision <= 0 {
+		return 0, errorsK8s.NewBadRequest(fmt.Sprintf("invalid revision %q", value))
+	}
+	return revision, nil
+}
+
+func (apiHandler *APIHandler) handleGetSchedules(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	count := pipelineconfig.DefaultScheduleFireTimes
+	if value := request.QueryParameter("count"); value != "" {
+		if count, err = strconv.Atoi(value); err != nil {
+			kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(fmt.Sprintf("invalid count %q", value)))
+			return
+		}
+	}
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	result, err := pipelineconfig.GetSchedules(devopsClient, namespace, name, count)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleSaveSchedule(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	schedule := new(pipelineconfig.PipelineSchedule)
+	if err := request.ReadEntity(schedule); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	schedule.Name = request.PathParameter("schedule")
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	owner, err := apiHandler.getScheduleOwner(request, namespace)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := pipelineconfig.SaveSchedule(devopsClient, namespace, name, schedule, owner)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleDeleteSchedule(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	schedule := request.PathParameter("schedule")
+	result, err := pipelineconfig.DeleteSchedule(devopsClient, namespace, name, schedule)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handlePauseSchedule(request *restful.Request, response *restful.Response) {
+	apiHandler.handleSetSchedulePaused(request, response, true)
+}
+
+func (apiHandler *APIHandler) handleResumeSchedule(request *restful.Request, response *restful.Response) {
+	apiHandler.handleSetSchedulePaused(request, response, false)
+}
+
+func (apiHandler *APIHandler) handleSetSchedulePaused(request *restful.Request, response *restful.Response, paused bool) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	schedule := request.PathParameter("schedule")
+	owner, err := apiHandler.getScheduleOwner(request, namespace)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := pipelineconfig.SetSchedulePaused(devopsClient, namespace, name, schedule, paused, owner)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+// getScheduleOwner returns the user of the request when it is allowed to create pipelines in the namespace.
+// The user is taken from a TokenReview of the request token, so it is the user the apiserver authorizes.
+// The scheduler triggers schedules on behalf of this user.
+func (apiHandler *APIHandler) getScheduleOwner(request *restful.Request, namespace string) (*pipelineconfig.ScheduleOwner, error) {
+	resource := schema.GroupResource{Group: v1alpha1.SchemeGroupVersion.Group, Resource: "pipelines"}
+	notLoggedIn := errorsK8s.NewForbidden(resource, "", errors.New("schedules can only be saved by users logged in with a token"))
+	// without a token of the user the request would be made with the default credentials of the dashboard
+	if request.HeaderParameter(HeadParameterAuthorization) == "" && request.HeaderParameter(client.JWETokenHeader) == "" {
+		return nil, notLoggedIn
+	}
+	config, err := apiHandler.cManager.Config(request)
+	if err != nil {
+		return nil, err
+	}
+	if config.BearerToken == "" {
+		return nil, notLoggedIn
+	}
+	review, err := apiHandler.cManager.InsecureClient().AuthenticationV1().TokenReviews().Create(&authnv1.TokenReview{
+		Spec: authnv1.TokenReviewSpec{Token: config.BearerToken},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !review.Status.Authenticated || review.Status.User.Username == "" {
+		return nil, notLoggedIn
+	}
+	user := review.Status.User
+
+	allowed := apiHandler.cManager.CanI(request, &authv1.SelfSubjectAccessReview{
+		Spec: authv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "create",
+				Group:     v1alpha1.SchemeGroupVersion.Group,
+				Version:   v1alpha1.SchemeGroupVersion.Version,
+				Resource:  "pipelines",
+			},
+		},
+	})
+	if !allowed {
+		return nil, errorsK8s.NewForbidden(resource, "", fmt.Errorf("user %s cannot create pipelines in namespace %s", user.Username, namespace))
+	}
+	return &pipelineconfig.ScheduleOwner{User: user.Username, Groups: user.Groups}, nil
+}
+
+func (apiHandler *APIHandler) handleRunSchedule(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	schedule := request.PathParameter("schedule")
+	result, err := pipelineconfig.RunSchedule(devopsClient, k8sClient, namespace, name, schedule)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleTriggerMultiBranchPipelineConfig(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	trigger := new(pipelineconfig.MultiBranchTrigger)
+	if err := request.ReadEntity(trigger); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := pipelineconfig.TriggerMultiBranchPipelineConfig(devopsClient, k8sClient, namespace, name, trigger,
+		parseBatchOptions(request))
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleTriggerPipelineConfigs(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	spec := new(pipelineconfig.PipelineConfigTrigger)
+	if err := request.ReadEntity(spec); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := parseNamespacePathParameter(request)
+	dataSelect := parseDataSelectPathParameter(request)
+	options := parseBatchOptions(request)
+	if err := common.CheckBatchSelection(isFiltered(dataSelect), options); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := pipelineconfig.TriggerPipelineConfigs(devopsClient, k8sClient, namespace, dataSelect, spec, options)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
 func (apiHandler *APIHandler) handleCronCheck(request *restful.Request, response *restful.Response) {
 	cron := request.QueryParameter("cron")
 	name := request.PathParameter("name")
@@ -617,6 +1198,23 @@ func (apiHandler *APIHandler) handlePipelineConfigLogs(request *restful.Request,
 		start = 0
 	}
 
+	if wantsEventStream(request) {
+		stream, err := newEventStream(response)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		err = pipelineconfig.FollowLogs(devopsClient, namespace, name, start, stream.StopOnClose(done),
+			func(configLog *v1alpha1.PipelineConfigLog) error {
+				return stream.Send("", configLog)
+			})
+		stream.Finish(err)
+		return
+	}
+
 	result, err := pipelineconfig.GetLogDetails(devopsClient, namespace, name, start)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
@@ -625,6 +1223,33 @@ func (apiHandler *APIHandler) handlePipelineConfigLogs(request *restful.Request,
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+func (apiHandler *APIHandler) handlePipelineConfigTestHistory(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	runs, err := strconv.Atoi(request.QueryParameter("runs"))
+	if err != nil || runs <= 0 {
+		runs = pipelineconfig.DefaultTestHistoryRuns
+	}
+	slowest, err := strconv.Atoi(request.QueryParameter("slowest"))
+	if err != nil || slowest < 0 {
+		slowest = pipelineconfig.DefaultTestHistorySlowest
+	}
+
+	result, err := pipelineconfig.GetTestHistory(devopsClient, namespace, name, runs, slowest)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
 // endregion
 
 // region Pipeline
@@ -706,28 +1331,94 @@ func (apiHandler *APIHandler) handleRetryPipelineDetail(request *restful.Request
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
-	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	spec := new(pipeline.RetryRequest)
+	// if err := request.ReadEntity(spec); err != nil {
+	// 	log.Println("error reading body", err)
+	// 	kdErrors.HandleInternalError(response, err)
+	// 	return
+	// }
+	spec.Namespace = namespace
+	spec.Name = name
+	result, err := pipeline.RetryPipeline(devopsClient, k8sClient, spec)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleRetryPipelines(request *restful.Request, response *restful.Response) {
+	apiHandler.handlePipelinesBatch(request, response, pipeline.RetryPipelines)
+}
+
+func (apiHandler *APIHandler) handleAbortPipelines(request *restful.Request, response *restful.Response) {
+	apiHandler.handlePipelinesBatch(request, response, pipeline.AbortPipelines)
+}
+
+// pipelinesBatch executes an action on every pipeline selected by the query
+type pipelinesBatch func(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace *common.NamespaceQuery,
+	dsQuery *dataselect.DataSelectQuery, since time.Time, options common.BatchOptions) (*common.BatchResult, error)
+
+func (apiHandler *APIHandler) handlePipelinesBatch(request *restful.Request, response *restful.Response, batch pipelinesBatch) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	// only pipelines created in the period are selected when it is given
+	var since time.Time
+	if period := request.QueryParameter("period"); period != "" {
+		if since, _, err = GetRecentPeriodTime(period); err != nil {
+			kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+			return
+		}
+	}
+
+	namespace := parseNamespacePathParameter(request)
+	dataSelect := parseDataSelectPathParameter(request)
+	options := parseBatchOptions(request)
+	if err := common.CheckBatchSelection(isFiltered(dataSelect) || !since.IsZero(), options); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := batch(devopsClient, k8sClient, namespace, dataSelect, since, options)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
 
-	namespace := request.PathParameter("namespace")
-	name := request.PathParameter("name")
-	spec := new(pipeline.RetryRequest)
-	// if err := request.ReadEntity(spec); err != nil {
-	// 	log.Println("error reading body", err)
-	// 	kdErrors.HandleInternalError(response, err)
-	// 	return
-	// }
-	spec.Namespace = namespace
-	spec.Name = name
-	result, err := pipeline.RetryPipeline(devopsClient, k8sClient, spec)
+// parseBatchOptions parses dryRun, workers and confirm query parameters
+func parseBatchOptions(request *restful.Request) common.BatchOptions {
+	workers, err := strconv.Atoi(request.QueryParameter("workers"))
 	if err != nil {
-		kdErrors.HandleInternalError(response, err)
-		return
+		workers = common.DefaultBatchWorkers
 	}
-	response.WriteHeaderAndEntity(http.StatusOK, result)
+	return common.BatchOptions{
+		DryRun:  request.QueryParameter("dryRun") == "true",
+		Workers: workers,
+		Confirm: request.QueryParameter("confirm") == "true",
+	}
+}
+
+// isFiltered returns whether the query narrows the selection down with a filter
+func isFiltered(dsQuery *dataselect.DataSelectQuery) bool {
+	return dsQuery.FilterQuery != nil && len(dsQuery.FilterQuery.FilterByList) > 0
 }
 
 func (apiHandler *APIHandler) handleAbortPipeline(request *restful.Request, response *restful.Response) {
@@ -782,6 +1473,23 @@ func (apiHandler *APIHandler) handlePipelineLogs(request *restful.Request, respo
 		step = 0
 	}
 
+	if wantsEventStream(request) {
+		stream, err := newEventStream(response)
+		if err != nil {
+			kdErrors.HandleInternalError(response, err)
+			return
+		}
+
+		done := make(chan struct{})
+		defer close(done)
+		err = pipeline.FollowLogs(devopsClient, namespace, name, start, stage, step, stream.StopOnClose(done),
+			func(details *pipeline.LogDetails) error {
+				return stream.Send("", details)
+			})
+		stream.Finish(err)
+		return
+	}
+
 	result, err := pipeline.GetLogDetails(devopsClient, namespace, name, start, stage, step)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
@@ -790,6 +1498,39 @@ func (apiHandler *APIHandler) handlePipelineLogs(request *restful.Request, respo
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+func (apiHandler *APIHandler) handleSearchPipelineLogs(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	query, err := parseLogSearchQuery(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	stage, err := strconv.Atoi(request.QueryParameter("stage"))
+	if err != nil {
+		stage = 0
+	}
+	step, err := strconv.Atoi(request.QueryParameter("step"))
+	if err != nil {
+		step = 0
+	}
+
+	result, err := pipeline.SearchLogs(devopsClient, namespace, name, stage, step, query)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
 func (apiHandler *APIHandler) handlePipelineTasks(request *restful.Request, response *restful.Response) {
 	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
 	if err != nil {
@@ -813,14 +1554,50 @@ func (apiHandler *APIHandler) handlePipelineTasks(request *restful.Request, resp
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
-// endregion
+func (apiHandler *APIHandler) handlePipelineTiming(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
 
-func (apiHandler *APIHandler) handlePipelineInput(request *restful.Request, response *restful.Response) {
-	k8sClient, err := apiHandler.cManager.Client(request)
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+
+	result, err := pipeline.GetPipelineTiming(devopsClient, namespace, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handlePipelineTimingDiff(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	name := request.PathParameter("name")
+	base := request.QueryParameter("base")
+	if base == "" {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest("base pipeline is required"))
+		return
+	}
+
+	result, err := pipeline.DiffPipelineTiming(devopsClient, namespace, name, base)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+// endregion
+
+func (apiHandler *APIHandler) handlePipelineInput(request *restful.Request, response *restful.Response) {
 	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
@@ -835,14 +1612,123 @@ func (apiHandler *APIHandler) handlePipelineInput(request *restful.Request, resp
 
 	opt.Name = request.PathParameter("name")
 	opt.Namespace = request.PathParameter("namespace")
-	result, err := pipeline.InputHandle(devopsClient, k8sClient, opt)
+	opt.User = getUserName(request)
+	// the decision is recorded by the dashboard, so that it is audited even when users cannot create events
+	result, err := pipeline.InputHandle(devopsClient, apiHandler.cManager.InsecureClient(), opt)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetPendingInputs(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespaces, nonCriticalErrors, err := apiHandler.getAccessibleNamespaces(k8sClient, request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result, err := pipeline.GetPendingInputs(devopsClient, namespaces)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	result.Errors = kdErrors.MergeErrors(result.Errors, nonCriticalErrors)
+
+	submitter := apiHandler.getInputSubmitter(request)
+	for i, input := range result.Items {
+		result.Items[i].Allowed = submitter.CanSubmit(input.Namespace) && input.CanBeSubmittedBy(submitter.User, submitter.Groups)
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleBulkPipelineInputs(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
+	opts := new(pipeline.BulkInputOptions)
+	if err := request.ReadEntity(opts); err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	// decisions are recorded by the dashboard, so that they are audited even when users cannot create events
+	result := pipeline.HandleInputs(devopsClient, apiHandler.cManager.InsecureClient(), opts,
+		apiHandler.getInputSubmitter(request))
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+// getInputSubmitter returns the user of the request deciding on inputs. Inputs are allowed when the user can
+// submit inputs in the namespace and is one of their submitters.
+func (apiHandler *APIHandler) getInputSubmitter(request *restful.Request) pipeline.InputSubmitter {
+	submitter := pipeline.InputSubmitter{}
+	if token, err := parseUser(request); err == nil {
+		submitter.User, submitter.Groups = token.Email, token.Groups
+	}
+	allowed := make(map[string]bool, 0)
+	submitter.CanSubmit = func(namespace string) bool {
+		if _, ok := allowed[namespace]; !ok {
+			allowed[namespace] = apiHandler.cManager.CanI(request, &authv1.SelfSubjectAccessReview{
+				Spec: authv1.SelfSubjectAccessReviewSpec{
+					ResourceAttributes: &authv1.ResourceAttributes{
+						Namespace:   namespace,
+						Verb:        "create",
+						Group:       v1alpha1.SchemeGroupVersion.Group,
+						Version:     v1alpha1.SchemeGroupVersion.Version,
+						Resource:    "pipelines",
+						Subresource: "input",
+					},
+				},
+			})
+		}
+		return allowed[namespace]
+	}
+	return submitter
+}
+
+// getAccessibleNamespaces returns namespaces of the project query parameter, see getProjectNamespaces,
+// namespaces given by the namespace query parameter or all namespaces the user can list
+func (apiHandler *APIHandler) getAccessibleNamespaces(client kubernetes.Interface, request *restful.Request) ([]string, []error, error) {
+	if project := request.QueryParameter("project"); project != "" {
+		return getProjectNamespaces(client, apiHandler.cManager.InsecureClient(), request, project)
+	}
+	if namespaces := parseNamespaceList(request.QueryParameter("namespace")); len(namespaces) > 0 {
+		return namespaces, make([]error, 0), nil
+	}
+
+	namespaceList, err := ns.GetNamespaceList(client, dataselect.NoDataSelect)
+	if err != nil {
+		return nil, nil, err
+	}
+	namespaces := make([]string, 0)
+	for _, item := range namespaceList.Namespaces {
+		namespaces = append(namespaces, item.ObjectMeta.Name)
+	}
+	return namespaces, make([]error, 0), nil
+}
+
+// getUserName returns email of the user in the token of the request, empty when it is not a JWT
+func getUserName(request *restful.Request) string {
+	token, err := parseUser(request)
+	if err != nil {
+		return ""
+	}
+	return token.Email
+}
+
 func (apiHandler *APIHandler) handlePipelineTestReports(request *restful.Request, response *restful.Response) {
 	k8sClient, err := apiHandler.cManager.Client(request)
 	if err != nil {
@@ -920,6 +1806,63 @@ func (apiHandler *APIHandler) handleGetToolChainBindings(request *restful.Reques
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+func (apiHandler *APIHandler) handleGetToolChainHealth(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	k8sClient := apiHandler.cManager.InsecureClient()
+
+	toolType := request.QueryParameter("tool_type")
+	brokenOnly := request.QueryParameter("broken") == "true"
+	namespaceQuery := common.NewSameNamespaceQuery(request.PathParameter("namespace"))
+	result, err := toolchain.GetToolChainHealth(devopsClient, k8sClient, namespaceQuery, toolType, brokenOnly)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleCheckToolChainBinding(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	namespace := request.PathParameter("namespace")
+	kind := request.PathParameter("kind")
+	name := request.PathParameter("name")
+	result, err := toolchain.CheckBinding(devopsClient, namespace, kind, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleCheckToolChainTool(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	k8sClient := apiHandler.cManager.InsecureClient()
+
+	kind := request.PathParameter("kind")
+	name := request.PathParameter("name")
+	result, err := toolchain.CheckToolBindings(devopsClient, k8sClient, parseNamespaceQueryParameter(request), kind, name)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
 // endregion
 
 func (apiHandler *APIHandler) handleOAuthCallback(request *restful.Request, response *restful.Response) {
@@ -1353,14 +2296,139 @@ func (apiHandler *APIHandler) handleGetPipelineStatistics(request *restful.Reque
 		return
 	}
 
-	result, err := statistics.GetPipelineStatistics(devopsClient, namespace, dataSelect, startTime, endTime)
+	options, err := parsePipelineStatisticsOptions(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+
+	result, err := statistics.GetPipelineStatistics(devopsClient, namespace, dataSelect, startTime, endTime, options)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	response.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (apiHandler *APIHandler) handleGetProjectStatistics(request *restful.Request, response *restful.Response) {
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	dataSelect := parseDataSelectPathParameter(request)
+	period := request.QueryParameter("period")
+	startTime, endTime, err := GetRecentPeriodTime(period)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	options, err := parsePipelineStatisticsOptions(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+
+	namespaces, nonCriticalErrors, err := getProjectNamespaces(k8sClient, apiHandler.cManager.InsecureClient(), request,
+		request.PathParameter("name"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	result, err := statistics.GetProjectStatistics(devopsClient, common.NewNamespaceQuery(namespaces), dataSelect, startTime, endTime, options)
 	if err != nil {
 		kdErrors.HandleInternalError(response, err)
 		return
 	}
+	result.Errors = nonCriticalErrors
 	response.WriteHeaderAndEntity(http.StatusOK, result)
 }
 
+// getProjectNamespaces returns the namespaces of the project. Users who may not list namespaces pass the
+// namespaces they can see with the namespace query parameter, each of them is then read with their own
+// access. Passed namespaces must belong to the project, which is checked with projectClient as users may
+// not list namespaces. Without it, a forbidden namespace list is returned as a non-critical error.
+func getProjectNamespaces(client, projectClient kubernetes.Interface, request *restful.Request, project string) ([]string, []error, error) {
+	if namespaces := parseNamespaceList(request.QueryParameter("namespace")); len(namespaces) > 0 {
+		namespaceList, err := projectClient.CoreV1().Namespaces().List(metav1.ListOptions{
+			LabelSelector: ns.GetprojectSelector(project).String(),
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		projectNamespaces := make([]string, 0, len(namespaceList.Items))
+		for _, item := range namespaceList.Items {
+			projectNamespaces = append(projectNamespaces, item.Name)
+		}
+		if err := checkProjectNamespaces(namespaces, projectNamespaces, project); err != nil {
+			return nil, nil, err
+		}
+		return namespaces, make([]error, 0), nil
+	}
+
+	namespaceList, err := client.CoreV1().Namespaces().List(metav1.ListOptions{
+		LabelSelector: ns.GetprojectSelector(project).String(),
+	})
+	nonCriticalErrors, criticalError := kdErrors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	namespaces := make([]string, 0)
+	if namespaceList != nil {
+		for _, item := range namespaceList.Items {
+			namespaces = append(namespaces, item.Name)
+		}
+	}
+	return namespaces, nonCriticalErrors, nil
+}
+
+// parseNamespaceList returns namespaces of a comma separated list. Empty entries are dropped, as an empty
+// namespace would select all namespaces.
+func parseNamespaceList(raw string) []string {
+	namespaces := make([]string, 0)
+	for _, namespace := range strings.Split(raw, ",") {
+		namespace = strings.TrimSpace(namespace)
+		if namespace != "" && !common.IsInSlice(namespaces, namespace) {
+			namespaces = append(namespaces, namespace)
+		}
+	}
+	return namespaces
+}
+
+// checkProjectNamespaces returns a bad request error listing the namespaces which are not in the project
+func checkProjectNamespaces(namespaces, projectNamespaces []string, project string) error {
+	outside := make([]string, 0)
+	for _, namespace := range namespaces {
+		if !common.IsInSlice(projectNamespaces, namespace) {
+			outside = append(outside, namespace)
+		}
+	}
+	if len(outside) > 0 {
+		return errorsK8s.NewBadRequest(fmt.Sprintf("namespaces %s do not belong to project %s",
+			strings.Join(outside, ", "), project))
+	}
+	return nil
+}
+
+// parsePipelineStatisticsOptions parses granularity and breakdown query parameters
+func parsePipelineStatisticsOptions(request *restful.Request) (options statistics.PipelineStatisticsOptions, err error) {
+	options.Granularity, err = statistics.ParseGranularity(request.QueryParameter("granularity"))
+	if err != nil {
+		return
+	}
+	if breakdown := request.QueryParameter("breakdown"); breakdown != "" {
+		options.Breakdowns = strings.Split(breakdown, ",")
+	}
+	return
+}
+
 func (apiHandler *APIHandler) handleGetStageStatistics(request *restful.Request, response *restful.Response) {
 	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
 	if err != nil {
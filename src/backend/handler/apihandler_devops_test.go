@@ -8,6 +8,7 @@ import (
 	"alauda.io/diablo/src/backend/settings"
 	"github.com/emicklei/go-restful"
 	"github.com/stretchr/testify/assert"
+	errorsK8s "k8s.io/apimachinery/pkg/api/errors"
 )
 
 func getAPIHandler() APIHandler {
@@ -86,3 +87,46 @@ func TestHandlePipelinetemplatecategories(t *testing.T) {
 		})
 	}
 }
+
+func TestParseNamespaceList(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		expected []string
+	}{
+		{name: "empty", input: "", expected: []string{}},
+		{name: "trailing comma", input: "a,", expected: []string{"a"}},
+		{name: "spaces and duplicates", input: " a , b,,a ", expected: []string{"a", "b"}},
+		{name: "only separators", input: " , ,", expected: []string{}},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, parseNamespaceList(test.input))
+		})
+	}
+}
+
+func TestCheckProjectNamespaces(t *testing.T) {
+	projectNamespaces := []string{"a-dev", "a-prod"}
+
+	tests := []struct {
+		name       string
+		namespaces []string
+		valid      bool
+	}{
+		{name: "project namespaces", namespaces: []string{"a-prod", "a-dev"}, valid: true},
+		{name: "namespace of another project", namespaces: []string{"a-dev", "b-dev"}, valid: false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := checkProjectNamespaces(test.namespaces, projectNamespaces, "a")
+			if test.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.True(t, errorsK8s.IsBadRequest(err), "expected bad request, got %v", err)
+			}
+		})
+	}
+}
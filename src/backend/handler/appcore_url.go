@@ -2,6 +2,7 @@ package handler
 
 import (
 	"alauda.io/diablo/src/backend/resource/application"
+	"alauda.io/diablo/src/backend/resource/logs"
 	"github.com/emicklei/go-restful"
 	"net/http"
 )
@@ -56,6 +57,17 @@ func AddAppCoreUrl(apiV1Ws *restful.WebService, apiHandler APIHandler) {
 			Doc("get application details").
 			Returns(200, "OK", application.ApplicationYAML{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.GET("/applications/{namespace}/{name}/logs").
+			To(apiHandler.handleAggregatedLogs(getApplicationLogPods)).
+			Writes(logs.LogDetails{}).
+			Doc("get logs merged from all pods of the application"))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/applications/{namespace}/{name}/logs/file").
+			To(apiHandler.handleAggregatedLogFile(getApplicationLogPods)).
+			Doc("download logs merged from all pods of the application"))
+
 	apiV1Ws.Route(
 		apiV1Ws.PUT("/applications/{namespace}/{name}/yaml").
 			To(apiHandler.handleUpdateApplicationYAML).
@@ -0,0 +1,114 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+
+	restful "github.com/emicklei/go-restful"
+)
+
+// mimeEventStream is the content type of server-sent events.
+const mimeEventStream = "text/event-stream"
+
+// eventEnd is sent when the stream is finished and the client should not reconnect.
+const eventEnd = "end"
+
+// eventStream writes server-sent events to the response. Every event is flushed immediately.
+type eventStream struct {
+	response *restful.Response
+	flusher  http.Flusher
+	closed   <-chan bool
+}
+
+// wantsEventStream returns true if the client asked to follow the resource with a stream of
+// server-sent events instead of a single response.
+func wantsEventStream(request *restful.Request) bool {
+	return request.QueryParameter("follow") == "true"
+}
+
+// newEventStream writes headers of server-sent events response. Nothing else should be written to
+// the response by the caller afterwards.
+func newEventStream(response *restful.Response) (*eventStream, error) {
+	flusher, ok := response.ResponseWriter.(http.Flusher)
+	if !ok {
+		return nil, fmt.Errorf("streaming is not supported")
+	}
+
+	var closed <-chan bool
+	if notifier, ok := response.ResponseWriter.(http.CloseNotifier); ok {
+		closed = notifier.CloseNotify()
+	}
+
+	response.AddHeader(restful.HEADER_ContentType, mimeEventStream)
+	response.AddHeader("Cache-Control", "no-cache")
+	response.AddHeader("X-Accel-Buffering", "no")
+	response.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	return &eventStream{response: response, flusher: flusher, closed: closed}, nil
+}
+
+// Send writes JSON encoded data as a single event. Events without name are delivered to
+// EventSource.onmessage.
+func (self *eventStream) Send(event string, data interface{}) error {
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+
+	if len(event) > 0 {
+		if _, err := fmt.Fprintf(self.response, "event: %s\n", event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(self.response, "data: %s\n\n", encoded); err != nil {
+		return err
+	}
+	self.flusher.Flush()
+	return nil
+}
+
+// Finish sends the end event, so the client closes the stream instead of reconnecting. Error that
+// stopped the stream, if any, is passed in the event.
+func (self *eventStream) Finish(err error) {
+	end := struct {
+		Error string `json:"error,omitempty"`
+	}{}
+	if err != nil {
+		log.Printf("Event stream finished with error: %v", err)
+		end.Error = err.Error()
+	}
+
+	if err := self.Send(eventEnd, end); err != nil {
+		log.Printf("Failed to send end of event stream: %v", err)
+	}
+}
+
+// StopOnClose returns channel that is closed when the client goes away or when done is closed.
+func (self *eventStream) StopOnClose(done <-chan struct{}) <-chan struct{} {
+	stopCh := make(chan struct{})
+	go func() {
+		defer close(stopCh)
+		select {
+		case <-self.closed:
+		case <-done:
+		}
+	}()
+	return stopCh
+}
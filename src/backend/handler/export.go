@@ -0,0 +1,223 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"log"
+	"net/http"
+	"strings"
+
+	kdErrors "alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/export"
+	"alauda.io/diablo/src/backend/resource/pipeline"
+	"alauda.io/diablo/src/backend/resource/pipelineconfig"
+	"alauda.io/diablo/src/backend/resource/statistics"
+	restful "github.com/emicklei/go-restful"
+	errorsK8s "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// newExportWriter writes headers of an export attachment and returns a writer of the requested format.
+// Nothing else should be written to the response by the caller afterwards.
+func newExportWriter(response *restful.Response, format export.Format, filename string, columns []string) (export.Writer, error) {
+	response.AddHeader(restful.HEADER_ContentType, format.ContentType())
+	response.AddHeader("Content-Disposition", export.ContentDisposition(filename+"."+format.Extension()))
+	response.WriteHeader(http.StatusOK)
+	return export.NewWriter(response, format, columns)
+}
+
+// exportFilename returns the name of the exported file for the resource in the namespaces
+func exportFilename(resource string, namespace *common.NamespaceQuery) string {
+	if ns := namespace.ToRequestParam(); ns != "" {
+		return resource + "-" + ns
+	}
+	return resource
+}
+
+func (apiHandler *APIHandler) handleExportPipelines(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	format, err := export.ParseFormat(request.QueryParameter("format"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+	columns, err := export.ParseColumns(request.QueryParameter("columns"), pipeline.ExportColumns, pipeline.ExportColumns)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+
+	// exports are not paginated, filters and sorting are applied as in the list
+	dataSelect := parseDataSelectPathParameter(request)
+	namespace := parseNamespacePathParameter(request)
+	opened := false
+	err = pipeline.ExportPipelineList(devopsClient, namespace, dataSelect, columns, func() (export.Writer, error) {
+		opened = true
+		return newExportWriter(response, format, exportFilename("pipelines", namespace), columns)
+	})
+	switch {
+	case err != nil && !opened:
+		kdErrors.HandleInternalError(response, err)
+	case err != nil:
+		log.Printf("Failed to export pipelines: %v", err)
+	}
+}
+
+func (apiHandler *APIHandler) handleExportPipelineStatistics(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	format, err := export.ParseFormat(request.QueryParameter("format"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+	options, err := parsePipelineStatisticsOptions(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+	startTime, endTime, err := GetRecentPeriodTime(request.QueryParameter("period"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	dataSelect := parseDataSelectPathParameter(request)
+	dataSelect.PaginationQuery = dataselect.NoPagination
+	namespace := parseNamespacePathParameter(request)
+	result, err := statistics.GetPipelineStatistics(devopsClient, namespace, dataSelect, startTime, endTime, options)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	writer, err := newExportWriter(response, format, exportFilename("pipeline-statistics", namespace), statistics.PipelineStatisticsColumns)
+	if err == nil {
+		err = statistics.ExportPipelineStatistics(writer, result)
+	}
+	if err != nil {
+		log.Printf("Failed to export pipeline statistics: %v", err)
+	}
+}
+
+func (apiHandler *APIHandler) handleExportStageStatistics(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	format, err := export.ParseFormat(request.QueryParameter("format"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+	startTime, endTime, err := GetRecentPeriodTime(request.QueryParameter("period"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	dataSelect := parseDataSelectPathParameter(request)
+	dataSelect.PaginationQuery = dataselect.NoPagination
+	namespace := parseNamespacePathParameter(request)
+	result, err := statistics.GetStageStatistics(devopsClient, namespace, dataSelect, startTime, endTime)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	writer, err := newExportWriter(response, format, exportFilename("stage-statistics", namespace), statistics.StageStatisticsColumns)
+	if err == nil {
+		err = statistics.ExportStageStatistics(writer, result)
+	}
+	if err != nil {
+		log.Printf("Failed to export stage statistics: %v", err)
+	}
+}
+
+func (apiHandler *APIHandler) handleExportPipelineConfigs(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	names := make([]string, 0)
+	for _, name := range strings.Split(request.QueryParameter("names"), ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+	namespace := request.PathParameter("namespace")
+	bundle, err := pipelineconfig.ExportPipelineConfigs(devopsClient, namespace, names)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+	raw, err := pipelineconfig.MarshalBundle(bundle)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	response.AddHeader(restful.HEADER_ContentType, "application/x-yaml")
+	response.AddHeader("Content-Disposition", export.ContentDisposition("pipelineconfigs-"+namespace+".yaml"))
+	response.WriteHeader(http.StatusOK)
+	if _, err := response.Write(raw); err != nil {
+		log.Printf("Failed to export pipeline configs: %v", err)
+	}
+}
+
+func (apiHandler *APIHandler) handleExportCodeQualityStatistics(request *restful.Request, response *restful.Response) {
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	format, err := export.ParseFormat(request.QueryParameter("format"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, errorsK8s.NewBadRequest(err.Error()))
+		return
+	}
+
+	dataSelect := parseDataSelectPathParameter(request)
+	dataSelect.PaginationQuery = dataselect.NoPagination
+	namespace := parseNamespacePathParameter(request)
+	result, err := statistics.GetCodeQualityStatistics(devopsClient, namespace, dataSelect)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	writer, err := newExportWriter(response, format, exportFilename("codequality-statistics", namespace), statistics.CodeQualityStatisticsColumns)
+	if err == nil {
+		err = statistics.ExportCodeQualityStatistics(writer, result)
+	}
+	if err != nil {
+		log.Printf("Failed to export code quality statistics: %v", err)
+	}
+}
@@ -57,10 +57,12 @@ import (
 	"alauda.io/diablo/src/backend/resource/replicaset"
 	"alauda.io/diablo/src/backend/resource/rolebinding"
 	"alauda.io/diablo/src/backend/resource/secret"
+	"alauda.io/diablo/src/backend/resource/statistics"
 	"alauda.io/diablo/src/backend/resource/storageclass"
 	"alauda.io/diablo/src/backend/resource/testtool"
 	"alauda.io/diablo/src/backend/resource/testtoolbinding"
 	"alauda.io/diablo/src/backend/resource/toolchain"
+	"alauda.io/diablo/src/backend/resource/watch"
 	"alauda.io/diablo/src/backend/settings"
 	"alauda.io/diablo/src/backend/systembanner"
 	"alauda.io/diablo/src/backend/thirdparty"
@@ -387,6 +389,13 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 		apiV1Ws.GET("/deployment/{namespace}/{deployment}/pods").
 			To(apiHandler.handleGetDeploymentPods).
 			Writes(pod.PodList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/deployment/{namespace}/{deployment}/logs").
+			To(apiHandler.handleAggregatedLogs(getDeploymentLogPods)).
+			Writes(logs.LogDetails{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/deployment/{namespace}/{deployment}/logs/file").
+			To(apiHandler.handleAggregatedLogFile(getDeploymentLogPods)))
 	apiV1Ws.Route(
 		apiV1Ws.GET("/deployment/{namespace}/{deployment}/oldreplicaset").
 			To(apiHandler.handleGetDeploymentOldReplicaSets).
@@ -439,6 +448,13 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 		apiV1Ws.GET("/daemonset/{namespace}/{daemonset}/pods").
 			To(apiHandler.handleGetDaemonSetPods).
 			Writes(pod.PodList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/daemonset/{namespace}/{daemonset}/logs").
+			To(apiHandler.handleAggregatedLogs(getDaemonSetLogPods)).
+			Writes(logs.LogDetails{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/daemonset/{namespace}/{daemonset}/logs/file").
+			To(apiHandler.handleAggregatedLogFile(getDaemonSetLogPods)))
 	apiV1Ws.Route(
 		apiV1Ws.PUT("/daemonset/{namespace}/{daemonset}/container/{container}/").
 			To(apiHandler.handlePutDaemonSetContainer).
@@ -597,14 +613,32 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			To(apiHandler.handleCreateSecret).
 			Reads(secret.SecretDetail{}).
 			Writes(secret.Secret{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secret/{name}/impact").
+			To(apiHandler.handleGetSecretImpact).
+			Writes(secret.SecretImpact{}).
+			Doc("list resources which break when the global secret is deleted").
+			Returns(200, "OK", secret.SecretImpact{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secret/{namespace}/{name}/impact").
+			To(apiHandler.handleGetSecretImpact).
+			Writes(secret.SecretImpact{}).
+			Doc("list resources which break when the secret is deleted").
+			Returns(200, "OK", secret.SecretImpact{}))
 	apiV1Ws.Route(
 		apiV1Ws.DELETE("/secret/{name}").
+			Param(restful.QueryParameter("dryRun", "Only return the impact of the deletion when true")).
+			Param(restful.QueryParameter("force", "Delete the secret even if resources reference it or could not be checked when true")).
 			To(apiHandler.handleDeleteSecret).
-			Writes(secret.SecretDetail{}))
+			Writes(secret.SecretImpact{}).
+			Returns(409, "Referenced by resources or not all resources could be checked", secret.SecretImpact{}))
 	apiV1Ws.Route(
 		apiV1Ws.DELETE("/secret/{namespace}/{name}").
+			Param(restful.QueryParameter("dryRun", "Only return the impact of the deletion when true")).
+			Param(restful.QueryParameter("force", "Delete the secret even if resources reference it or could not be checked when true")).
 			To(apiHandler.handleDeleteSecret).
-			Writes(secret.SecretDetail{}))
+			Writes(secret.SecretImpact{}).
+			Returns(409, "Referenced by resources or not all resources could be checked", secret.SecretImpact{}))
 	apiV1Ws.Route(
 		apiV1Ws.POST("/secret/{namespace}/{name}/actions/tradeapp").
 			To(apiHandler.handleUpdateSecretBelongApp).
@@ -612,6 +646,41 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Writes(secret.SecretDetail{}).
 			Doc("update secret belongs app").
 			Returns(200, "OK", secret.SecretDetail{}))
+	apiV1Ws.Route(
+		apiV1Ws.POST("/secret/{namespace}/{name}/actions/rotate").
+			To(apiHandler.handleRotateSecret).
+			Reads(secret.SecretRotation{}).
+			Writes(secret.SecretRotationResult{}).
+			Doc("rotate credentials of secret after validating them against every referencing tool, reverting when verification fails").
+			Returns(200, "OK", secret.SecretRotationResult{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secrets/expiring").
+			Param(restful.QueryParameter("days", "Secrets expiring within days, expired ones included. Defaults to 30")).
+			To(apiHandler.handleGetExpiringSecrets).
+			Writes(secret.SecretUsageList{}).
+			Doc("list secrets expiring soon with the resources using them").
+			Returns(200, "OK", secret.SecretUsageList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secrets/expiring/{namespace}").
+			Param(restful.PathParameter("namespace", "Comma separated namespaces")).
+			Param(restful.QueryParameter("days", "Secrets expiring within days, expired ones included. Defaults to 30")).
+			To(apiHandler.handleGetExpiringSecrets).
+			Writes(secret.SecretUsageList{}).
+			Doc("list secrets expiring soon in namespaces with the resources using them").
+			Returns(200, "OK", secret.SecretUsageList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secrets/unused").
+			To(apiHandler.handleGetUnusedSecrets).
+			Writes(secret.SecretUsageList{}).
+			Doc("list secrets no resource references").
+			Returns(200, "OK", secret.SecretUsageList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/secrets/unused/{namespace}").
+			Param(restful.PathParameter("namespace", "Comma separated namespaces")).
+			To(apiHandler.handleGetUnusedSecrets).
+			Writes(secret.SecretUsageList{}).
+			Doc("list secrets no resource references in namespaces").
+			Returns(200, "OK", secret.SecretUsageList{}))
 	// endregion
 
 	// region Configmap
@@ -719,6 +788,13 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 		apiV1Ws.GET("/statefulset/{namespace}/{statefulset}/pods").
 			To(apiHandler.handleGetStatefulSetPods).
 			Writes(pod.PodList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/statefulset/{namespace}/{statefulset}/logs").
+			To(apiHandler.handleAggregatedLogs(getStatefulSetLogPods)).
+			Writes(logs.LogDetails{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/statefulset/{namespace}/{statefulset}/logs/file").
+			To(apiHandler.handleAggregatedLogFile(getStatefulSetLogPods)))
 	apiV1Ws.Route(
 		apiV1Ws.PUT("/statefulset/{namespace}/{statefulset}/container/{container}/").
 			To(apiHandler.handlePutStatefulSetContainer).
@@ -891,6 +967,21 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 		apiV1Ws.GET("/log/{namespace}/{pod}/{container}").
 			To(apiHandler.handleLogs).
 			Writes(logs.LogDetails{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/log/search/{namespace}/{pod}").
+			To(apiHandler.handleSearchLogs).
+			Writes(logs.SearchResult{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/log/search/{namespace}/{pod}/{container}").
+			Param(restful.QueryParameter("q", "Text to search for")).
+			Param(restful.QueryParameter("regex", "Whether q is a regular expression")).
+			Param(restful.QueryParameter("caseSensitive", "Whether the search is case sensitive")).
+			Param(restful.QueryParameter("before", "Number of context lines before every match")).
+			Param(restful.QueryParameter("after", "Number of context lines after every match")).
+			Param(restful.QueryParameter("since", "Only lines logged at or after this RFC3339 time are searched")).
+			Param(restful.QueryParameter("until", "Only lines logged at or before this RFC3339 time are searched")).
+			To(apiHandler.handleSearchLogs).
+			Writes(logs.SearchResult{}))
 	//
 	apiV1Ws.Route(
 		apiV1Ws.GET("/log/file/{namespace}/{pod}/{container}").
@@ -1104,6 +1195,26 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Doc("get chart detail"))
 
 	// region PipelineTemplate
+	apiV1Ws.Route(
+		apiV1Ws.GET("/clusterpipelinetemplate/{name}/upgrades").
+			Param(restful.PathParameter("name", "ClusterPipelineTemplate name")).
+			Param(restful.QueryParameter("namespace", "Comma separated namespaces of pipeline configs. All when empty")).
+			To(apiHandler.handleGetClusterPipelineTemplateUpgrades).
+			Doc("lists pipeline configs using an outdated version of the clusterpipelinetemplate with their argument changes").
+			Returns(200, "OK", pipelineconfig.TemplateUpgradeList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/clusterpipelinetemplate/{name}/upgrades").
+			Param(restful.PathParameter("name", "ClusterPipelineTemplate name")).
+			Param(restful.QueryParameter("namespace", "Comma separated namespaces of pipeline configs. All when empty")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected pipeline configs when true")).
+			Param(restful.QueryParameter("workers", "Number of pipeline configs upgraded concurrently. Defaults to 5")).
+			Param(restful.QueryParameter("confirm", "Required to be true when the body is empty")).
+			To(apiHandler.handleUpgradeClusterPipelineTemplateConfigs).
+			Reads([]common.BatchTarget{}).
+			Doc("upgrades pipeline configs to the latest version of the clusterpipelinetemplate, all outdated ones when the body is empty and confirm is true").
+			Returns(200, "OK", common.BatchResult{}))
+
 	// PipelineTemplateSync
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipelinetemplatesync/{namespace}").
@@ -1264,6 +1375,26 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Doc("get the exports in pipelinetemplate").
 			Returns(200, "OK", clusterpipelinetemplate.PipelineExportedVariables{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelinetemplate/{namespace}/{name}/upgrades").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineTemplate name")).
+			To(apiHandler.handleGetPipelineTemplateUpgrades).
+			Doc("lists pipeline configs using an outdated version of the pipelinetemplate with their argument changes").
+			Returns(200, "OK", pipelineconfig.TemplateUpgradeList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelinetemplate/{namespace}/{name}/upgrades").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineTemplate name")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected pipeline configs when true")).
+			Param(restful.QueryParameter("workers", "Number of pipeline configs upgraded concurrently. Defaults to 5")).
+			Param(restful.QueryParameter("confirm", "Required to be true when the body is empty")).
+			To(apiHandler.handleUpgradePipelineTemplateConfigs).
+			Reads([]common.BatchTarget{}).
+			Doc("upgrades pipeline configs to the latest version of the pipelinetemplate, all outdated ones when the body is empty and confirm is true").
+			Returns(200, "OK", common.BatchResult{}))
+
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipelinetemplatecategories/{namespace}").
 			To(apiHandler.handlePipelinetemplatecategories).
@@ -1313,9 +1444,185 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/trigger").
 			To(apiHandler.handleTriggerPipelineConfig).
 			Writes(pipelineconfig.PipelineConfigTrigger{}).
-			Doc("triggers pipeline").
+			Doc("triggers pipeline, invalid parameters are rejected unless skipValidation is set").
 			Returns(200, "OK", pipelineconfig.PipelineTriggerResponse{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/trigger/branches").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "Multi-branch PipelineConfig name")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected branches and pull requests when true")).
+			Param(restful.QueryParameter("confirm", "Required to be true when pattern is not given")).
+			To(apiHandler.handleTriggerMultiBranchPipelineConfig).
+			Reads(pipelineconfig.MultiBranchTrigger{}).
+			Doc("triggers selected branches and pull requests of a multi-branch pipeline config").
+			Returns(200, "OK", pipelineconfig.MultiBranchTriggerResponse{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/trigger/validate").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			To(apiHandler.handleValidatePipelineConfigParameters).
+			Reads(pipelineconfig.PipelineConfigTrigger{}).
+			Doc("validates trigger parameters and returns them with presets and defaults filled").
+			Returns(200, "OK", pipelineconfig.ParameterValidationResponse{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/presets").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			To(apiHandler.handleGetParameterPresets).
+			Doc("gets parameter presets of pipeline config").
+			Returns(200, "OK", pipelineconfig.ParameterPresets{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.PUT("/pipelineconfig/{namespace}/{name}/presets/{preset}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("preset", "Preset name")).
+			To(apiHandler.handleSaveParameterPreset).
+			Reads(map[string]string{}).
+			Doc("creates or replaces a parameter preset of pipeline config").
+			Returns(200, "OK", pipelineconfig.ParameterPresets{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.DELETE("/pipelineconfig/{namespace}/{name}/presets/{preset}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("preset", "Preset name")).
+			To(apiHandler.handleDeleteParameterPreset).
+			Doc("deletes a parameter preset of pipeline config").
+			Returns(200, "OK", pipelineconfig.ParameterPresets{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/upgrade").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			To(apiHandler.handlePreviewTemplateUpgrade).
+			Doc("previews the upgrade of pipeline config to the latest version of its template with the re-rendered jenkinsfile").
+			Returns(200, "OK", pipelineconfig.TemplateUpgradePreview{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/clone").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			To(apiHandler.handleClonePipelineConfig).
+			Reads(pipelineconfig.PipelineConfigClone{}).
+			Doc("creates a copy of pipeline config in the same or another namespace with overridden name, repository, branch and template parameters").
+			Returns(200, "OK", v1alpha1.PipelineConfig{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/import").
+			Param(restful.PathParameter("namespace", "Namespace to import to")).
+			Param(restful.QueryParameter("dryRun", "Only preview the import when true")).
+			To(apiHandler.handleImportPipelineConfigs).
+			Reads(pipelineconfig.BundleImportOptions{}).
+			Doc("imports pipeline configs of a yaml bundle, remapping its placeholders to resources of the namespace").
+			Returns(200, "OK", pipelineconfig.BundleImportResult{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/revisions").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			To(apiHandler.handleGetPipelineConfigRevisions).
+			Doc("gets revisions of pipeline config, the newest first").
+			Returns(200, "OK", pipelineconfig.PipelineConfigRevisionList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/revisions/{revision}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("revision", "Revision number")).
+			To(apiHandler.handleGetPipelineConfigRevision).
+			Doc("gets a revision of pipeline config with its spec").
+			Returns(200, "OK", pipelineconfig.PipelineConfigRevision{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/revisions/{revision}/diff/{to}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("revision", "Revision number to diff from")).
+			Param(restful.PathParameter("to", "Revision number to diff to")).
+			To(apiHandler.handleDiffPipelineConfigRevisions).
+			Doc("gets diffs of the spec and the rendered jenkinsfile between two revisions of pipeline config").
+			Returns(200, "OK", pipelineconfig.PipelineConfigRevisionDiff{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/revisions/{revision}/rollback").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("revision", "Revision number")).
+			To(apiHandler.handleRollbackPipelineConfig).
+			Doc("rolls pipeline config back to the spec of a revision").
+			Returns(200, "OK", pipelineconfig.PipelineConfigRevisionList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/schedules").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.QueryParameter("count", "Number of next fire times of every schedule. At most 100, defaults to 5")).
+			To(apiHandler.handleGetSchedules).
+			Doc("gets cron schedules of pipeline config with their next fire times").
+			Returns(200, "OK", []pipelineconfig.PipelineScheduleDetail{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.PUT("/pipelineconfig/{namespace}/{name}/schedules/{schedule}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("schedule", "Schedule name")).
+			To(apiHandler.handleSaveSchedule).
+			Reads(pipelineconfig.PipelineSchedule{}).
+			Doc("creates or replaces a cron schedule of pipeline config").
+			Returns(200, "OK", pipelineconfig.PipelineSchedule{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.DELETE("/pipelineconfig/{namespace}/{name}/schedules/{schedule}").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("schedule", "Schedule name")).
+			To(apiHandler.handleDeleteSchedule).
+			Doc("deletes a cron schedule of pipeline config").
+			Returns(200, "OK", []pipelineconfig.PipelineScheduleDetail{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.PUT("/pipelineconfig/{namespace}/{name}/schedules/{schedule}/pause").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("schedule", "Schedule name")).
+			To(apiHandler.handlePauseSchedule).
+			Doc("pauses a cron schedule of pipeline config").
+			Returns(200, "OK", []pipelineconfig.PipelineScheduleDetail{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.PUT("/pipelineconfig/{namespace}/{name}/schedules/{schedule}/resume").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("schedule", "Schedule name")).
+			To(apiHandler.handleResumeSchedule).
+			Doc("resumes a cron schedule of pipeline config, fire times missed while paused are skipped").
+			Returns(200, "OK", []pipelineconfig.PipelineScheduleDetail{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/schedules/{schedule}/run").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name")).
+			Param(restful.PathParameter("schedule", "Schedule name")).
+			To(apiHandler.handleRunSchedule).
+			Doc("triggers pipeline config with branch and parameters of a cron schedule now").
+			Returns(200, "OK", pipelineconfig.PipelineTriggerResponse{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineconfig/{namespace}/trigger").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.QueryParameter("filterBy", "Filter to select pipeline configs, e.g. name,build")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected pipeline configs when true")).
+			Param(restful.QueryParameter("workers", "Number of pipeline configs triggered concurrently. Defaults to 5")).
+			Param(restful.QueryParameter("confirm", "Required to be true when filterBy is not given")).
+			To(apiHandler.handleTriggerPipelineConfigs).
+			Writes(pipelineconfig.PipelineConfigTrigger{}).
+			Doc("triggers every selected pipeline config").
+			Returns(200, "OK", common.BatchResult{}))
+
 	apiV1Ws.Route(
 		apiV1Ws.POST("/pipelineconfig/{namespace}/{name}/preview").
 			To(apiHandler.handlePreviewPipelineConfig).
@@ -1334,10 +1641,22 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Param(restful.PathParameter("namespace", "Namespace to use")).
 			Param(restful.PathParameter("name", "Pipeline name to filter scope")).
 			Param(restful.QueryParameter("start", "Start offset to fetch logs")).
+			Param(restful.QueryParameter("follow", "Stream new logs as server-sent events until the scan is finished")).
+			Produces(restful.MIME_JSON, mimeEventStream).
 			To(apiHandler.handlePipelineConfigLogs).
 			Doc("gets scan logs for multi-branch pipeline").
 			Returns(200, "OK", v1alpha1.PipelineConfigLog{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineconfig/{namespace}/{name}/testhistory").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "PipelineConfig name to filter scope")).
+			Param(restful.QueryParameter("runs", "Number of the last finished runs to walk, at most 50. Defaults to 10")).
+			Param(restful.QueryParameter("slowest", "Number of slowest tests to return. Defaults to 10")).
+			To(apiHandler.handlePipelineConfigTestHistory).
+			Doc("gets history of test cases in the last runs of pipeline config").
+			Returns(200, "OK", pipelineconfig.TestHistory{}))
+
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipeline/{namespace}").
 			To(apiHandler.handleGetPipelineList).
@@ -1345,6 +1664,30 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Doc("get namespaced pipeline list").
 			Returns(200, "OK", pipeline.PipelineList{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipeline/{namespace}/abort").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.QueryParameter("filterBy", "Filter to select pipelines, e.g. status,Running or pipelineConfig,build")).
+			Param(restful.QueryParameter("period", "Only select pipelines created in the period, e.g. -1h")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected pipelines when true")).
+			Param(restful.QueryParameter("workers", "Number of pipelines processed concurrently. Defaults to 5")).
+			Param(restful.QueryParameter("confirm", "Required to be true when neither filterBy nor period is given")).
+			To(apiHandler.handleAbortPipelines).
+			Doc("aborts every selected running pipeline").
+			Returns(200, "OK", common.BatchResult{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipeline/{namespace}/retry").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.QueryParameter("filterBy", "Filter to select pipelines, e.g. status,Running or pipelineConfig,build")).
+			Param(restful.QueryParameter("period", "Only select pipelines created in the period, e.g. -1h")).
+			Param(restful.QueryParameter("dryRun", "Only return the selected pipelines when true")).
+			Param(restful.QueryParameter("workers", "Number of pipelines processed concurrently. Defaults to 5")).
+			Param(restful.QueryParameter("confirm", "Required to be true when neither filterBy nor period is given")).
+			To(apiHandler.handleRetryPipelines).
+			Doc("retries every selected failed or aborted pipeline").
+			Returns(200, "OK", common.BatchResult{}))
+
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipeline/{namespace}/{name}").
 			Param(restful.QueryParameter("withFreshStages", "Whether to retrieve newest stages from Jenkins")).
@@ -1380,10 +1723,44 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Param(restful.QueryParameter("start", "Start offset to fetch logs")).
 			Param(restful.QueryParameter("stage", "Stage to fetch logs from")).
 			Param(restful.QueryParameter("step", "Step to fetch logs from. Can be combined with stage")).
+			Param(restful.QueryParameter("follow", "Stream new logs as server-sent events until the pipeline is finished")).
+			Produces(restful.MIME_JSON, mimeEventStream).
 			To(apiHandler.handlePipelineLogs).
 			Doc("gets logs for pipeline").
 			Returns(200, "OK", v1alpha1.PipelineLog{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipeline/{namespace}/{name}/logs/search").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "Pipeline name to filter scope")).
+			Param(restful.QueryParameter("q", "Text to search for")).
+			Param(restful.QueryParameter("regex", "Whether q is a regular expression")).
+			Param(restful.QueryParameter("caseSensitive", "Whether the search is case sensitive")).
+			Param(restful.QueryParameter("before", "Number of context lines before every match")).
+			Param(restful.QueryParameter("after", "Number of context lines after every match")).
+			Param(restful.QueryParameter("stage", "Stage to search logs of")).
+			Param(restful.QueryParameter("step", "Step to search logs of. Can be combined with stage")).
+			To(apiHandler.handleSearchPipelineLogs).
+			Doc("searches logs of pipeline").
+			Returns(200, "OK", logs.SearchResult{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipeline/{namespace}/{name}/timing").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "Pipeline name to filter scope")).
+			To(apiHandler.handlePipelineTiming).
+			Doc("gets stage and step tree of pipeline with timing").
+			Returns(200, "OK", pipeline.PipelineTiming{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipeline/{namespace}/{name}/timing/diff").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "Pipeline name to filter scope")).
+			Param(restful.QueryParameter("base", "Pipeline of the same pipeline config to compare with")).
+			To(apiHandler.handlePipelineTimingDiff).
+			Doc("compares stage durations of pipeline with another run of the same pipeline config").
+			Returns(200, "OK", pipeline.TimingDiff{}))
+
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipeline/{namespace}/{name}/tasks").
 			Param(restful.PathParameter("namespace", "Namespace to use")).
@@ -1398,6 +1775,20 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Writes(pipeline.InputOptions{}).
 			Doc("response a input request which in a pipeline").
 			Returns(200, "OK", pipeline.InputResponse{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/pipelineinput").
+			Param(restful.QueryParameter("namespace", "Comma separated namespaces to look in")).
+			Param(restful.QueryParameter("project", "Project whose namespaces are looked in. Defaults to all namespaces the user can list")).
+			To(apiHandler.handleGetPendingInputs).
+			Doc("lists inputs of running pipelines waiting for a decision").
+			Returns(200, "OK", pipeline.PendingInputList{}))
+	apiV1Ws.Route(
+		apiV1Ws.POST("/pipelineinput").
+			To(apiHandler.handleBulkPipelineInputs).
+			Reads(pipeline.BulkInputOptions{}).
+			Doc("approves or rejects several pending inputs, inputs which are not pending or which the user may not "+
+				"submit fail. Every decision is recorded as an event of the pipeline").
+			Returns(200, "OK", common.BatchResult{}))
 	apiV1Ws.Route(
 		apiV1Ws.GET("/pipeline/{namespace}/{name}/testreports").
 			Param(restful.QueryParameter("start", "Start offset to fetch test report items")).
@@ -1499,6 +1890,41 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			To(apiHandler.HandleGetCodeRepositoryBranches).
 			Returns(200, "Get coderepo branch Successful", v1alpha1.CodeRepoBranchResult{}))
 
+	apiV1Ws.Route(
+		apiV1Ws.GET("/coderepository/{namespace}/{name}/commits").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "CodeRepository name")).
+			Param(restful.QueryParameter("branch", "Branch of the commits, the default branch when empty")).
+			Param(restful.QueryParameter("page", "Page to return, starting from 1")).
+			Param(restful.QueryParameter("itemsPerPage", "Commits per page, 20 by default and 100 at most")).
+			To(apiHandler.handleGetCodeRepositoryCommits).
+			Writes(coderepository.RepositoryCommitList{}).
+			Doc("browse commits of a branch of a code repository").
+			Returns(200, "OK", coderepository.RepositoryCommitList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/coderepository/{namespace}/{name}/tags").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "CodeRepository name")).
+			Param(restful.QueryParameter("page", "Page to return, starting from 1")).
+			Param(restful.QueryParameter("itemsPerPage", "Tags per page, 20 by default and 100 at most")).
+			To(apiHandler.handleGetCodeRepositoryTags).
+			Writes(coderepository.RepositoryTagList{}).
+			Doc("browse tags of a code repository").
+			Returns(200, "OK", coderepository.RepositoryTagList{}))
+
+	apiV1Ws.Route(
+		apiV1Ws.GET("/coderepository/{namespace}/{name}/pullrequests").
+			Param(restful.PathParameter("namespace", "Namespace to use")).
+			Param(restful.PathParameter("name", "CodeRepository name")).
+			Param(restful.QueryParameter("state", "State of the pull requests, one of open, merged, closed or all. open by default")).
+			Param(restful.QueryParameter("page", "Page to return, starting from 1")).
+			Param(restful.QueryParameter("itemsPerPage", "Pull requests per page, 20 by default and 100 at most")).
+			To(apiHandler.handleGetCodeRepositoryPullRequests).
+			Writes(coderepository.RepositoryPullRequestList{}).
+			Doc("browse pull requests of a code repository").
+			Returns(200, "OK", coderepository.RepositoryPullRequestList{}))
+
 	// endregion
 
 	// region ToolChain
@@ -1520,6 +1946,37 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 			Writes(toolchain.ToolChainBindingList{}).
 			Doc("get namespaced toolchain binding list").
 			Returns(200, "OK", coderepository.CodeRepositoryList{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/toolchain/health").
+			Param(restful.QueryParameter("tool_type", "Only tools of the toolchain type")).
+			Param(restful.QueryParameter("broken", "Only return broken bindings when true")).
+			To(apiHandler.handleGetToolChainHealth).
+			Doc("get last-known health of toolchain tools and their bindings").
+			Returns(200, "OK", toolchain.ToolChainHealth{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/toolchain/health/{namespace}").
+			Param(restful.PathParameter("namespace", "Namespace of the bindings")).
+			Param(restful.QueryParameter("tool_type", "Only tools of the toolchain type")).
+			Param(restful.QueryParameter("broken", "Only return broken bindings when true")).
+			To(apiHandler.handleGetToolChainHealth).
+			Doc("get last-known health of toolchain tools and their bindings in namespace").
+			Returns(200, "OK", toolchain.ToolChainHealth{}))
+	apiV1Ws.Route(
+		apiV1Ws.POST("/toolchain/health/{namespace}/{kind}/{name}/check").
+			Param(restful.PathParameter("namespace", "Namespace of the binding")).
+			Param(restful.PathParameter("kind", "Binding kind: jenkinsbinding, coderepobinding, imageregistrybinding or codequalitybinding")).
+			Param(restful.PathParameter("name", "Binding name")).
+			To(apiHandler.handleCheckToolChainBinding).
+			Doc("check again the secret of a binding against its tool").
+			Returns(200, "OK", toolchain.HealthCheck{}))
+	apiV1Ws.Route(
+		apiV1Ws.POST("/toolchain/health/{kind}/{name}/check").
+			Param(restful.PathParameter("kind", "Tool kind: jenkins, codereposervice, imageregistry or codequalitytool")).
+			Param(restful.PathParameter("name", "Tool name")).
+			Param(restful.QueryParameter("namespace", "Comma separated namespaces of the bindings. All when empty")).
+			To(apiHandler.handleCheckToolChainTool).
+			Doc("check again the secrets of all bindings of a tool").
+			Returns(200, "OK", []toolchain.HealthCheck{}))
 	// endregion
 
 	// region callback
@@ -1856,10 +2313,25 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 	// region Statistics
 	apiV1Ws.Route(
 		apiV1Ws.GET("/statistics/pipeline/{namespace}").
+			Param(restful.QueryParameter("period", "Negative duration before now, e.g. -168h")).
+			Param(restful.QueryParameter("granularity", "Size of time buckets: hour, day or week. Defaults to hour")).
+			Param(restful.QueryParameter("breakdown", "Comma separated breakdowns to calculate: pipelineconfig, trigger")).
 			To(apiHandler.handleGetPipelineStatistics).
-			Writes(struct{}{}).
+			Writes(statistics.PipelineStatistics{}).
 			Doc("get the statistics info of pipeline").
-			Returns(200, "OK", struct{}{}))
+			Returns(200, "OK", statistics.PipelineStatistics{}))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/statistics/project/{name}").
+			Param(restful.PathParameter("name", "Project whose namespaces are rolled up")).
+			Param(restful.QueryParameter("namespace", "Comma separated namespaces of the project to roll up. "+
+				"Required for users who may not list namespaces")).
+			Param(restful.QueryParameter("period", "Negative duration before now, e.g. -168h")).
+			Param(restful.QueryParameter("granularity", "Size of time buckets: hour, day or week. Defaults to hour")).
+			Param(restful.QueryParameter("breakdown", "Comma separated breakdowns to calculate: pipelineconfig, trigger")).
+			To(apiHandler.handleGetProjectStatistics).
+			Writes(statistics.ProjectStatistics{}).
+			Doc("get the statistics info of every namespace of the project and combined").
+			Returns(200, "OK", statistics.ProjectStatistics{}))
 	apiV1Ws.Route(
 		apiV1Ws.GET("/statistics/stage/{namespace}").
 			To(apiHandler.handleGetStageStatistics).
@@ -1875,6 +2347,54 @@ func CreateHTTPAPIHandler(iManager integration.IntegrationManager, cManager clie
 
 	// endregion
 
+	// region Export
+	apiV1Ws.Route(
+		apiV1Ws.GET("/export/pipeline/{namespace}").
+			Param(restful.QueryParameter("format", "Export format: csv or ndjson. Defaults to csv")).
+			Param(restful.QueryParameter("columns", "Comma separated columns: name, config, branch, phase, startedAt, finishedAt, duration, cause. All when empty")).
+			Produces("text/csv", "application/x-ndjson").
+			To(apiHandler.handleExportPipelines).
+			Doc("export namespaced pipeline list without pagination"))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/export/statistics/pipeline/{namespace}").
+			Param(restful.QueryParameter("format", "Export format: csv or ndjson. Defaults to csv")).
+			Param(restful.QueryParameter("period", "Negative duration before now, e.g. -168h")).
+			Param(restful.QueryParameter("granularity", "Size of time buckets: hour, day or week. Defaults to hour")).
+			Produces("text/csv", "application/x-ndjson").
+			To(apiHandler.handleExportPipelineStatistics).
+			Doc("export the statistics info of pipeline"))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/export/statistics/stage/{namespace}").
+			Param(restful.QueryParameter("format", "Export format: csv or ndjson. Defaults to csv")).
+			Param(restful.QueryParameter("period", "Negative duration before now, e.g. -168h")).
+			Produces("text/csv", "application/x-ndjson").
+			To(apiHandler.handleExportStageStatistics).
+			Doc("export the statistics info of stage"))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/export/statistics/codequality/{namespace}").
+			Param(restful.QueryParameter("format", "Export format: csv or ndjson. Defaults to csv")).
+			Produces("text/csv", "application/x-ndjson").
+			To(apiHandler.handleExportCodeQualityStatistics).
+			Doc("export the statistics info of code quality"))
+	apiV1Ws.Route(
+		apiV1Ws.GET("/export/pipelineconfig/{namespace}").
+			Param(restful.QueryParameter("names", "Comma separated names of pipeline configs. All when empty")).
+			Produces("application/x-yaml").
+			To(apiHandler.handleExportPipelineConfigs).
+			Doc("export pipeline configs as a portable yaml bundle with placeholders for bindings, code repositories and secrets"))
+	// endregion
+
+	// region Watch
+	apiV1Ws.Route(
+		apiV1Ws.GET("/watch/{namespace}").
+			Param(restful.QueryParameter("kinds", "Comma separated kinds to watch: pipeline, pipelineconfig, pod, deployment. All when empty")).
+			Param(restful.QueryParameter("filterBy", "Filter applied to watched objects, same as in list requests")).
+			To(apiHandler.handleWatch).
+			Produces(mimeEventStream).
+			Doc("stream changes of resources in the namespace as server-sent events").
+			Returns(200, "OK", watch.Event{}))
+	// endregion
+
 	// region CodeQualityTool
 	apiV1Ws.Route(
 		apiV1Ws.POST("/codequalitytool").
@@ -0,0 +1,79 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handler
+
+import (
+	"log"
+
+	kdErrors "alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/watch"
+	restful "github.com/emicklei/go-restful"
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+// handleWatch streams changes of resources in the namespace as server-sent events. Each event
+// is a JSON encoded watch.Event. The stream ends when the client goes away or when the
+// apiserver closes one of the watches, EventSource reconnects automatically then.
+func (apiHandler *APIHandler) handleWatch(request *restful.Request, response *restful.Response) {
+	kinds, err := watch.ParseKinds(request.QueryParameter("kinds"))
+	if err != nil {
+		kdErrors.HandleInternalError(response, errors.NewBadRequest(err.Error()))
+		return
+	}
+
+	k8sClient, err := apiHandler.cManager.Client(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	devopsClient, err := apiHandler.cManager.DevOpsClient(request)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+
+	namespace := parseNamespacePathParameter(request)
+	events, err := watch.Watch(k8sClient, devopsClient, namespace, kinds, parseFilterPathParameter(request), done)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	stream, err := newEventStream(response)
+	if err != nil {
+		kdErrors.HandleInternalError(response, err)
+		return
+	}
+
+	stopCh := stream.StopOnClose(done)
+	for {
+		select {
+		case <-stopCh:
+			return
+		case event, ok := <-events:
+			if !ok {
+				return
+			}
+			if err := stream.Send("", event); err != nil {
+				log.Printf("Stopping watch stream: %v", err)
+				return
+			}
+		}
+	}
+}
@@ -23,6 +23,7 @@ import (
 	integrationapi "alauda.io/diablo/src/backend/integration/api"
 	metricapi "alauda.io/diablo/src/backend/integration/metric/api"
 	"alauda.io/diablo/src/backend/integration/metric/heapster"
+	"alauda.io/diablo/src/backend/integration/metric/prometheus"
 	"k8s.io/apimachinery/pkg/util/wait"
 )
 
@@ -42,6 +43,8 @@ type MetricManager interface {
 	List() []integrationapi.Integration
 	// ConfigureHeapster configures and adds heapster to clients list.
 	ConfigureHeapster(host string) MetricManager
+	// ConfigurePrometheus configures and adds prometheus to clients list.
+	ConfigurePrometheus(host string) MetricManager
 }
 
 // Implements MetricManager interface.
@@ -127,6 +130,18 @@ func (self *metricManager) ConfigureHeapster(host string) MetricManager {
 	return self
 }
 
+// ConfigurePrometheus implements metric manager interface. See MetricManager for more information.
+func (self *metricManager) ConfigurePrometheus(host string) MetricManager {
+	metricClient, err := prometheus.CreatePrometheusClient(host)
+	if err != nil {
+		log.Printf("There was an error during prometheus client creation: %s", err.Error())
+		return self
+	}
+
+	self.clients[metricClient.ID()] = metricClient
+	return self
+}
+
 // NewMetricManager creates metric manager.
 func NewMetricManager(manager clientapi.ClientManager) MetricManager {
 	return &metricManager{
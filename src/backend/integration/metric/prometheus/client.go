@@ -0,0 +1,210 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"log"
+	"time"
+
+	integrationapi "alauda.io/diablo/src/backend/integration/api"
+	metricapi "alauda.io/diablo/src/backend/integration/metric/api"
+	"alauda.io/diablo/src/backend/integration/metric/common"
+	p8s "alauda.io/diablo/src/backend/integration/prometheus"
+	"github.com/prometheus/common/model"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+const (
+	// metricWindow is the time range of downloaded metrics. It matches heapster's default model resolution.
+	metricWindow = 15 * time.Minute
+	// metricStep is the resolution (in seconds) of downloaded metrics.
+	metricStep = 60
+)
+
+// PrometheusAPI is the subset of the prometheus query client used to download metrics.
+type PrometheusAPI interface {
+	Query(query string, queryTime time.Time) (model.Vector, error)
+	QueryRange(query string, startTime, endTime time.Time, step int) (model.Matrix, error)
+}
+
+// Prometheus client implements MetricClient and Integration interfaces.
+type prometheusClient struct {
+	client PrometheusAPI
+}
+
+// Implement Integration interface.
+
+// HealthCheck implements integration app interface. See Integration interface for more information.
+func (self prometheusClient) HealthCheck() error {
+	if self.client == nil {
+		return errors.New("Prometheus not configured")
+	}
+
+	_, err := self.client.Query("vector(1)", time.Now())
+	return err
+}
+
+// ID implements integration app interface. See Integration interface for more information.
+func (self prometheusClient) ID() integrationapi.IntegrationID {
+	return integrationapi.PrometheusIntegrationID
+}
+
+// Implement MetricClient interface
+
+// DownloadMetrics implements metric client interface. See MetricClient for more information.
+func (self prometheusClient) DownloadMetrics(selectors []metricapi.ResourceSelector,
+	metricNames []string, cachedResources *metricapi.CachedResources) metricapi.MetricPromises {
+	result := metricapi.MetricPromises{}
+	for _, metricName := range metricNames {
+		collectedMetrics := self.DownloadMetric(selectors, metricName, cachedResources)
+		result = append(result, collectedMetrics...)
+	}
+	return result
+}
+
+// DownloadMetric implements metric client interface. See MetricClient for more information.
+func (self prometheusClient) DownloadMetric(selectors []metricapi.ResourceSelector,
+	metricName string, cachedResources *metricapi.CachedResources) metricapi.MetricPromises {
+	prometheusSelectors := getPrometheusSelectors(selectors, cachedResources)
+	end := time.Now()
+	start := end.Add(-metricWindow)
+
+	result := metricapi.NewMetricPromises(len(prometheusSelectors))
+	for i, selector := range prometheusSelectors {
+		go self.downloadMetric(selector, metricName, start, end, result[i])
+	}
+	return result
+}
+
+// AggregateMetrics implements metric client interface. See MetricClient for more information.
+func (self prometheusClient) AggregateMetrics(metrics metricapi.MetricPromises, metricName string,
+	aggregations metricapi.AggregationModes) metricapi.MetricPromises {
+	return common.AggregateMetricPromises(metrics, metricName, aggregations, nil)
+}
+
+// downloadMetric downloads metric of all resources targeted by selector and sums them into
+// single metric pushed to the given promise.
+func (self prometheusClient) downloadMetric(selector prometheusSelector, metricName string,
+	start, end time.Time, promise metricapi.MetricPromise) {
+	if len(selector.Resources) == 0 {
+		promise.Metric <- &metricapi.Metric{
+			DataPoints:   metricapi.DataPoints{},
+			MetricPoints: []metricapi.MetricPoint{},
+			MetricName:   metricName,
+			Label:        selector.Label,
+			Aggregate:    metricapi.SumAggregation,
+		}
+		promise.Error <- nil
+		return
+	}
+
+	query, err := selector.query(metricName)
+	if err != nil {
+		promise.Metric <- nil
+		promise.Error <- err
+		return
+	}
+
+	matrix, err := self.client.QueryRange(query, start, end, metricStep)
+	if err != nil {
+		promise.Metric <- nil
+		promise.Error <- err
+		return
+	}
+
+	metrics := metricsFromMatrix(matrix, selector, metricName)
+	aggregatedMetric := common.AggregateData(metrics, metricName, metricapi.SumAggregation)
+	promise.Metric <- &aggregatedMetric
+	promise.Error <- nil
+}
+
+// metricsFromMatrix converts series returned by prometheus into per resource metrics. Series
+// that do not belong to any resource of the selector are skipped.
+func metricsFromMatrix(matrix model.Matrix, selector prometheusSelector, metricName string) []metricapi.Metric {
+	uids := selector.uidMapping()
+	labelName := model.LabelName(seriesLabels[selector.TargetResourceType])
+
+	result := make([]metricapi.Metric, 0, len(matrix))
+	for _, stream := range matrix {
+		uid, exists := uids[string(stream.Metric[labelName])]
+		if !exists {
+			continue
+		}
+
+		result = append(result, metricapi.Metric{
+			DataPoints:   toDataPoints(stream.Values),
+			MetricPoints: toMetricPoints(stream.Values),
+			MetricName:   metricName,
+			Label: metricapi.Label{
+				selector.TargetResourceType: []types.UID{uid},
+			},
+		})
+	}
+
+	return result
+}
+
+// toDataPoints converts prometheus samples to our data points format.
+func toDataPoints(samples []model.SamplePair) metricapi.DataPoints {
+	dp := metricapi.DataPoints{}
+	for _, sample := range samples {
+		converted := metricapi.DataPoint{
+			X: sample.Timestamp.Unix(),
+			Y: int64(sample.Value),
+		}
+
+		if converted.Y < 0 {
+			converted.Y = 0
+		}
+
+		dp = append(dp, converted)
+	}
+	return dp
+}
+
+func toMetricPoints(samples []model.SamplePair) []metricapi.MetricPoint {
+	metricPoints := make([]metricapi.MetricPoint, 0, len(samples))
+	for _, sample := range samples {
+		value := uint64(0)
+		if sample.Value > 0 {
+			value = uint64(sample.Value)
+		}
+
+		metricPoints = append(metricPoints, metricapi.MetricPoint{
+			Value:     value,
+			Timestamp: sample.Timestamp.Time(),
+		})
+	}
+
+	return metricPoints
+}
+
+// CreatePrometheusClient creates new Prometheus metric client. When host param is empty string
+// the function assumes that it is running inside a Kubernetes cluster and connects to the
+// in-cluster Prometheus. host param is in the format of protocol://address:port,
+// e.g., http://localhost:9090.
+func CreatePrometheusClient(host string) (metricapi.MetricClient, error) {
+	if host == "" {
+		host = p8s.GetP8sURL()
+	}
+
+	c, err := p8s.NewClient(host)
+	if err != nil {
+		return prometheusClient{}, err
+	}
+	log.Printf("Creating Prometheus metric client for %s", host)
+	return prometheusClient{client: c}, nil
+}
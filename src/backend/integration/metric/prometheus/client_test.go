@@ -0,0 +1,211 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"alauda.io/diablo/src/backend/api"
+	integrationapi "alauda.io/diablo/src/backend/integration/api"
+	metricapi "alauda.io/diablo/src/backend/integration/metric/api"
+	"github.com/prometheus/common/model"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+type FakePrometheus struct {
+	Series map[string][]model.SamplePair
+	Err    error
+}
+
+func (self *FakePrometheus) Query(query string, queryTime time.Time) (model.Vector, error) {
+	return model.Vector{}, self.Err
+}
+
+func (self *FakePrometheus) QueryRange(query string, startTime, endTime time.Time, step int) (model.Matrix, error) {
+	if self.Err != nil {
+		return model.Matrix{}, self.Err
+	}
+
+	matrix := model.Matrix{}
+	for _, name := range queriedPods(query) {
+		values, exists := self.Series[name]
+		if !exists {
+			continue
+		}
+		matrix = append(matrix, &model.SampleStream{
+			Metric: model.Metric{podLabel: model.LabelValue(name)},
+			Values: values,
+		})
+	}
+	return matrix, nil
+}
+
+// queriedPods extracts names of pods matched by the query.
+func queriedPods(query string) []string {
+	prefix := podLabel + `=~"`
+	start := strings.Index(query, prefix)
+	if start < 0 {
+		return nil
+	}
+	rest := query[start+len(prefix):]
+	return strings.Split(rest[:strings.Index(rest, `"`)], "|")
+}
+
+func samples(values ...float64) []model.SamplePair {
+	result := make([]model.SamplePair, len(values))
+	for i, value := range values {
+		result[i] = model.SamplePair{
+			Timestamp: model.TimeFromUnix(int64(i * metricStep)),
+			Value:     model.SampleValue(value),
+		}
+	}
+	return result
+}
+
+func areErrorsEqual(err1, err2 error) bool {
+	return (err1 != nil && err2 != nil && err1.Error() == err2.Error()) ||
+		(err1 == nil && err2 == nil)
+}
+
+func TestPrometheusClient_HealthCheck(t *testing.T) {
+	cases := []struct {
+		info     string
+		client   prometheusClient
+		expected error
+	}{
+		{"Not configured", prometheusClient{}, errors.New("Prometheus not configured")},
+		{"Healthy", prometheusClient{client: &FakePrometheus{}}, nil},
+		{"Unreachable", prometheusClient{client: &FakePrometheus{Err: errors.New("unreachable")}},
+			errors.New("unreachable")},
+	}
+
+	for _, c := range cases {
+		err := c.client.HealthCheck()
+		if !areErrorsEqual(err, c.expected) {
+			t.Errorf("Test Case: %s. Expected error to be: %v, but got %v.", c.info, c.expected, err)
+		}
+	}
+}
+
+func TestPrometheusClient_ID(t *testing.T) {
+	if id := (prometheusClient{}).ID(); id != integrationapi.PrometheusIntegrationID {
+		t.Errorf("Expected ID to be %s, but got %s.", integrationapi.PrometheusIntegrationID, id)
+	}
+}
+
+func TestPrometheusSelector_Query(t *testing.T) {
+	cases := []struct {
+		info        string
+		selector    prometheusSelector
+		metricName  string
+		expected    []string
+		expectedErr error
+	}{
+		{
+			"Pod cpu usage",
+			prometheusSelector{TargetResourceType: api.ResourceKindPod, Namespace: "ns", Resources: []string{"a", "b"}},
+			metricapi.CpuUsage,
+			[]string{`namespace="ns"`, `pod_name=~"a|b"`, "container_cpu_usage_seconds_total"},
+			nil,
+		},
+		{
+			"Node memory usage",
+			prometheusSelector{TargetResourceType: api.ResourceKindNode, Resources: []string{"node.1"}},
+			metricapi.MemoryUsage,
+			[]string{`instance=~"node\.1"`, "container_memory_working_set_bytes"},
+			nil,
+		},
+		{
+			"Unsupported metric",
+			prometheusSelector{TargetResourceType: api.ResourceKindPod, Resources: []string{"a"}},
+			"network/rx",
+			nil,
+			errors.New(`Metric "network/rx" is not supported by prometheus metric client`),
+		},
+	}
+
+	for _, c := range cases {
+		query, err := c.selector.query(c.metricName)
+		if !areErrorsEqual(err, c.expectedErr) {
+			t.Errorf("Test Case: %s. Expected error to be: %v, but got %v.", c.info, c.expectedErr, err)
+		}
+		for _, fragment := range c.expected {
+			if !strings.Contains(query, fragment) {
+				t.Errorf("Test Case: %s. Expected query %s to contain %s.", c.info, query, fragment)
+			}
+		}
+	}
+}
+
+func TestPrometheusClient_DownloadMetric(t *testing.T) {
+	controller := true
+	pods := []v1.Pod{
+		{ObjectMeta: metaV1.ObjectMeta{Name: "a", Namespace: "ns", UID: "uid-a",
+			OwnerReferences: []metaV1.OwnerReference{{UID: "rs", Controller: &controller}}}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "b", Namespace: "ns", UID: "uid-b",
+			OwnerReferences: []metaV1.OwnerReference{{UID: "rs", Controller: &controller}}}},
+		{ObjectMeta: metaV1.ObjectMeta{Name: "c", Namespace: "other", UID: "uid-c",
+			OwnerReferences: []metaV1.OwnerReference{{UID: "rs", Controller: &controller}}}},
+	}
+	fake := &FakePrometheus{Series: map[string][]model.SamplePair{
+		"a": samples(1, 2),
+		"b": samples(10, -5),
+	}}
+	client := prometheusClient{client: fake}
+
+	selectors := []metricapi.ResourceSelector{
+		{Namespace: "ns", ResourceType: api.ResourceKindReplicaSet, ResourceName: "rs", UID: "rs"},
+		{Namespace: "ns", ResourceType: api.ResourceKindPod, ResourceName: "a", UID: "uid-a"},
+	}
+
+	metrics, err := client.DownloadMetric(selectors, metricapi.CpuUsage,
+		&metricapi.CachedResources{Pods: pods}).GetMetrics()
+	if err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := []metricapi.DataPoints{
+		{{X: 0, Y: 11}, {X: 60, Y: 2}},
+		{{X: 0, Y: 1}, {X: 60, Y: 2}},
+	}
+	for i, metric := range metrics {
+		if !reflect.DeepEqual(metric.DataPoints, expected[i]) {
+			t.Errorf("Selector %d. Expected data points %v, but got %v.", i, expected[i], metric.DataPoints)
+		}
+	}
+
+	expectedLabel := metricapi.Label{api.ResourceKindPod: []types.UID{"uid-a", "uid-b"}}
+	if len(metrics[0].Label[api.ResourceKindPod]) != len(expectedLabel[api.ResourceKindPod]) {
+		t.Errorf("Expected label %v, but got %v.", expectedLabel, metrics[0].Label)
+	}
+}
+
+func TestPrometheusClient_DownloadMetricError(t *testing.T) {
+	client := prometheusClient{client: &FakePrometheus{Err: errors.New("timeout")}}
+	selectors := []metricapi.ResourceSelector{
+		{Namespace: "ns", ResourceType: api.ResourceKindPod, ResourceName: "a", UID: "uid-a"},
+	}
+
+	_, err := client.DownloadMetric(selectors, metricapi.MemoryUsage, metricapi.NoResourceCache).GetMetrics()
+	if !areErrorsEqual(err, errors.New("timeout")) {
+		t.Errorf("Expected error to be timeout, but got %v.", err)
+	}
+}
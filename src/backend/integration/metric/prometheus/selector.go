@@ -0,0 +1,200 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package prometheus
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"strings"
+
+	"alauda.io/diablo/src/backend/api"
+	metricapi "alauda.io/diablo/src/backend/integration/metric/api"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Label names used by cAdvisor metrics scraped through kubelet.
+const (
+	podLabel       = "pod_name"
+	containerLabel = "container_name"
+	nodeLabel      = "instance"
+)
+
+// rateInterval is the range used by rate() when computing usage rates.
+const rateInterval = "2m"
+
+// metricQueries maps native resource type and metric name to the PromQL template used to
+// download it. Templates take a namespace (pods only) and a regexp matching resource names
+// and must return one series per resource labelled with the resource name.
+var metricQueries = map[api.ResourceKind]map[string]string{
+	api.ResourceKindPod: {
+		metricapi.CpuUsage: `sum(rate(container_cpu_usage_seconds_total{namespace="%s",` + podLabel + `=~"%s",` +
+			containerLabel + `!="",` + containerLabel + `!="POD"}[` + rateInterval + `])) by (` + podLabel + `) * 1000`,
+		metricapi.MemoryUsage: `sum(container_memory_working_set_bytes{namespace="%s",` + podLabel + `=~"%s",` +
+			containerLabel + `!="",` + containerLabel + `!="POD"}) by (` + podLabel + `)`,
+	},
+	api.ResourceKindNode: {
+		metricapi.CpuUsage: `sum(rate(container_cpu_usage_seconds_total{id="/",` + nodeLabel + `=~"%s"}[` +
+			rateInterval + `])) by (` + nodeLabel + `) * 1000`,
+		metricapi.MemoryUsage: `sum(container_memory_working_set_bytes{id="/",` + nodeLabel + `=~"%s"}) by (` +
+			nodeLabel + `)`,
+	},
+}
+
+// seriesLabels maps native resource type to the label which identifies resource in returned series.
+var seriesLabels = map[api.ResourceKind]string{
+	api.ResourceKindPod:  podLabel,
+	api.ResourceKindNode: nodeLabel,
+}
+
+type prometheusSelector struct {
+	TargetResourceType api.ResourceKind
+	Namespace          string
+	Resources          []string
+	metricapi.Label
+}
+
+// query returns PromQL query downloading given metric for all resources of the selector.
+func (self prometheusSelector) query(metricName string) (string, error) {
+	queries, exists := metricQueries[self.TargetResourceType]
+	if !exists {
+		return "", fmt.Errorf(`Resource "%s" is not supported by prometheus metric client`, self.TargetResourceType)
+	}
+
+	query, exists := queries[metricName]
+	if !exists {
+		return "", fmt.Errorf(`Metric "%s" is not supported by prometheus metric client`, metricName)
+	}
+
+	names := make([]string, len(self.Resources))
+	for i, name := range self.Resources {
+		names[i] = regexp.QuoteMeta(name)
+	}
+	nameRegexp := strings.Join(names, "|")
+
+	if self.TargetResourceType == api.ResourceKindNode {
+		return fmt.Sprintf(query, nameRegexp), nil
+	}
+	return fmt.Sprintf(query, self.Namespace, nameRegexp), nil
+}
+
+// uidMapping returns mapping from resource name to its UID.
+func (self prometheusSelector) uidMapping() map[string]types.UID {
+	result := make(map[string]types.UID, len(self.Resources))
+	uids := self.Label[self.TargetResourceType]
+	for i, name := range self.Resources {
+		if i < len(uids) {
+			result[name] = uids[i]
+		}
+	}
+	return result
+}
+
+func getPrometheusSelectors(selectors []metricapi.ResourceSelector,
+	cachedResources *metricapi.CachedResources) []prometheusSelector {
+	result := make([]prometheusSelector, len(selectors))
+	for i, selector := range selectors {
+		prometheusSelector, err := getPrometheusSelector(selector, cachedResources)
+		if err != nil {
+			log.Printf("There was an error during transformation to prometheus selector: %s", err.Error())
+			continue
+		}
+
+		result[i] = prometheusSelector
+	}
+
+	return result
+}
+
+func getPrometheusSelector(selector metricapi.ResourceSelector,
+	cachedResources *metricapi.CachedResources) (prometheusSelector, error) {
+	summingResource, isDerivedResource := metricapi.DerivedResources[selector.ResourceType]
+	if !isDerivedResource {
+		return newPrometheusSelectorFromNativeResource(selector.ResourceType, selector.Namespace,
+			[]string{selector.ResourceName}, []types.UID{selector.UID})
+	}
+	// Derived resources are converted to the list of pods that belong to them, same as for heapster.
+	if summingResource == api.ResourceKindPod {
+		myPods, err := getMyPodsFromCache(selector, cachedResources.Pods)
+		if err != nil {
+			return prometheusSelector{}, err
+		}
+		return newPrometheusSelectorFromNativeResource(api.ResourceKindPod,
+			selector.Namespace, podListToNameList(myPods), podListToUIDList(myPods))
+	}
+
+	return prometheusSelector{}, fmt.Errorf(`Internal Error: Requested summing resources not supported. Requested "%s"`, summingResource)
+}
+
+// getMyPodsFromCache returns a full list of pods that belong to this resource.
+// It is important that cachedPods include ALL pods from the namespace of this resource (but they
+// can also include pods from other namespaces).
+func getMyPodsFromCache(selector metricapi.ResourceSelector, cachedPods []v1.Pod) (matchingPods []v1.Pod, err error) {
+	switch {
+	case cachedPods == nil:
+		err = fmt.Errorf(`Pods were not available in cache. Required for resource type: "%s"`,
+			selector.ResourceType)
+	case selector.ResourceType == api.ResourceKindDeployment:
+		for _, pod := range cachedPods {
+			if pod.ObjectMeta.Namespace == selector.Namespace && api.IsSelectorMatching(selector.Selector, pod.Labels) {
+				matchingPods = append(matchingPods, pod)
+			}
+		}
+	default:
+		for _, pod := range cachedPods {
+			if pod.Namespace == selector.Namespace {
+				for _, ownerRef := range pod.OwnerReferences {
+					if ownerRef.Controller != nil && *ownerRef.Controller == true &&
+						ownerRef.UID == selector.UID {
+						matchingPods = append(matchingPods, pod)
+					}
+				}
+			}
+		}
+	}
+	return
+}
+
+// newPrometheusSelectorFromNativeResource returns new prometheus selector for native resources
+// specified in arguments. Returns error if requested resource is not supported.
+func newPrometheusSelectorFromNativeResource(resourceType api.ResourceKind, namespace string,
+	resourceNames []string, resourceUIDs []types.UID) (prometheusSelector, error) {
+	if _, exists := metricQueries[resourceType]; !exists {
+		return prometheusSelector{}, fmt.Errorf(`Resource "%s" is not a native prometheus resource type or is not supported`, resourceType)
+	}
+
+	return prometheusSelector{
+		TargetResourceType: resourceType,
+		Namespace:          namespace,
+		Resources:          resourceNames,
+		Label:              metricapi.Label{resourceType: resourceUIDs},
+	}, nil
+}
+
+// podListToNameList converts list of pods to the list of pod names.
+func podListToNameList(podList []v1.Pod) (result []string) {
+	for _, pod := range podList {
+		result = append(result, pod.Name)
+	}
+	return
+}
+
+func podListToUIDList(podList []v1.Pod) (result []types.UID) {
+	for _, pod := range podList {
+		result = append(result, pod.UID)
+	}
+	return
+}
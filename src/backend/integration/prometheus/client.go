@@ -45,7 +45,9 @@ func NewClient(p8sURL string) (*Client, error) {
 	return clientMap[p8sURL], nil
 }
 
-func getP8sURL() string {
+// GetP8sURL returns address of the in-cluster Prometheus. It can be overridden with the
+// PROMETHEUS_URL environment variable.
+func GetP8sURL() string {
 	if os.Getenv("PROMETHEUS_URL") != "" {
 		return os.Getenv("PROMETHEUS_URL")
 	}
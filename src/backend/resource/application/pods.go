@@ -0,0 +1,60 @@
+package application
+
+import (
+	appCore "alauda.io/app-core/pkg/app"
+
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/daemonset"
+	"alauda.io/diablo/src/backend/resource/deployment"
+	"alauda.io/diablo/src/backend/resource/statefulset"
+	"k8s.io/api/core/v1"
+	client "k8s.io/client-go/kubernetes"
+)
+
+// GetApplicationPods returns pods of all deployments, daemon sets and stateful sets of the application.
+func GetApplicationPods(k8sclient client.Interface, appCoreClient *appCore.ApplicationClient, namespace, name string) ([]v1.Pod, error) {
+	app, result := appCoreClient.GetApplication(namespace, name)
+	if err := result.CombineError(); err != nil {
+		return nil, err
+	}
+
+	deployments, err := deployment.GetFormCore(*app)
+	if err != nil {
+		return nil, err
+	}
+	daemonSets, err := daemonset.GetFormCore(*app)
+	if err != nil {
+		return nil, err
+	}
+	statefulSets, err := statefulset.GetFormCore(*app)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := &common.ResourceChannels{
+		PodList:        common.GetPodListChannel(k8sclient, getNamespaceQuery(namespace), 1),
+		ReplicaSetList: common.GetReplicaSetListChannel(k8sclient, getNamespaceQuery(namespace), 1),
+	}
+
+	allPods := <-channels.PodList.List
+	if err := <-channels.PodList.Error; err != nil {
+		return nil, err
+	}
+
+	allRs := <-channels.ReplicaSetList.List
+	if err := <-channels.ReplicaSetList.Error; err != nil {
+		return nil, err
+	}
+
+	pods := make([]v1.Pod, 0)
+	for _, d := range deployments {
+		pods = append(pods, common.FilterDeploymentPodsByOwnerReference(d, allRs.Items, allPods.Items)...)
+	}
+	for i := range daemonSets {
+		pods = append(pods, common.FilterPodsByControllerRef(&daemonSets[i], allPods.Items)...)
+	}
+	for i := range statefulSets {
+		pods = append(pods, common.FilterPodsByControllerRef(&statefulSets[i], allPods.Items)...)
+	}
+	return pods, nil
+}
@@ -0,0 +1,301 @@
+package coderepository
+
+import (
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// PullRequestOpen, PullRequestMerged and PullRequestClosed are states of pull requests. Closed pull
+	// requests were not merged.
+	PullRequestOpen   = "open"
+	PullRequestMerged = "merged"
+	PullRequestClosed = "closed"
+	// PullRequestAll selects pull requests in any state
+	PullRequestAll = "all"
+
+	defaultItemsPerPage = 20
+	maxItemsPerPage     = 100
+
+	// oauth2AccessTokenKey is the key of the access token in oauth2 secrets
+	oauth2AccessTokenKey = "accessToken"
+)
+
+var (
+	browseClient = &http.Client{Timeout: 30 * time.Second}
+	// selfHostedBrowseClient does not verify certificates, self-hosted services commonly use certificates
+	// of private authorities as for the other clients of self-hosted services in the dashboard
+	selfHostedBrowseClient = &http.Client{
+		Timeout: 30 * time.Second,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+	}
+)
+
+// RepositoryPage is the page of a list browsed on the code repository service. The total is unknown, HasMore
+// tells whether a next page exists.
+type RepositoryPage struct {
+	Page         int  `json:"page"`
+	ItemsPerPage int  `json:"itemsPerPage"`
+	HasMore      bool `json:"hasMore"`
+}
+
+// RepositoryCommit is a commit of a code repository
+type RepositoryCommit struct {
+	ID          string     `json:"id"`
+	Message     string     `json:"message"`
+	Author      string     `json:"author"`
+	AuthorEmail string     `json:"authorEmail,omitempty"`
+	CommittedAt *time.Time `json:"committedAt,omitempty"`
+	HTMLURL     string     `json:"htmlURL,omitempty"`
+}
+
+// RepositoryCommitList contains a page of commits of a branch
+type RepositoryCommitList struct {
+	RepositoryPage
+	Branch string             `json:"branch"`
+	Items  []RepositoryCommit `json:"items"`
+}
+
+// RepositoryTag is a tag of a code repository
+type RepositoryTag struct {
+	Name     string `json:"name"`
+	CommitID string `json:"commitID"`
+	Message  string `json:"message,omitempty"`
+}
+
+// RepositoryTagList contains a page of tags
+type RepositoryTagList struct {
+	RepositoryPage
+	Items []RepositoryTag `json:"items"`
+}
+
+// RepositoryPullRequest is a pull request, or merge request, of a code repository
+type RepositoryPullRequest struct {
+	ID           string     `json:"id"`
+	Title        string     `json:"title"`
+	State        string     `json:"state"`
+	Author       string     `json:"author"`
+	SourceBranch string     `json:"sourceBranch"`
+	TargetBranch string     `json:"targetBranch"`
+	CommitID     string     `json:"commitID"`
+	HTMLURL      string     `json:"htmlURL,omitempty"`
+	CreatedAt    *time.Time `json:"createdAt,omitempty"`
+	UpdatedAt    *time.Time `json:"updatedAt,omitempty"`
+}
+
+// RepositoryPullRequestList contains a page of pull requests
+type RepositoryPullRequestList struct {
+	RepositoryPage
+	State string                  `json:"state"`
+	Items []RepositoryPullRequest `json:"items"`
+}
+
+// repositoryBrowser reads commits, tags and pull requests from the API of a code repository service.
+// Pages start from 1.
+type repositoryBrowser interface {
+	commits(branch string, page, perPage int) ([]RepositoryCommit, bool, error)
+	tags(page, perPage int) ([]RepositoryTag, bool, error)
+	pullRequests(state string, page, perPage int) ([]RepositoryPullRequest, bool, error)
+}
+
+// repositoryCredentials are the credentials of the secret of a CodeRepoBinding
+type repositoryCredentials struct {
+	username    string
+	password    string
+	accessToken string
+}
+
+// GetCodeRepositoryCommits returns a page of the commits of the branch of the CodeRepository, newest first.
+// The default branch is used when branch is empty.
+func GetCodeRepositoryCommits(client devopsclient.Interface, secretClient kubernetes.Interface, namespace, name, branch string,
+	pQuery *dataselect.PaginationQuery) (*RepositoryCommitList, error) {
+	browser, err := getRepositoryBrowser(client, secretClient, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &RepositoryCommitList{RepositoryPage: toRepositoryPage(pQuery), Branch: branch}
+	list.Items, list.HasMore, err = browser.commits(branch, list.Page, list.ItemsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetCodeRepositoryTags returns a page of the tags of the CodeRepository
+func GetCodeRepositoryTags(client devopsclient.Interface, secretClient kubernetes.Interface, namespace, name string,
+	pQuery *dataselect.PaginationQuery) (*RepositoryTagList, error) {
+	browser, err := getRepositoryBrowser(client, secretClient, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &RepositoryTagList{RepositoryPage: toRepositoryPage(pQuery)}
+	list.Items, list.HasMore, err = browser.tags(list.Page, list.ItemsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+// GetCodeRepositoryPullRequests returns a page of the pull requests of the CodeRepository in state, open ones
+// when state is empty
+func GetCodeRepositoryPullRequests(client devopsclient.Interface, secretClient kubernetes.Interface, namespace, name, state string,
+	pQuery *dataselect.PaginationQuery) (*RepositoryPullRequestList, error) {
+	if state == "" {
+		state = PullRequestOpen
+	}
+	switch state {
+	case PullRequestOpen, PullRequestMerged, PullRequestClosed, PullRequestAll:
+	default:
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("unknown pull request state %s", state))
+	}
+
+	browser, err := getRepositoryBrowser(client, secretClient, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &RepositoryPullRequestList{RepositoryPage: toRepositoryPage(pQuery), State: state}
+	list.Items, list.HasMore, err = browser.pullRequests(state, list.Page, list.ItemsPerPage)
+	if err != nil {
+		return nil, err
+	}
+	return list, nil
+}
+
+func toRepositoryPage(pQuery *dataselect.PaginationQuery) RepositoryPage {
+	page := RepositoryPage{Page: 1, ItemsPerPage: defaultItemsPerPage}
+	if pQuery != nil && pQuery.IsValidPagination() && pQuery.ItemsPerPage > 0 {
+		page.Page = pQuery.Page + 1
+		page.ItemsPerPage = pQuery.ItemsPerPage
+	}
+	if page.ItemsPerPage > maxItemsPerPage {
+		page.ItemsPerPage = maxItemsPerPage
+	}
+	return page
+}
+
+// getRepositoryBrowser returns the browser of the service of the CodeRepository, authenticated with the
+// secret of its CodeRepoBinding. The CodeRepository is read with the client of the user, so only users who
+// can access it browse it. The secret is read with secretClient, users do not need access to secrets of
+// bindings, e.g. to global credentials.
+func getRepositoryBrowser(client devopsclient.Interface, secretClient kubernetes.Interface, namespace, name string) (repositoryBrowser, error) {
+	repository, err := client.DevopsV1alpha1().CodeRepositories(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	binding, err := client.DevopsV1alpha1().CodeRepoBindings(namespace).Get(repository.Spec.CodeRepoBinding.Name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	service, err := client.DevopsV1alpha1().CodeRepoServices().Get(binding.Spec.CodeRepoService.Name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+
+	credentials := repositoryCredentials{}
+	if secretName := binding.Spec.Account.Secret.Name; secretName != "" {
+		secretNamespace, err := getBindingSecretNamespace(namespace, binding.Spec.Account.Secret.Namespace, secretName)
+		if err != nil {
+			return nil, err
+		}
+		secret, err := secretClient.CoreV1().Secrets(secretNamespace).Get(secretName, api.GetOptionsInCache)
+		if err != nil {
+			return nil, err
+		}
+		credentials.username = string(secret.Data[v1.BasicAuthUsernameKey])
+		credentials.password = string(secret.Data[v1.BasicAuthPasswordKey])
+		credentials.accessToken = string(secret.Data[oauth2AccessTokenKey])
+	}
+
+	fullName := repository.Spec.Repository.FullName
+	serviceType := service.Spec.Type.String()
+	log.Printf("Browsing %s repository %s of %s/%s", serviceType, fullName, namespace, name)
+	return newRepositoryBrowser(serviceType, strings.TrimRight(service.Spec.HTTP.Host, "/"), service.Spec.Public, fullName, credentials)
+}
+
+// getBindingSecretNamespace returns the namespace of the secret of a binding in namespace. Bindings may only use
+// secrets of their own namespace or global credentials, as in GetSecretRelatedResources of secrets.
+func getBindingSecretNamespace(namespace, secretNamespace, secretName string) (string, error) {
+	if secretNamespace == "" {
+		return namespace, nil
+	}
+	if secretNamespace != namespace && secretNamespace != devopsv1alpha1.NamespaceGlobalCredentials {
+		return "", k8serrors.NewForbidden(schema.GroupResource{Resource: "secrets"}, secretName,
+			fmt.Errorf("binding in namespace %s cannot use secrets of namespace %s", namespace, secretNamespace))
+	}
+	return secretNamespace, nil
+}
+
+// newRepositoryBrowser returns the browser of a repository of the service at host. Public services are the
+// SaaS ones, e.g. github.com, the others are self-hosted.
+func newRepositoryBrowser(serviceType, host string, public bool, fullName string, credentials repositoryCredentials) (repositoryBrowser, error) {
+	httpClient := browseClient
+	if !public {
+		httpClient = selfHostedBrowseClient
+	}
+
+	switch strings.ToLower(serviceType) {
+	case "github":
+		apiURL := host + "/api/v3"
+		if public {
+			apiURL = "https://api.github.com"
+		}
+		return &githubBrowser{client: httpClient, apiURL: apiURL, fullName: fullName, credentials: credentials}, nil
+	case "gitee":
+		return &githubBrowser{client: httpClient, apiURL: host + "/api/v5", fullName: fullName, credentials: credentials, gitee: true}, nil
+	case "gitlab":
+		return &gitlabBrowser{client: httpClient, apiURL: host + "/api/v4", fullName: fullName, credentials: credentials}, nil
+	case "bitbucket":
+		// Bitbucket Server has another API than Bitbucket Cloud
+		if public {
+			return &bitbucketBrowser{client: httpClient, apiURL: "https://api.bitbucket.org/2.0", fullName: fullName, credentials: credentials}, nil
+		}
+	}
+	return nil, k8serrors.NewBadRequest(fmt.Sprintf("browsing repositories of %s service %s is not supported", serviceType, host))
+}
+
+// getJSON decodes the JSON response of a GET request of the url into out
+func getJSON(client *http.Client, url string, authorize func(*http.Request), out interface{}) error {
+	request, err := http.NewRequest(http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	request.Header.Set("Accept", "application/json")
+	authorize(request)
+
+	response, err := client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode < http.StatusOK || response.StatusCode >= http.StatusMultipleChoices {
+		body, _ := ioutil.ReadAll(response.Body)
+		message := strings.TrimSpace(string(body))
+		if len(message) > 200 {
+			message = message[:200]
+		}
+		return fmt.Errorf("code repository service responded %d: %s", response.StatusCode, message)
+	}
+	return json.NewDecoder(response.Body).Decode(out)
+}
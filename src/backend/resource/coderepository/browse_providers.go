@@ -0,0 +1,442 @@
+package coderepository
+
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// githubBrowser browses repositories of GitHub and of Gitee, whose API follows the GitHub one
+type githubBrowser struct {
+	client      *http.Client
+	apiURL      string
+	fullName    string
+	credentials repositoryCredentials
+	gitee       bool
+}
+
+type githubCommit struct {
+	SHA     string `json:"sha"`
+	HTMLURL string `json:"html_url"`
+	Commit  struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string     `json:"name"`
+			Email string     `json:"email"`
+			Date  *time.Time `json:"date"`
+		} `json:"author"`
+	} `json:"commit"`
+}
+
+type githubTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Commit  struct {
+		SHA string `json:"sha"`
+	} `json:"commit"`
+}
+
+type githubPullRequest struct {
+	Number  int    `json:"number"`
+	Title   string `json:"title"`
+	State   string `json:"state"`
+	HTMLURL string `json:"html_url"`
+	User    struct {
+		Login string `json:"login"`
+	} `json:"user"`
+	Head struct {
+		Ref string `json:"ref"`
+		SHA string `json:"sha"`
+	} `json:"head"`
+	Base struct {
+		Ref string `json:"ref"`
+	} `json:"base"`
+	MergedAt  *time.Time `json:"merged_at"`
+	CreatedAt *time.Time `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+func (b *githubBrowser) get(path string, query url.Values, out interface{}) error {
+	// Gitee takes the token as a query parameter, a password of a basic-auth secret is a personal token there
+	if b.gitee {
+		if token := b.credentials.accessToken; token != "" {
+			query.Set("access_token", token)
+		} else if token = b.credentials.password; token != "" {
+			query.Set("access_token", token)
+		}
+	}
+	return getJSON(b.client, b.apiURL+"/repos/"+b.fullName+path+"?"+query.Encode(), func(request *http.Request) {
+		switch {
+		case b.gitee:
+		case b.credentials.accessToken != "":
+			request.Header.Set("Authorization", "token "+b.credentials.accessToken)
+		case b.credentials.password != "":
+			request.SetBasicAuth(b.credentials.username, b.credentials.password)
+		}
+	}, out)
+}
+
+func (b *githubBrowser) commits(branch string, page, perPage int) ([]RepositoryCommit, bool, error) {
+	query := pageQuery("page", page, "per_page", perPage)
+	if branch != "" {
+		query.Set("sha", branch)
+	}
+	commits := make([]githubCommit, 0)
+	if err := b.get("/commits", query, &commits); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryCommit, 0, len(commits))
+	for _, commit := range commits {
+		items = append(items, RepositoryCommit{
+			ID:          commit.SHA,
+			Message:     commit.Commit.Message,
+			Author:      commit.Commit.Author.Name,
+			AuthorEmail: commit.Commit.Author.Email,
+			CommittedAt: commit.Commit.Author.Date,
+			HTMLURL:     commit.HTMLURL,
+		})
+	}
+	return items, len(commits) == perPage, nil
+}
+
+func (b *githubBrowser) tags(page, perPage int) ([]RepositoryTag, bool, error) {
+	tags := make([]githubTag, 0)
+	if err := b.get("/tags", pageQuery("page", page, "per_page", perPage), &tags); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryTag, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, RepositoryTag{Name: tag.Name, CommitID: tag.Commit.SHA, Message: tag.Message})
+	}
+	return items, len(tags) == perPage, nil
+}
+
+func (b *githubBrowser) pullRequests(state string, page, perPage int) ([]RepositoryPullRequest, bool, error) {
+	query := pageQuery("page", page, "per_page", perPage)
+	// GitHub only distinguishes open and closed pull requests, merged ones are closed ones with a merge time
+	if state == PullRequestMerged && !b.gitee {
+		query.Set("state", PullRequestClosed)
+	} else {
+		query.Set("state", state)
+	}
+	pulls := make([]githubPullRequest, 0)
+	if err := b.get("/pulls", query, &pulls); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryPullRequest, 0, len(pulls))
+	for _, pull := range pulls {
+		pullState := pull.State
+		if pull.MergedAt != nil {
+			pullState = PullRequestMerged
+		}
+		if !b.gitee && state != PullRequestAll && pullState != state {
+			continue
+		}
+		items = append(items, RepositoryPullRequest{
+			ID:           strconv.Itoa(pull.Number),
+			Title:        pull.Title,
+			State:        pullState,
+			Author:       pull.User.Login,
+			SourceBranch: pull.Head.Ref,
+			TargetBranch: pull.Base.Ref,
+			CommitID:     pull.Head.SHA,
+			HTMLURL:      pull.HTMLURL,
+			CreatedAt:    pull.CreatedAt,
+			UpdatedAt:    pull.UpdatedAt,
+		})
+	}
+	return items, len(pulls) == perPage, nil
+}
+
+// gitlabBrowser browses repositories of GitLab
+type gitlabBrowser struct {
+	client      *http.Client
+	apiURL      string
+	fullName    string
+	credentials repositoryCredentials
+}
+
+type gitlabCommit struct {
+	ID            string     `json:"id"`
+	Message       string     `json:"message"`
+	AuthorName    string     `json:"author_name"`
+	AuthorEmail   string     `json:"author_email"`
+	CommittedDate *time.Time `json:"committed_date"`
+	WebURL        string     `json:"web_url"`
+}
+
+type gitlabTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Commit  struct {
+		ID string `json:"id"`
+	} `json:"commit"`
+}
+
+type gitlabMergeRequest struct {
+	IID    int    `json:"iid"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	WebURL string `json:"web_url"`
+	Author struct {
+		Username string `json:"username"`
+	} `json:"author"`
+	SourceBranch string     `json:"source_branch"`
+	TargetBranch string     `json:"target_branch"`
+	SHA          string     `json:"sha"`
+	CreatedAt    *time.Time `json:"created_at"`
+	UpdatedAt    *time.Time `json:"updated_at"`
+}
+
+func (b *gitlabBrowser) get(path string, query url.Values, out interface{}) error {
+	return getJSON(b.client, b.apiURL+"/projects/"+url.PathEscape(b.fullName)+path+"?"+query.Encode(), func(request *http.Request) {
+		switch {
+		case b.credentials.accessToken != "":
+			request.Header.Set("Authorization", "Bearer "+b.credentials.accessToken)
+		case b.credentials.password != "":
+			request.Header.Set("PRIVATE-TOKEN", b.credentials.password)
+		}
+	}, out)
+}
+
+func (b *gitlabBrowser) commits(branch string, page, perPage int) ([]RepositoryCommit, bool, error) {
+	query := pageQuery("page", page, "per_page", perPage)
+	if branch != "" {
+		query.Set("ref_name", branch)
+	}
+	commits := make([]gitlabCommit, 0)
+	if err := b.get("/repository/commits", query, &commits); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryCommit, 0, len(commits))
+	for _, commit := range commits {
+		items = append(items, RepositoryCommit{
+			ID:          commit.ID,
+			Message:     commit.Message,
+			Author:      commit.AuthorName,
+			AuthorEmail: commit.AuthorEmail,
+			CommittedAt: commit.CommittedDate,
+			HTMLURL:     commit.WebURL,
+		})
+	}
+	return items, len(commits) == perPage, nil
+}
+
+func (b *gitlabBrowser) tags(page, perPage int) ([]RepositoryTag, bool, error) {
+	tags := make([]gitlabTag, 0)
+	if err := b.get("/repository/tags", pageQuery("page", page, "per_page", perPage), &tags); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryTag, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, RepositoryTag{Name: tag.Name, CommitID: tag.Commit.ID, Message: tag.Message})
+	}
+	return items, len(tags) == perPage, nil
+}
+
+func (b *gitlabBrowser) pullRequests(state string, page, perPage int) ([]RepositoryPullRequest, bool, error) {
+	query := pageQuery("page", page, "per_page", perPage)
+	if state == PullRequestOpen {
+		query.Set("state", "opened")
+	} else {
+		query.Set("state", state)
+	}
+	requests := make([]gitlabMergeRequest, 0)
+	if err := b.get("/merge_requests", query, &requests); err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryPullRequest, 0, len(requests))
+	for _, request := range requests {
+		requestState := request.State
+		if requestState == "opened" {
+			requestState = PullRequestOpen
+		}
+		items = append(items, RepositoryPullRequest{
+			ID:           strconv.Itoa(request.IID),
+			Title:        request.Title,
+			State:        requestState,
+			Author:       request.Author.Username,
+			SourceBranch: request.SourceBranch,
+			TargetBranch: request.TargetBranch,
+			CommitID:     request.SHA,
+			HTMLURL:      request.WebURL,
+			CreatedAt:    request.CreatedAt,
+			UpdatedAt:    request.UpdatedAt,
+		})
+	}
+	return items, len(requests) == perPage, nil
+}
+
+// bitbucketBrowser browses repositories of Bitbucket Cloud
+type bitbucketBrowser struct {
+	client      *http.Client
+	apiURL      string
+	fullName    string
+	credentials repositoryCredentials
+}
+
+type bitbucketLinks struct {
+	HTML struct {
+		Href string `json:"href"`
+	} `json:"html"`
+}
+
+type bitbucketCommit struct {
+	Hash    string     `json:"hash"`
+	Message string     `json:"message"`
+	Date    *time.Time `json:"date"`
+	Author  struct {
+		Raw  string `json:"raw"`
+		User struct {
+			DisplayName string `json:"display_name"`
+		} `json:"user"`
+	} `json:"author"`
+	Links bitbucketLinks `json:"links"`
+}
+
+type bitbucketTag struct {
+	Name    string `json:"name"`
+	Message string `json:"message"`
+	Target  struct {
+		Hash string `json:"hash"`
+	} `json:"target"`
+}
+
+type bitbucketPullRequest struct {
+	ID     int    `json:"id"`
+	Title  string `json:"title"`
+	State  string `json:"state"`
+	Author struct {
+		DisplayName string `json:"display_name"`
+	} `json:"author"`
+	Source struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+		Commit struct {
+			Hash string `json:"hash"`
+		} `json:"commit"`
+	} `json:"source"`
+	Destination struct {
+		Branch struct {
+			Name string `json:"name"`
+		} `json:"branch"`
+	} `json:"destination"`
+	CreatedOn *time.Time     `json:"created_on"`
+	UpdatedOn *time.Time     `json:"updated_on"`
+	Links     bitbucketLinks `json:"links"`
+}
+
+var bitbucketPullRequestStates = map[string][]string{
+	PullRequestOpen:   {"OPEN"},
+	PullRequestMerged: {"MERGED"},
+	PullRequestClosed: {"DECLINED", "SUPERSEDED"},
+	PullRequestAll:    {"OPEN", "MERGED", "DECLINED", "SUPERSEDED"},
+}
+
+func (b *bitbucketBrowser) get(path string, query url.Values, values interface{}) (bool, error) {
+	page := struct {
+		Values interface{} `json:"values"`
+		Next   string      `json:"next"`
+	}{Values: values}
+	err := getJSON(b.client, b.apiURL+"/repositories/"+b.fullName+path+"?"+query.Encode(), func(request *http.Request) {
+		switch {
+		case b.credentials.accessToken != "":
+			request.Header.Set("Authorization", "Bearer "+b.credentials.accessToken)
+		case b.credentials.password != "":
+			request.SetBasicAuth(b.credentials.username, b.credentials.password)
+		}
+	}, &page)
+	return page.Next != "", err
+}
+
+func (b *bitbucketBrowser) commits(branch string, page, perPage int) ([]RepositoryCommit, bool, error) {
+	path := "/commits"
+	if branch != "" {
+		path += "/" + url.PathEscape(branch)
+	}
+	commits := make([]bitbucketCommit, 0)
+	hasMore, err := b.get(path, pageQuery("page", page, "pagelen", perPage), &commits)
+	if err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryCommit, 0, len(commits))
+	for _, commit := range commits {
+		author := commit.Author.User.DisplayName
+		if author == "" {
+			author = commit.Author.Raw
+		}
+		items = append(items, RepositoryCommit{
+			ID:          commit.Hash,
+			Message:     commit.Message,
+			Author:      author,
+			CommittedAt: commit.Date,
+			HTMLURL:     commit.Links.HTML.Href,
+		})
+	}
+	return items, hasMore, nil
+}
+
+func (b *bitbucketBrowser) tags(page, perPage int) ([]RepositoryTag, bool, error) {
+	tags := make([]bitbucketTag, 0)
+	hasMore, err := b.get("/refs/tags", pageQuery("page", page, "pagelen", perPage), &tags)
+	if err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryTag, 0, len(tags))
+	for _, tag := range tags {
+		items = append(items, RepositoryTag{Name: tag.Name, CommitID: tag.Target.Hash, Message: tag.Message})
+	}
+	return items, hasMore, nil
+}
+
+func (b *bitbucketBrowser) pullRequests(state string, page, perPage int) ([]RepositoryPullRequest, bool, error) {
+	query := pageQuery("page", page, "pagelen", perPage)
+	query["state"] = bitbucketPullRequestStates[state]
+	pulls := make([]bitbucketPullRequest, 0)
+	hasMore, err := b.get("/pullrequests", query, &pulls)
+	if err != nil {
+		return nil, false, err
+	}
+
+	items := make([]RepositoryPullRequest, 0, len(pulls))
+	for _, pull := range pulls {
+		pullState := PullRequestClosed
+		switch pull.State {
+		case "OPEN":
+			pullState = PullRequestOpen
+		case "MERGED":
+			pullState = PullRequestMerged
+		}
+		items = append(items, RepositoryPullRequest{
+			ID:           strconv.Itoa(pull.ID),
+			Title:        pull.Title,
+			State:        pullState,
+			Author:       pull.Author.DisplayName,
+			SourceBranch: pull.Source.Branch.Name,
+			TargetBranch: pull.Destination.Branch.Name,
+			CommitID:     pull.Source.Commit.Hash,
+			HTMLURL:      pull.Links.HTML.Href,
+			CreatedAt:    pull.CreatedOn,
+			UpdatedAt:    pull.UpdatedOn,
+		})
+	}
+	return items, hasMore, nil
+}
+
+// pageQuery returns the query of a page with the parameter names of a service
+func pageQuery(pageKey string, page int, perPageKey string, perPage int) url.Values {
+	return url.Values{
+		pageKey:    {strconv.Itoa(page)},
+		perPageKey: {strconv.Itoa(perPage)},
+	}
+}
@@ -0,0 +1,228 @@
+package coderepository
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"reflect"
+	"testing"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+// recordingServer responds with the body of the request path and records the last request
+func recordingServer(bodies map[string]string) (*httptest.Server, *http.Request) {
+	last := new(http.Request)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*last = *r
+		body, ok := bodies[r.URL.Path]
+		if !ok {
+			http.Error(w, "not found", http.StatusNotFound)
+			return
+		}
+		fmt.Fprint(w, body)
+	}))
+	return server, last
+}
+
+func TestNewRepositoryBrowser(t *testing.T) {
+	cases := []struct {
+		info        string
+		serviceType string
+		host        string
+		public      bool
+		apiURL      string
+	}{
+		{"github.com", "Github", "https://github.com", true, "https://api.github.com"},
+		{"github enterprise", "Github", "https://github.example.com", false, "https://github.example.com/api/v3"},
+		{"gitee", "Gitee", "https://gitee.com", true, "https://gitee.com/api/v5"},
+		{"self-hosted gitlab", "Gitlab", "https://gitlab.example.com", false, "https://gitlab.example.com/api/v4"},
+		{"bitbucket cloud", "Bitbucket", "https://bitbucket.org", true, "https://api.bitbucket.org/2.0"},
+		{"bitbucket server", "Bitbucket", "https://bitbucket.example.com", false, ""},
+		{"unknown service", "Svn", "https://svn.example.com", false, ""},
+	}
+
+	for _, c := range cases {
+		browser, err := newRepositoryBrowser(c.serviceType, c.host, c.public, "org/repo", repositoryCredentials{})
+		if c.apiURL == "" {
+			if err == nil {
+				t.Errorf("Test Case: %s. newRepositoryBrowser() returned %v, expected error", c.info, browser)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Test Case: %s. newRepositoryBrowser() returned error %v", c.info, err)
+			continue
+		}
+
+		apiURL, client := "", (*http.Client)(nil)
+		switch browser := browser.(type) {
+		case *githubBrowser:
+			apiURL, client = browser.apiURL, browser.client
+		case *gitlabBrowser:
+			apiURL, client = browser.apiURL, browser.client
+		case *bitbucketBrowser:
+			apiURL, client = browser.apiURL, browser.client
+		}
+		if apiURL != c.apiURL {
+			t.Errorf("Test Case: %s. newRepositoryBrowser() uses API %s, expected %s", c.info, apiURL, c.apiURL)
+		}
+		if expected := c.public; (client == browseClient) != expected {
+			t.Errorf("Test Case: %s. newRepositoryBrowser() verifies certificates: %t, expected %t", c.info, client == browseClient, expected)
+		}
+	}
+}
+
+func TestGetBindingSecretNamespace(t *testing.T) {
+	cases := []struct {
+		info            string
+		secretNamespace string
+		expected        string
+		forbidden       bool
+	}{
+		{"secret without namespace", "", "ns1", false},
+		{"secret of the binding namespace", "ns1", "ns1", false},
+		{"global credentials", devopsv1alpha1.NamespaceGlobalCredentials, devopsv1alpha1.NamespaceGlobalCredentials, false},
+		{"secret of another namespace", "ns2", "", true},
+	}
+
+	for _, c := range cases {
+		namespace, err := getBindingSecretNamespace("ns1", c.secretNamespace, "secret")
+		if c.forbidden {
+			if !k8serrors.IsForbidden(err) {
+				t.Errorf("Test Case: %s. getBindingSecretNamespace() returned %q, %v, expected forbidden", c.info, namespace, err)
+			}
+			continue
+		}
+		if err != nil || namespace != c.expected {
+			t.Errorf("Test Case: %s. getBindingSecretNamespace() == %q, %v, expected %q", c.info, namespace, err, c.expected)
+		}
+	}
+}
+
+func TestGithubBrowser(t *testing.T) {
+	server, last := recordingServer(map[string]string{
+		"/repos/org/repo/commits": `[{"sha":"abc","html_url":"https://github.com/org/repo/commit/abc",
+			"commit":{"message":"fix","author":{"name":"alice","email":"alice@example.com","date":"2020-01-02T03:04:05Z"}}},
+			{"sha":"def","commit":{"message":"init","author":{"name":"bob"}}}]`,
+		"/repos/org/repo/tags": `[{"name":"v1.0.0","commit":{"sha":"abc"}}]`,
+		"/repos/org/repo/pulls": `[{"number":2,"title":"merged","state":"closed","merged_at":"2020-01-02T03:04:05Z",
+			"user":{"login":"alice"},"head":{"ref":"feature","sha":"abc"},"base":{"ref":"master"}},
+			{"number":1,"title":"declined","state":"closed","user":{"login":"bob"}}]`,
+	})
+	defer server.Close()
+	browser := &githubBrowser{client: server.Client(), apiURL: server.URL, fullName: "org/repo",
+		credentials: repositoryCredentials{accessToken: "token"}}
+
+	commits, hasMore, err := browser.commits("develop", 2, 2)
+	if err != nil || len(commits) != 2 || !hasMore {
+		t.Fatalf("commits() == %v, %t, %v, expected 2 commits and more", commits, hasMore, err)
+	}
+	if commits[0].ID != "abc" || commits[0].Author != "alice" || commits[0].CommittedAt == nil {
+		t.Errorf("commits() first commit == %+v", commits[0])
+	}
+	if query := last.URL.Query(); query.Get("sha") != "develop" || query.Get("page") != "2" || query.Get("per_page") != "2" {
+		t.Errorf("commits() requested %s, expected branch and page in query", last.URL)
+	}
+	if auth := last.Header.Get("Authorization"); auth != "token token" {
+		t.Errorf("commits() sent Authorization %q, expected the access token", auth)
+	}
+
+	tags, hasMore, err := browser.tags(1, 20)
+	if err != nil || hasMore || !reflect.DeepEqual(tags, []RepositoryTag{{Name: "v1.0.0", CommitID: "abc"}}) {
+		t.Errorf("tags() == %v, %t, %v", tags, hasMore, err)
+	}
+
+	pulls, _, err := browser.pullRequests(PullRequestMerged, 1, 20)
+	if err != nil || len(pulls) != 1 || pulls[0].ID != "2" || pulls[0].State != PullRequestMerged {
+		t.Errorf("pullRequests(merged) == %v, %v, expected only the merged pull request", pulls, err)
+	}
+	if state := last.URL.Query().Get("state"); state != PullRequestClosed {
+		t.Errorf("pullRequests(merged) requested state %s, expected closed", state)
+	}
+
+	if _, _, err := (&githubBrowser{client: server.Client(), apiURL: server.URL, fullName: "org/missing"}).tags(1, 20); err == nil {
+		t.Errorf("tags() of a missing repository returned no error")
+	}
+}
+
+func TestGiteeBrowserToken(t *testing.T) {
+	server, last := recordingServer(map[string]string{"/repos/org/repo/tags": `[]`})
+	defer server.Close()
+	browser := &githubBrowser{client: server.Client(), apiURL: server.URL, fullName: "org/repo", gitee: true,
+		credentials: repositoryCredentials{username: "alice", password: "personal-token"}}
+
+	if _, _, err := browser.tags(1, 20); err != nil {
+		t.Fatalf("tags() returned error %v", err)
+	}
+	if token := last.URL.Query().Get("access_token"); token != "personal-token" {
+		t.Errorf("tags() sent access_token %q, expected the password", token)
+	}
+	if auth := last.Header.Get("Authorization"); auth != "" {
+		t.Errorf("tags() sent Authorization %q, expected none", auth)
+	}
+}
+
+func TestGitlabBrowser(t *testing.T) {
+	server, last := recordingServer(map[string]string{
+		"/projects/group/sub/repo/merge_requests": `[{"iid":3,"title":"feature","state":"opened","author":{"username":"alice"},
+			"source_branch":"feature","target_branch":"master","sha":"abc"}]`,
+	})
+	defer server.Close()
+	browser := &gitlabBrowser{client: server.Client(), apiURL: server.URL, fullName: "group/sub/repo",
+		credentials: repositoryCredentials{password: "private-token"}}
+
+	pulls, hasMore, err := browser.pullRequests(PullRequestOpen, 1, 20)
+	expected := []RepositoryPullRequest{{ID: "3", Title: "feature", State: PullRequestOpen, Author: "alice",
+		SourceBranch: "feature", TargetBranch: "master", CommitID: "abc"}}
+	if err != nil || hasMore || !reflect.DeepEqual(pulls, expected) {
+		t.Errorf("pullRequests() == %v, %t, %v, expected %v", pulls, hasMore, err, expected)
+	}
+	if path := last.URL.EscapedPath(); path != "/projects/group%2Fsub%2Frepo/merge_requests" {
+		t.Errorf("pullRequests() requested %s, expected escaped project path", path)
+	}
+	if state := last.URL.Query().Get("state"); state != "opened" {
+		t.Errorf("pullRequests() requested state %s, expected opened", state)
+	}
+	if token := last.Header.Get("PRIVATE-TOKEN"); token != "private-token" {
+		t.Errorf("pullRequests() sent PRIVATE-TOKEN %q", token)
+	}
+}
+
+func TestBitbucketBrowser(t *testing.T) {
+	server, last := recordingServer(map[string]string{
+		"/repositories/org/repo/commits/release/1.0": `{"values":[{"hash":"abc","message":"fix",
+			"author":{"raw":"alice <alice@example.com>"}}],"next":"https://api.bitbucket.org/next"}`,
+		"/repositories/org/repo/pullrequests": `{"values":[{"id":4,"title":"old","state":"DECLINED",
+			"author":{"display_name":"bob"},"source":{"branch":{"name":"old"},"commit":{"hash":"def"}},
+			"destination":{"branch":{"name":"master"}}}]}`,
+	})
+	defer server.Close()
+	browser := &bitbucketBrowser{client: server.Client(), apiURL: server.URL, fullName: "org/repo",
+		credentials: repositoryCredentials{username: "alice", password: "app-password"}}
+
+	commits, hasMore, err := browser.commits("release/1.0", 1, 1)
+	if err != nil || !hasMore || len(commits) != 1 || commits[0].Author != "alice <alice@example.com>" {
+		t.Errorf("commits() == %v, %t, %v, expected a commit and more", commits, hasMore, err)
+	}
+	if username, password, ok := last.BasicAuth(); !ok || username != "alice" || password != "app-password" {
+		t.Errorf("commits() sent basic auth %s:%s, expected the credentials", username, password)
+	}
+
+	pulls, hasMore, err := browser.pullRequests(PullRequestClosed, 1, 20)
+	if err != nil || hasMore || len(pulls) != 1 || pulls[0].State != PullRequestClosed || pulls[0].TargetBranch != "master" {
+		t.Errorf("pullRequests() == %v, %t, %v, expected the declined pull request", pulls, hasMore, err)
+	}
+	if states := last.URL.Query()["state"]; !reflect.DeepEqual(states, []string{"DECLINED", "SUPERSEDED"}) {
+		t.Errorf("pullRequests() requested states %v, expected declined and superseded", states)
+	}
+}
+
+func TestPageQuery(t *testing.T) {
+	expected := url.Values{"page": {"3"}, "pagelen": {"50"}}
+	if query := pageQuery("page", 3, "pagelen", 50); !reflect.DeepEqual(query, expected) {
+		t.Errorf("pageQuery() == %v, expected %v", query, expected)
+	}
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// DefaultBatchWorkers is the number of objects processed concurrently by a batch by default
+	DefaultBatchWorkers = 5
+	// MaxBatchWorkers is the maximum number of objects processed concurrently by a batch
+	MaxBatchWorkers = 20
+)
+
+// BatchOptions defines how a batch action is executed
+type BatchOptions struct {
+	// DryRun only returns the selected objects without executing the action
+	DryRun bool
+	// Workers is the number of objects processed concurrently
+	Workers int
+	// Confirm allows a batch to act on every object when its selection is not narrowed by any filter
+	Confirm bool
+}
+
+// CheckBatchSelection rejects a batch which is not narrowed by any filter, because it would act on every
+// object of the namespaces. Dry runs and confirmed batches are allowed.
+func CheckBatchSelection(filtered bool, options BatchOptions) error {
+	if filtered || options.DryRun || options.Confirm {
+		return nil
+	}
+	return errors.NewBadRequest("batch without a filter acts on every object, narrow it down or set confirm to true")
+}
+
+// BatchTarget is an object which a batch action is executed on
+type BatchTarget struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+}
+
+// BatchItemResult is the result of a batch action on a single object
+type BatchItemResult struct {
+	BatchTarget
+	Succeeded bool   `json:"succeeded"`
+	Error     string `json:"error,omitempty"`
+	// Result is the object returned by the action, e.g. the created pipeline
+	Result interface{} `json:"result,omitempty"`
+}
+
+// BatchResult is the result of a batch action
+type BatchResult struct {
+	DryRun    bool `json:"dryRun"`
+	Total     int  `json:"total"`
+	Succeeded int  `json:"succeeded"`
+	Failed    int  `json:"failed"`
+	// Items are in the same order as the targets
+	Items []BatchItemResult `json:"items"`
+}
+
+// RunBatch executes the action on every target with a bounded number of workers. In dry run mode
+// the targets are returned as succeeded without executing the action.
+func RunBatch(targets []BatchTarget, options BatchOptions, action func(target BatchTarget) (interface{}, error)) *BatchResult {
+	result := &BatchResult{
+		DryRun: options.DryRun,
+		Total:  len(targets),
+		Items:  make([]BatchItemResult, len(targets)),
+	}
+
+	workers := options.Workers
+	if workers <= 0 {
+		workers = DefaultBatchWorkers
+	}
+	if workers > MaxBatchWorkers {
+		workers = MaxBatchWorkers
+	}
+
+	queue := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range queue {
+				item := BatchItemResult{BatchTarget: targets[i], Succeeded: true}
+				if !options.DryRun {
+					obj, err := action(targets[i])
+					if err != nil {
+						item.Succeeded = false
+						item.Error = err.Error()
+					} else {
+						item.Result = obj
+					}
+				}
+				result.Items[i] = item
+			}
+		}()
+	}
+
+	for i := range targets {
+		queue <- i
+	}
+	close(queue)
+	wg.Wait()
+
+	for _, item := range result.Items {
+		if item.Succeeded {
+			result.Succeeded++
+		} else {
+			result.Failed++
+		}
+	}
+	return result
+}
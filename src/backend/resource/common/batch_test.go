@@ -0,0 +1,82 @@
+package common
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestRunBatch(t *testing.T) {
+	targets := []BatchTarget{{"ns", "a"}, {"ns", "b"}, {"ns", "c"}, {"ns", "d"}}
+
+	var (
+		lock             sync.Mutex
+		running, maximum int
+	)
+	action := func(target BatchTarget) (interface{}, error) {
+		lock.Lock()
+		running++
+		if running > maximum {
+			maximum = running
+		}
+		lock.Unlock()
+		defer func() {
+			lock.Lock()
+			running--
+			lock.Unlock()
+		}()
+
+		if target.Name == "c" {
+			return nil, fmt.Errorf("failed %s", target.Name)
+		}
+		return target.Name, nil
+	}
+
+	result := RunBatch(targets, BatchOptions{Workers: 2}, action)
+	if result.Total != 4 || result.Succeeded != 3 || result.Failed != 1 {
+		t.Errorf("RunBatch() == %+v, expected 3 succeeded and 1 failed", result)
+	}
+	if maximum > 2 {
+		t.Errorf("RunBatch() ran %d actions concurrently, expected at most 2", maximum)
+	}
+	for i, item := range result.Items {
+		if item.BatchTarget != targets[i] {
+			t.Errorf("RunBatch() item %d is %v, expected %v", i, item.BatchTarget, targets[i])
+		}
+	}
+	if item := result.Items[2]; item.Succeeded || item.Error != "failed c" {
+		t.Errorf("RunBatch() item for c == %+v, expected failure", item)
+	}
+	if item := result.Items[0]; item.Result != "a" {
+		t.Errorf("RunBatch() item for a == %+v, expected result a", item)
+	}
+
+	called := false
+	result = RunBatch(targets, BatchOptions{DryRun: true}, func(target BatchTarget) (interface{}, error) {
+		called = true
+		return nil, nil
+	})
+	if called || !result.DryRun || result.Succeeded != 4 {
+		t.Errorf("RunBatch() in dry run == %+v, expected no action called", result)
+	}
+}
+
+func TestCheckBatchSelection(t *testing.T) {
+	cases := []struct {
+		info     string
+		filtered bool
+		options  BatchOptions
+		err      bool
+	}{
+		{"Filtered batch is allowed", true, BatchOptions{}, false},
+		{"Unfiltered batch is rejected", false, BatchOptions{}, true},
+		{"Unfiltered dry run is allowed", false, BatchOptions{DryRun: true}, false},
+		{"Confirmed unfiltered batch is allowed", false, BatchOptions{Confirm: true}, false},
+	}
+
+	for _, c := range cases {
+		if err := CheckBatchSelection(c.filtered, c.options); (err != nil) != c.err {
+			t.Errorf("Test Case: %s. Unexpected error: %v", c.info, err)
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package common
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronSearchYears limits the search of the next fire time for expressions which never fire, e.g. 30 2 31 2 *
+const cronSearchYears = 5
+
+// CronSchedule is a parsed cron expression with five fields: minute, hour, day of month, month and
+// day of week. Fields support '*', lists, ranges, steps and names of months and days of week.
+type CronSchedule struct {
+	minute, hour, dayOfMonth, month, dayOfWeek uint64
+	// day of month and day of week match any of them when both are restricted
+	dayOfMonthAny, dayOfWeekAny bool
+	location                    *time.Location
+}
+
+type cronField struct {
+	min, max int
+	names    []string
+}
+
+var (
+	cronMinute     = cronField{min: 0, max: 59}
+	cronHour       = cronField{min: 0, max: 23}
+	cronDayOfMonth = cronField{min: 1, max: 31}
+	cronMonth      = cronField{min: 1, max: 12, names: []string{"", "JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}}
+	// 7 is Sunday as well as 0
+	cronDayOfWeek = cronField{min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}}
+
+	cronAliases = map[string]string{
+		"@yearly":   "0 0 1 1 *",
+		"@annually": "0 0 1 1 *",
+		"@monthly":  "0 0 1 * *",
+		"@weekly":   "0 0 * * 0",
+		"@daily":    "0 0 * * *",
+		"@midnight": "0 0 * * *",
+		"@hourly":   "0 * * * *",
+	}
+)
+
+// ParseCron parses the cron expression in the timezone. Empty timezone means UTC.
+func ParseCron(expression, timezone string) (*CronSchedule, error) {
+	location, err := time.LoadLocation(timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %v", timezone, err)
+	}
+
+	expression = strings.TrimSpace(expression)
+	if alias, ok := cronAliases[strings.ToLower(expression)]; ok {
+		expression = alias
+	}
+	fields := strings.Fields(expression)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields, got %d", expression, len(fields))
+	}
+
+	schedule := &CronSchedule{
+		location:      location,
+		dayOfMonthAny: strings.HasPrefix(fields[2], "*"),
+		dayOfWeekAny:  strings.HasPrefix(fields[4], "*"),
+	}
+	for i, f := range []struct {
+		bits  *uint64
+		field cronField
+	}{
+		{&schedule.minute, cronMinute},
+		{&schedule.hour, cronHour},
+		{&schedule.dayOfMonth, cronDayOfMonth},
+		{&schedule.month, cronMonth},
+		{&schedule.dayOfWeek, cronDayOfWeek},
+	} {
+		if *f.bits, err = f.field.parse(fields[i]); err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %v", expression, err)
+		}
+	}
+	if schedule.dayOfWeek&(1<<7) != 0 {
+		schedule.dayOfWeek |= 1
+	}
+	return schedule, nil
+}
+
+// parse returns bits of values matched by the field
+func (f cronField) parse(value string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		step, stepped := 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			stepped = true
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			part = part[:i]
+		}
+
+		start, end := f.min, f.max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			var err error
+			if start, err = f.value(bounds[0]); err != nil {
+				return 0, err
+			}
+			if end, err = f.value(bounds[1]); err != nil {
+				return 0, err
+			}
+		default:
+			var err error
+			if start, err = f.value(part); err != nil {
+				return 0, err
+			}
+			// a single value with a step runs until the maximum, e.g. 5/15
+			if !stepped {
+				end = start
+			}
+		}
+		if start > end {
+			return 0, fmt.Errorf("invalid range %q", part)
+		}
+
+		for v := start; v <= end; v += step {
+			bits |= 1 << uint(v)
+		}
+	}
+	return bits, nil
+}
+
+// value parses a number or a name of the field
+func (f cronField) value(value string) (int, error) {
+	for i, name := range f.names {
+		if name != "" && strings.EqualFold(name, value) {
+			return i, nil
+		}
+	}
+	v, err := strconv.Atoi(value)
+	if err != nil || v < f.min || v > f.max {
+		return 0, fmt.Errorf("value %q out of range %d-%d", value, f.min, f.max)
+	}
+	return v, nil
+}
+
+// Location returns the timezone of the schedule
+func (s *CronSchedule) Location() *time.Location {
+	return s.location
+}
+
+// Next returns the first fire time after the given time, zero when the schedule never fires
+func (s *CronSchedule) Next(after time.Time) time.Time {
+	t := after.In(s.location).Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(cronSearchYears, 0, 0)
+
+	for t.Before(limit) {
+		year, month, day := t.Date()
+		var next time.Time
+		switch {
+		case !hasCronValue(s.month, int(month)):
+			next = time.Date(year, month+1, 1, 0, 0, 0, 0, s.location)
+		case !s.matchesDay(t):
+			next = time.Date(year, month, day+1, 0, 0, 0, 0, s.location)
+		case !hasCronValue(s.hour, t.Hour()):
+			next = time.Date(year, month, day, t.Hour()+1, 0, 0, 0, s.location)
+		case !hasCronValue(s.minute, t.Minute()):
+			next = t.Add(time.Minute)
+		default:
+			return t
+		}
+		// daylight saving changes may map the wall clock back, always move forward
+		if !next.After(t) {
+			next = t.Add(time.Minute)
+		}
+		t = next
+	}
+	return time.Time{}
+}
+
+// NextN returns up to n fire times after the given time
+func (s *CronSchedule) NextN(after time.Time, n int) []time.Time {
+	result := make([]time.Time, 0, n)
+	for len(result) < n {
+		after = s.Next(after)
+		if after.IsZero() {
+			break
+		}
+		result = append(result, after)
+	}
+	return result
+}
+
+func (s *CronSchedule) matchesDay(t time.Time) bool {
+	dayOfMonth := hasCronValue(s.dayOfMonth, t.Day())
+	dayOfWeek := hasCronValue(s.dayOfWeek, int(t.Weekday()))
+	if s.dayOfMonthAny || s.dayOfWeekAny {
+		return dayOfMonth && dayOfWeek
+	}
+	return dayOfMonth || dayOfWeek
+}
+
+func hasCronValue(bits uint64, value int) bool {
+	return bits&(1<<uint(value)) != 0
+}
@@ -0,0 +1,81 @@
+package common
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCron(t *testing.T) {
+	cases := []struct {
+		expression string
+		timezone   string
+		err        bool
+	}{
+		{"*/15 * * * *", "", false},
+		{"0 9-17 * * MON-FRI", "Asia/Shanghai", false},
+		{"@daily", "", false},
+		{"0 0 * *", "", true},
+		{"60 * * * *", "", true},
+		{"* * * * 8", "", true},
+		{"5-1 * * * *", "", true},
+		{"*/0 * * * *", "", true},
+		{"* * * * *", "Mars/Olympus", true},
+	}
+
+	for _, c := range cases {
+		_, err := ParseCron(c.expression, c.timezone)
+		if (err != nil) != c.err {
+			t.Errorf("ParseCron(%q, %q) returned error %v", c.expression, c.timezone, err)
+		}
+	}
+}
+
+func TestCronScheduleNext(t *testing.T) {
+	start := time.Date(2019, 8, 9, 10, 7, 30, 0, time.UTC) // Friday
+
+	cases := []struct {
+		expression string
+		timezone   string
+		expected   []time.Time
+	}{
+		{"*/15 * * * *", "", []time.Time{
+			time.Date(2019, 8, 9, 10, 15, 0, 0, time.UTC),
+			time.Date(2019, 8, 9, 10, 30, 0, 0, time.UTC),
+		}},
+		{"30 8 * * MON-FRI", "", []time.Time{
+			time.Date(2019, 8, 12, 8, 30, 0, 0, time.UTC),
+			time.Date(2019, 8, 13, 8, 30, 0, 0, time.UTC),
+		}},
+		// restricted day of month and day of week match any of them
+		{"0 0 1 * 0", "", []time.Time{
+			time.Date(2019, 8, 11, 0, 0, 0, 0, time.UTC),
+			time.Date(2019, 8, 18, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 9 * * 7", "Asia/Shanghai", []time.Time{
+			time.Date(2019, 8, 11, 1, 0, 0, 0, time.UTC),
+			time.Date(2019, 8, 18, 1, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 29 2 *", "", []time.Time{
+			time.Date(2020, 2, 29, 0, 0, 0, 0, time.UTC),
+			time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC),
+		}},
+		{"0 0 31 2 *", "", []time.Time{}},
+	}
+
+	for _, c := range cases {
+		schedule, err := ParseCron(c.expression, c.timezone)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) returned error %v", c.expression, err)
+		}
+		actual := schedule.NextN(start, 2)
+		if len(actual) != len(c.expected) {
+			t.Errorf("NextN(%q) == %v, expected %v", c.expression, actual, c.expected)
+			continue
+		}
+		for i := range actual {
+			if !actual[i].Equal(c.expected[i]) {
+				t.Errorf("NextN(%q)[%d] == %v, expected %v", c.expression, i, actual[i], c.expected[i])
+			}
+		}
+	}
+}
@@ -64,6 +64,16 @@ const (
 	AnnotationsKeyMultiBranchPRList = "alauda.io/jenkins.pr"
 	// AnnotationsKeyMultiBranchStalePRList stale pr list for multibranch
 	AnnotationsKeyMultiBranchStalePRList = "alauda.io/jenkins.stale.pr"
+	// AnnotationsKeyParameterPresets saved parameter presets for pipeline config
+	AnnotationsKeyParameterPresets = "alauda.io/parameterPresets"
+	// AnnotationsKeySchedules cron schedules for pipeline config
+	AnnotationsKeySchedules = "alauda.io/schedules"
+
+	// AnnotationsKeyExpiresAt expiry of the credentials of a secret in RFC3339 format
+	AnnotationsKeyExpiresAt = "alauda.io/expiresAt"
+
+	// AnnotationsKeyTemplateVersion version of a pipeline template
+	AnnotationsKeyTemplateVersion = "version"
 
 	// AnnotationsCommit commit ID for pipeline
 	AnnotationsCommit = "alauda.io/commit"
@@ -79,6 +89,8 @@ const (
 	RoleNameProjectManager = "devops-project-manager"
 	// RoleNameAlaudaProjectManager Role for project manager
 	RoleNameAlaudaProjectManager = "alauda_project_admin"
+	// LabelPipelineConfigRevisions name of the pipeline config whose revisions are kept in a configmap
+	LabelPipelineConfigRevisions = "alauda.io/pipelineConfig.revisions"
 	// LabelApplicationKey key in label for application
 	LabelApplicationKey    = "app"
 	LabelPipelineConfigKey = ""
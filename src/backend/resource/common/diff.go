@@ -0,0 +1,169 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+)
+
+// MaxDiffCells is the maximum product of the numbers of changed lines of two texts which are diffed. The
+// longest common subsequence of the lines needs memory proportional to it.
+const MaxDiffCells = 1000000
+
+type diffOp struct {
+	kind byte
+	text string
+	// from and to are indexes of the line in the old and new text
+	from, to int
+}
+
+// UnifiedDiff returns the line based diff of two texts in unified format with context unchanged lines
+// around every change. It returns an empty string when the texts are equal. Texts whose changed lines
+// exceed MaxDiffCells are not diffed, only a note that they are too large is returned.
+func UnifiedDiff(fromName, toName, from, to string, context int) string {
+	if from == to {
+		return ""
+	}
+	ops, ok := diffLines(splitLines(from), splitLines(to))
+
+	var builder strings.Builder
+	fmt.Fprintf(&builder, "--- %s\n+++ %s\n", fromName, toName)
+	if !ok {
+		builder.WriteString("too large to diff\n")
+		return builder.String()
+	}
+	for start := 0; start < len(ops); {
+		// find the next change and the end of changes closer than two contexts to each other
+		first := start
+		for first < len(ops) && ops[first].kind == ' ' {
+			first++
+		}
+		if first == len(ops) {
+			break
+		}
+		last := first
+		for i := first; i < len(ops) && i-last <= 2*context; i++ {
+			if ops[i].kind != ' ' {
+				last = i
+			}
+		}
+
+		begin, end := maxInt(first-context, start), minInt(last+context+1, len(ops))
+		writeHunk(&builder, ops[begin:end])
+		start = end
+	}
+	return builder.String()
+}
+
+func writeHunk(builder *strings.Builder, ops []diffOp) {
+	fromStart, toStart := ops[0].from, ops[0].to
+	fromCount, toCount := 0, 0
+	for _, op := range ops {
+		if op.kind != '+' {
+			fromCount++
+		}
+		if op.kind != '-' {
+			toCount++
+		}
+	}
+	fmt.Fprintf(builder, "@@ -%s +%s @@\n", hunkRange(fromStart, fromCount), hunkRange(toStart, toCount))
+	for _, op := range ops {
+		fmt.Fprintf(builder, "%c%s\n", op.kind, op.text)
+	}
+}
+
+// hunkRange formats the range of a hunk, an empty range starts at the line before it
+func hunkRange(start, count int) string {
+	if count == 0 {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, count)
+}
+
+// diffLines returns operations turning from into to using the longest common subsequence of lines. Common
+// leading and trailing lines are skipped, it returns false when the remaining lines exceed MaxDiffCells.
+func diffLines(from, to []string) ([]diffOp, bool) {
+	prefix := 0
+	for prefix < len(from) && prefix < len(to) && from[prefix] == to[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(from)-prefix && suffix < len(to)-prefix && from[len(from)-1-suffix] == to[len(to)-1-suffix] {
+		suffix++
+	}
+	n, m := len(from)-prefix-suffix, len(to)-prefix-suffix
+	if n > 0 && m > 0 && n > MaxDiffCells/m {
+		return nil, false
+	}
+
+	ops := make([]diffOp, 0, len(from)+len(to))
+	for i := 0; i < prefix; i++ {
+		ops = append(ops, diffOp{kind: ' ', text: from[i], from: i, to: i})
+	}
+	for _, op := range diffChangedLines(from[prefix:prefix+n], to[prefix:prefix+m]) {
+		op.from += prefix
+		op.to += prefix
+		ops = append(ops, op)
+	}
+	for i := 0; i < suffix; i++ {
+		ops = append(ops, diffOp{kind: ' ', text: from[prefix+n+i], from: prefix + n + i, to: prefix + m + i})
+	}
+	return ops, true
+}
+
+// diffChangedLines returns operations turning from into to using the longest common subsequence of lines
+func diffChangedLines(from, to []string) []diffOp {
+	n, m := len(from), len(to)
+	// lcs[i][j] is the length of the longest common subsequence of from[i:] and to[j:]
+	lcs := make([][]int, n+1)
+	for i := range lcs {
+		lcs[i] = make([]int, m+1)
+	}
+	for i := n - 1; i >= 0; i-- {
+		for j := m - 1; j >= 0; j-- {
+			if from[i] == to[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = maxInt(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	ops := make([]diffOp, 0, n+m)
+	i, j := 0, 0
+	for i < n || j < m {
+		switch {
+		case i < n && j < m && from[i] == to[j]:
+			ops = append(ops, diffOp{kind: ' ', text: from[i], from: i, to: j})
+			i++
+			j++
+		case j < m && (i == n || lcs[i][j+1] > lcs[i+1][j]):
+			ops = append(ops, diffOp{kind: '+', text: to[j], from: i, to: j})
+			j++
+		default:
+			ops = append(ops, diffOp{kind: '-', text: from[i], from: i, to: j})
+			i++
+		}
+	}
+	return ops
+}
+
+func splitLines(text string) []string {
+	if text == "" {
+		return []string{}
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,44 @@
+package common
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestUnifiedDiff(t *testing.T) {
+	cases := []struct {
+		from, to string
+		context  int
+		expected string
+	}{
+		{"a\nb\n", "a\nb\n", 3, ""},
+		{"a\nb\nc\n", "a\nx\nc\n", 1, "--- old\n+++ new\n@@ -1,3 +1,3 @@\n a\n-b\n+x\n c\n"},
+		{"", "a\n", 3, "--- old\n+++ new\n@@ -0,0 +1,1 @@\n+a\n"},
+		{"a\nb\nc\nd\ne\nf\ng\n", "b\nc\nd\ne\nf\ng\nh\n", 1,
+			"--- old\n+++ new\n@@ -1,2 +1,1 @@\n-a\n b\n@@ -7,1 +6,2 @@\n g\n+h\n"},
+	}
+
+	for _, c := range cases {
+		actual := UnifiedDiff("old", "new", c.from, c.to, c.context)
+		if actual != c.expected {
+			t.Errorf("UnifiedDiff(%q, %q) ==\n%s\nexpected\n%s", c.from, c.to, actual, c.expected)
+		}
+	}
+}
+
+func TestUnifiedDiffTooLarge(t *testing.T) {
+	from := strings.Repeat("a\n", 1001)
+	to := strings.Repeat("b\n", 1000)
+	expected := "--- old\n+++ new\ntoo large to diff\n"
+	if actual := UnifiedDiff("old", "new", from, to, 3); actual != expected {
+		t.Errorf("UnifiedDiff() of large texts ==\n%s\nexpected\n%s", actual, expected)
+	}
+
+	// unchanged lines around a small change are not counted
+	from = strings.Repeat("a\n", 2000) + "b\n" + strings.Repeat("c\n", 2000)
+	to = strings.Repeat("a\n", 2000) + "x\n" + strings.Repeat("c\n", 2000)
+	expected = "--- old\n+++ new\n@@ -2000,3 +2000,3 @@\n a\n-b\n+x\n c\n"
+	if actual := UnifiedDiff("old", "new", from, to, 1); actual != expected {
+		t.Errorf("UnifiedDiff() of a small change in large texts ==\n%s\nexpected\n%s", actual, expected)
+	}
+}
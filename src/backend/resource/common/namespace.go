@@ -62,6 +62,11 @@ func (n *NamespaceQuery) ToRequestParam() string {
 	return api.NamespaceAll
 }
 
+// Namespaces returns namespaces selected by this query. Empty list means all namespaces.
+func (n *NamespaceQuery) Namespaces() []string {
+	return n.namespaces
+}
+
 // Matches returns true when the given namespace matches this query.
 func (n *NamespaceQuery) Matches(namespace string) bool {
 	if len(n.namespaces) == 0 {
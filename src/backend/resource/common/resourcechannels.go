@@ -16,6 +16,7 @@ package common
 
 import (
 	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/cache"
 	apps "k8s.io/api/apps/v1"
 	autoscaling "k8s.io/api/autoscaling/v2beta1"
 	batch "k8s.io/api/batch/v1"
@@ -135,7 +136,7 @@ func GetServiceListChannel(client client.Interface, nsQuery *NamespaceQuery,
 		Error: make(chan error, numReads),
 	}
 	go func() {
-		list, err := client.CoreV1().Services(nsQuery.ToRequestParam()).List(api.ListEverything)
+		list, err := cache.ListServices(client, nsQuery.ToRequestParam(), api.ListEverything)
 		var filteredItems []v1.Service
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -355,7 +356,7 @@ func GetPodListChannelWithOptions(client client.Interface, nsQuery *NamespaceQue
 	}
 
 	go func() {
-		list, err := client.CoreV1().Pods(nsQuery.ToRequestParam()).List(options)
+		list, err := cache.ListPods(client, nsQuery.ToRequestParam(), options)
 		var filteredItems []v1.Pod
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -425,8 +426,7 @@ func GetDeploymentListChannel(client client.Interface,
 	}
 
 	go func() {
-		list, err := client.AppsV1().Deployments(nsQuery.ToRequestParam()).
-			List(api.ListEverything)
+		list, err := cache.ListDeployments(client, nsQuery.ToRequestParam(), api.ListEverything)
 		var filteredItems []apps.Deployment
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -466,8 +466,7 @@ func GetReplicaSetListChannelWithOptions(client client.Interface, nsQuery *Names
 	}
 
 	go func() {
-		list, err := client.AppsV1().ReplicaSets(nsQuery.ToRequestParam()).
-			List(options)
+		list, err := cache.ListReplicaSets(client, nsQuery.ToRequestParam(), options)
 		var filteredItems []apps.ReplicaSet
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -499,7 +498,7 @@ func GetDaemonSetListChannel(client client.Interface, nsQuery *NamespaceQuery, n
 	}
 
 	go func() {
-		list, err := client.Apps().DaemonSets(nsQuery.ToRequestParam()).List(api.ListEverything)
+		list, err := cache.ListDaemonSets(client, nsQuery.ToRequestParam(), api.ListEverything)
 		var filteredItems []apps.DaemonSet
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -531,7 +530,7 @@ func GetJobListChannel(client client.Interface,
 	}
 
 	go func() {
-		list, err := client.BatchV1().Jobs(nsQuery.ToRequestParam()).List(api.ListEverything)
+		list, err := cache.ListJobs(client, nsQuery.ToRequestParam(), api.ListEverything)
 		var filteredItems []batch.Job
 		for _, item := range list.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -595,7 +594,7 @@ func GetStatefulSetListChannel(client client.Interface,
 	}
 
 	go func() {
-		statefulSets, err := client.Apps().StatefulSets(nsQuery.ToRequestParam()).List(api.ListEverything)
+		statefulSets, err := cache.ListStatefulSets(client, nsQuery.ToRequestParam(), api.ListEverything)
 		var filteredItems []apps.StatefulSet
 		for _, item := range statefulSets.Items {
 			if nsQuery.Matches(item.ObjectMeta.Namespace) {
@@ -0,0 +1,238 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"alauda.io/diablo/src/backend/resource/logs"
+	"k8s.io/api/core/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// logSource identifies a single container log that is a part of an aggregated log.
+type logSource struct {
+	podName       string
+	containerName string
+}
+
+// getLogSources returns containers of given pods whose logs should be aggregated. When container
+// is empty all containers of every pod are used, otherwise only pods that have it.
+func getLogSources(pods []v1.Pod, container string) []logSource {
+	sources := make([]logSource, 0)
+	for _, pod := range pods {
+		for _, c := range pod.Spec.Containers {
+			if len(container) == 0 || c.Name == container {
+				sources = append(sources, logSource{podName: pod.Name, containerName: c.Name})
+			}
+		}
+	}
+	return sources
+}
+
+// GetAggregatedLogDetails reads logs of containers of all given pods, merges them by timestamp and
+// returns part of them selected by logSelector. Every line is tagged with its pod and container.
+// When container is empty logs of all containers are read.
+func GetAggregatedLogDetails(client kubernetes.Interface, namespace string, pods []v1.Pod, container string,
+	logSelector *logs.Selection, usePreviousLogs bool) (*logs.LogDetails, error) {
+	sources := getLogSources(pods, container)
+	results := make([]logs.LogLines, len(sources))
+	truncated := make([]bool, len(sources))
+	errs := make([]error, len(sources))
+
+	var wg sync.WaitGroup
+	for i, source := range sources {
+		wg.Add(1)
+		go func(i int, source logSource) {
+			defer wg.Done()
+			logOptions := mapToLogOptions(source.containerName, logSelector, usePreviousLogs)
+			rawLogs, err := readRawLogs(client, namespace, source.podName, logOptions)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+
+			lines := logs.ToLogLines(rawLogs)
+			for j := range lines {
+				lines[j].PodName = source.podName
+				lines[j].ContainerName = source.containerName
+			}
+			results[i] = lines
+			truncated[i] = isReadLimitReached(int64(len(rawLogs)), int64(len(lines)), logSelector.LogFilePosition)
+		}(i, source)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	readLimitReached := false
+	for _, t := range truncated {
+		readLimitReached = readLimitReached || t
+	}
+
+	merged := mergeLogLines(results)
+	logLines, fromDate, toDate, logSelection, lastPage := merged.SelectLogs(logSelector)
+
+	podNames := make([]string, 0, len(pods))
+	for _, pod := range pods {
+		podNames = append(podNames, pod.Name)
+	}
+
+	return &logs.LogDetails{
+		Info: logs.LogInfo{
+			PodName:       strings.Join(podNames, ","),
+			ContainerName: container,
+			FromDate:      fromDate,
+			ToDate:        toDate,
+			Truncated:     readLimitReached && lastPage,
+		},
+		Selection: logSelection,
+		LogLines:  logLines,
+	}, nil
+}
+
+// mergeLogLines merges log lines of many containers into a single list ordered by timestamp.
+// Lines with equal timestamps keep their order.
+func mergeLogLines(lines []logs.LogLines) logs.LogLines {
+	merged := logs.LogLines{}
+	for _, l := range lines {
+		merged = append(merged, l...)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return parseLogTimestamp(merged[i].Timestamp).Before(parseLogTimestamp(merged[j].Timestamp))
+	})
+	return merged
+}
+
+// parseLogTimestamp returns time of the log line. Lines without valid timestamp, e.g. errors returned
+// instead of logs, are treated as the oldest ones.
+func parseLogTimestamp(timestamp logs.LogTimestamp) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, string(timestamp))
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// GetAggregatedLogFile returns a stream of logs of containers of all given pods merged by
+// timestamp. Every line is prefixed with timestamp, pod and container name. Logs are streamed
+// from the apiserver to avoid out of memory issues. Containers whose logs can not be read, e.g.
+// of pending pods, are skipped with a marker line at the beginning of the file.
+func GetAggregatedLogFile(client kubernetes.Interface, namespace string, pods []v1.Pod, container string,
+	usePreviousLogs bool) (io.ReadCloser, error) {
+	sources := getLogSources(pods, container)
+	opened := make([]logSource, 0, len(sources))
+	streams := make([]io.ReadCloser, 0, len(sources))
+	skipped := make([]string, 0)
+
+	for _, source := range sources {
+		logOptions := &v1.PodLogOptions{
+			Container:  source.containerName,
+			Follow:     false,
+			Previous:   usePreviousLogs,
+			Timestamps: true,
+		}
+		stream, err := openStream(client, namespace, source.podName, logOptions)
+		if err != nil {
+			skipped = append(skipped, fmt.Sprintf("%s/%s logs skipped: %v\n", source.podName, source.containerName, err))
+			continue
+		}
+		opened = append(opened, source)
+		streams = append(streams, stream)
+	}
+
+	reader, writer := io.Pipe()
+	go func() {
+		defer func() {
+			for _, stream := range streams {
+				stream.Close()
+			}
+		}()
+		for _, marker := range skipped {
+			if _, err := io.WriteString(writer, marker); err != nil {
+				writer.CloseWithError(err)
+				return
+			}
+		}
+		writer.CloseWithError(mergeLogStreams(writer, opened, streams))
+	}()
+	return reader, nil
+}
+
+// mergeLogStreams writes lines of all streams to the writer ordered by timestamp. Every stream
+// has to be ordered already, so only the first unread line of each stream is compared.
+func mergeLogStreams(w io.Writer, sources []logSource, streams []io.ReadCloser) error {
+	readers := make([]*bufio.Reader, len(streams))
+	heads := make([]*logs.LogLine, len(streams))
+
+	next := func(i int) error {
+		heads[i] = nil
+		for {
+			line, err := readers[i].ReadString('\n')
+			if err != nil && err != io.EOF {
+				return err
+			}
+			if parsed := logs.ToLogLines(line); len(parsed) > 0 {
+				heads[i] = &parsed[0]
+				return nil
+			}
+			if err == io.EOF {
+				return nil
+			}
+		}
+	}
+
+	for i, stream := range streams {
+		readers[i] = bufio.NewReader(stream)
+		if err := next(i); err != nil {
+			return err
+		}
+	}
+
+	for {
+		oldest := -1
+		for i, head := range heads {
+			if head == nil {
+				continue
+			}
+			if oldest == -1 || parseLogTimestamp(head.Timestamp).Before(parseLogTimestamp(heads[oldest].Timestamp)) {
+				oldest = i
+			}
+		}
+		if oldest == -1 {
+			return nil
+		}
+
+		head := heads[oldest]
+		if _, err := fmt.Fprintf(w, "%s %s/%s %s\n", head.Timestamp, sources[oldest].podName,
+			sources[oldest].containerName, head.Content); err != nil {
+			return err
+		}
+		if err := next(oldest); err != nil {
+			return err
+		}
+	}
+}
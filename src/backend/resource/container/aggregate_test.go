@@ -0,0 +1,103 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package container
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"reflect"
+	"strings"
+	"testing"
+
+	"alauda.io/diablo/src/backend/resource/logs"
+	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newPodWithContainers(name string, containers ...string) v1.Pod {
+	pod := v1.Pod{ObjectMeta: metaV1.ObjectMeta{Name: name}}
+	for _, c := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, v1.Container{Name: c})
+	}
+	return pod
+}
+
+func TestGetLogSources(t *testing.T) {
+	pods := []v1.Pod{
+		newPodWithContainers("pod-1", "app", "sidecar"),
+		newPodWithContainers("pod-2", "app"),
+	}
+
+	cases := []struct {
+		container string
+		expected  []logSource
+	}{
+		{"", []logSource{{"pod-1", "app"}, {"pod-1", "sidecar"}, {"pod-2", "app"}}},
+		{"sidecar", []logSource{{"pod-1", "sidecar"}}},
+		{"missing", []logSource{}},
+	}
+
+	for _, c := range cases {
+		actual := getLogSources(pods, c.container)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("getLogSources(%q) == %v, expected %v", c.container, actual, c.expected)
+		}
+	}
+}
+
+func TestMergeLogLines(t *testing.T) {
+	pod1 := logs.LogLines{
+		{Timestamp: "2018-01-01T00:00:01Z", Content: "a1", PodName: "pod-1"},
+		{Timestamp: "2018-01-01T00:00:03.5Z", Content: "a2", PodName: "pod-1"},
+	}
+	pod2 := logs.LogLines{
+		{Timestamp: "2018-01-01T00:00:02Z", Content: "b1", PodName: "pod-2"},
+		{Timestamp: "2018-01-01T00:00:03.25Z", Content: "b2", PodName: "pod-2"},
+		{Timestamp: "0", Content: "error", PodName: "pod-2"},
+	}
+
+	actual := mergeLogLines([]logs.LogLines{pod1, pod2})
+	contents := make([]string, 0)
+	for _, line := range actual {
+		contents = append(contents, line.Content)
+	}
+
+	expected := []string{"error", "a1", "b1", "b2", "a2"}
+	if !reflect.DeepEqual(contents, expected) {
+		t.Errorf("mergeLogLines() == %v, expected %v", contents, expected)
+	}
+}
+
+func TestMergeLogStreams(t *testing.T) {
+	sources := []logSource{{"pod-1", "app"}, {"pod-2", "app"}}
+	streams := []io.ReadCloser{
+		ioutil.NopCloser(strings.NewReader("2018-01-01T00:00:01Z a1\n\n2018-01-01T00:00:04Z a2\n")),
+		ioutil.NopCloser(strings.NewReader("2018-01-01T00:00:02Z b1\n2018-01-01T00:00:03Z b2")),
+	}
+
+	var out bytes.Buffer
+	if err := mergeLogStreams(&out, sources, streams); err != nil {
+		t.Fatalf("Unexpected error: %v", err)
+	}
+
+	expected := "2018-01-01T00:00:01Z pod-1/app a1\n" +
+		"2018-01-01T00:00:02Z pod-2/app b1\n" +
+		"2018-01-01T00:00:03Z pod-2/app b2\n" +
+		"2018-01-01T00:00:04Z pod-1/app a2\n"
+	if out.String() != expected {
+		t.Errorf("mergeLogStreams() wrote:\n%s\nexpected:\n%s", out.String(), expected)
+	}
+}
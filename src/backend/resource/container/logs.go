@@ -21,6 +21,7 @@ import (
 	"alauda.io/diablo/src/backend/api"
 	"alauda.io/diablo/src/backend/resource/logs"
 	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/kubernetes/scheme"
 )
@@ -74,6 +75,39 @@ func GetLogDetails(client kubernetes.Interface, namespace, podID string, contain
 	return details, nil
 }
 
+// SearchLogs returns lines of the container log that match the query. The log is loaded from the
+// beginning or the end of the file as described by logFilePosition, with the same read limits as
+// in GetLogDetails.
+func SearchLogs(client kubernetes.Interface, namespace, podID string, container string, query *logs.SearchQuery,
+	logFilePosition string, usePreviousLogs bool) (*logs.SearchResult, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(podID, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(container) == 0 {
+		container = pod.Spec.Containers[0].Name
+	}
+
+	logOptions := mapToLogOptions(container, &logs.Selection{LogFilePosition: logFilePosition}, usePreviousLogs)
+	if !query.Since.IsZero() {
+		sinceTime := metaV1.NewTime(query.Since)
+		logOptions.SinceTime = &sinceTime
+	}
+
+	rawLogs, err := readRawLogs(client, namespace, podID, logOptions)
+	if err != nil {
+		return nil, err
+	}
+
+	parsedLines := logs.ToLogLines(rawLogs)
+	result := parsedLines.Search(query)
+	result.Info.PodName = podID
+	result.Info.ContainerName = container
+	result.Info.Truncated = isReadLimitReached(int64(len(rawLogs)), int64(len(parsedLines)), logFilePosition)
+	return result, nil
+}
+
 // Maps the log selection to the corresponding api object
 // Read limits are set to avoid out of memory issues
 func mapToLogOptions(container string, logSelector *logs.Selection, previous bool) *v1.PodLogOptions {
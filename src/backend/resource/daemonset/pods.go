@@ -34,7 +34,7 @@ func GetDaemonSetPods(client k8sClient.Interface, metricClient metricapi.MetricC
 	dsQuery *dataselect.DataSelectQuery, daemonSetName, namespace string) (*pod.PodList, error) {
 	log.Printf("Getting replication controller %s pods in namespace %s", daemonSetName, namespace)
 
-	pods, err := getRawDaemonSetPods(client, daemonSetName, namespace)
+	pods, err := GetRawDaemonSetPods(client, daemonSetName, namespace)
 	if err != nil {
 		return pod.EmptyPodList, err
 	}
@@ -49,8 +49,8 @@ func GetDaemonSetPods(client k8sClient.Interface, metricClient metricapi.MetricC
 	return &podList, nil
 }
 
-// Returns array of corev1 pods targeting daemon set with given name.
-func getRawDaemonSetPods(client k8sClient.Interface, daemonSetName, namespace string) ([]corev1.Pod, error) {
+// GetRawDaemonSetPods returns array of corev1 pods targeting daemon set with given name.
+func GetRawDaemonSetPods(client k8sClient.Interface, daemonSetName, namespace string) ([]corev1.Pod, error) {
 	daemonSet, err := client.AppsV1beta2().DaemonSets(namespace).Get(daemonSetName, api.GetOptionsInCache)
 	if err != nil {
 		return nil, err
@@ -73,7 +73,7 @@ func getRawDaemonSetPods(client k8sClient.Interface, daemonSetName, namespace st
 func getDaemonSetPodInfo(client k8sClient.Interface, daemonSet *apps.DaemonSet) (
 	*common.PodInfo, error) {
 
-	pods, err := getRawDaemonSetPods(client, daemonSet.Name, daemonSet.Namespace)
+	pods, err := GetRawDaemonSetPods(client, daemonSet.Name, daemonSet.Namespace)
 	if err != nil {
 		return nil, err
 	}
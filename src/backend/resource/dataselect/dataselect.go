@@ -107,15 +107,7 @@ func (self *DataSelector) Filter() *DataSelector {
 	filteredList := []DataCell{}
 
 	for _, c := range self.GenericDataList {
-		matches := true
-		for _, filterBy := range self.DataSelectQuery.FilterQuery.FilterByList {
-			v := c.GetProperty(filterBy.Property)
-			if v == nil || !v.Contains(filterBy.Value) {
-				matches = false
-				break
-			}
-		}
-		if matches {
+		if self.DataSelectQuery.FilterQuery.Matches(c) {
 			filteredList = append(filteredList, c)
 		}
 	}
@@ -222,3 +222,27 @@ func TestPagination(t *testing.T) {
 	}
 
 }
+
+func TestFilterQueryMatches(t *testing.T) {
+	testCases := []struct {
+		Info        string
+		FilterQuery *FilterQuery
+		Cell        TestDataCell
+		Expected    bool
+	}{
+		{"no filter - every cell should match", NoFilter, TestDataCell{"ab", 1}, true},
+		{"property contains value - cell should match",
+			NewFilterQuery([]string{NameProperty, "b"}), TestDataCell{"ab", 1}, true},
+		{"property does not contain value - cell should not match",
+			NewFilterQuery([]string{NameProperty, "c"}), TestDataCell{"ab", 1}, false},
+		{"one of many filters fails - cell should not match",
+			NewFilterQuery([]string{NameProperty, "a", NameProperty, "c"}), TestDataCell{"ab", 1}, false},
+		{"unknown property - cell should not match",
+			NewFilterQuery([]string{StatusProperty, "a"}), TestDataCell{"ab", 1}, false},
+	}
+	for _, testCase := range testCases {
+		if matches := testCase.FilterQuery.Matches(testCase.Cell); matches != testCase.Expected {
+			t.Errorf(`Matches: %s. Got %v, expected %v.`, testCase.Info, matches, testCase.Expected)
+		}
+	}
+}
@@ -166,6 +166,18 @@ func NewFilterQuery(filterByListRaw []string) *FilterQuery {
 	}
 }
 
+// Matches returns true if given data cell satisfies all filters of the query. Cells that do not
+// have a filtered property never match.
+func (self *FilterQuery) Matches(cell DataCell) bool {
+	for _, filterBy := range self.FilterByList {
+		v := cell.GetProperty(filterBy.Property)
+		if v == nil || !v.Contains(filterBy.Value) {
+			return false
+		}
+	}
+	return true
+}
+
 // GeSimpleLabelQuery get query by 'key == value' in labels
 func GeSimpleLabelQuery(key, value string) *DataSelectQuery {
 	return NewDataSelectQuery(
@@ -27,6 +27,7 @@ import (
 	"alauda.io/diablo/src/backend/resource/ingress"
 	"alauda.io/diablo/src/backend/resource/network"
 	"alauda.io/diablo/src/backend/resource/service"
+	apps "k8s.io/api/apps/v1"
 	client "k8s.io/client-go/kubernetes"
 )
 
@@ -190,19 +191,7 @@ func toDeploymentList(rc *common.ResourceCollection, nonCriticalErrors []error,
 	deployments := fromCells(deploymentCells)
 	deploymentList.ListMeta = api.ListMeta{TotalItems: filteredTotal}
 	for _, deployment := range deployments {
-		_, visitAddresses := network.GetNetworkInfo(deployment.Spec.Template.Spec.Containers, rc.Ingresses, rc.Services, deployment.Namespace, deployment.Spec.Template.Labels)
-		matchingPods := common.FilterDeploymentPodsByOwnerReference(deployment, rc.ReplicaSets, rc.Pods)
-		podInfo := common.GetPodControllerInfo(deployment.Status.Replicas, deployment.Spec.Replicas, deployment.GetObjectMeta(), matchingPods, rc.Events)
-		deploymentList.Deployments = append(deploymentList.Deployments,
-			Deployment{
-				ObjectMeta:          api.NewObjectMeta(deployment.ObjectMeta),
-				TypeMeta:            api.NewTypeMeta(api.ResourceKindDeployment),
-				ContainerImages:     common.GetContainerImages(&deployment.Spec.Template.Spec),
-				InitContainerImages: common.GetInitContainerImages(&deployment.Spec.Template.Spec),
-				PodInfo:             podInfo,
-				VisitAddresses:      visitAddresses,
-				Status:              common.GetControllerStatus(&podInfo),
-			})
+		deploymentList.Deployments = append(deploymentList.Deployments, ToDeployment(deployment, rc))
 	}
 
 	cumulativeMetrics, err := metricPromises.GetMetrics()
@@ -214,6 +203,24 @@ func toDeploymentList(rc *common.ResourceCollection, nonCriticalErrors []error,
 	return deploymentList
 }
 
+// ToDeployment returns presentation view of a single deployment. Pods, replica sets, events,
+// services and ingresses from given resource collection are used to fill pod info and visit
+// addresses.
+func ToDeployment(deployment apps.Deployment, rc *common.ResourceCollection) Deployment {
+	_, visitAddresses := network.GetNetworkInfo(deployment.Spec.Template.Spec.Containers, rc.Ingresses, rc.Services, deployment.Namespace, deployment.Spec.Template.Labels)
+	matchingPods := common.FilterDeploymentPodsByOwnerReference(deployment, rc.ReplicaSets, rc.Pods)
+	podInfo := common.GetPodControllerInfo(deployment.Status.Replicas, deployment.Spec.Replicas, deployment.GetObjectMeta(), matchingPods, rc.Events)
+	return Deployment{
+		ObjectMeta:          api.NewObjectMeta(deployment.ObjectMeta),
+		TypeMeta:            api.NewTypeMeta(api.ResourceKindDeployment),
+		ContainerImages:     common.GetContainerImages(&deployment.Spec.Template.Spec),
+		InitContainerImages: common.GetInitContainerImages(&deployment.Spec.Template.Spec),
+		PodInfo:             podInfo,
+		VisitAddresses:      visitAddresses,
+		Status:              common.GetControllerStatus(&podInfo),
+	}
+}
+
 func GenerateFromCore(app appCore.Application, rc *common.ResourceCollection, metricClient metricapi.MetricClient) (*DeploymentList, error) {
 	deployments, err := GetFormCore(app)
 	if err != nil {
@@ -22,6 +22,7 @@ import (
 	"alauda.io/diablo/src/backend/resource/dataselect"
 	"alauda.io/diablo/src/backend/resource/event"
 	"alauda.io/diablo/src/backend/resource/pod"
+	"k8s.io/api/core/v1"
 	client "k8s.io/client-go/kubernetes"
 )
 
@@ -61,3 +62,28 @@ func GetDeploymentPods(client client.Interface, metricClient metricapi.MetricCli
 	podList := pod.ToPodList(pods, events, nonCriticalErrors, dsQuery, metricClient)
 	return &podList, nil
 }
+
+// GetRawDeploymentPods returns array of api pods targeting deployment with given name.
+func GetRawDeploymentPods(client client.Interface, namespace, deploymentName string) ([]v1.Pod, error) {
+	deployment, err := client.AppsV1().Deployments(namespace).Get(deploymentName, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+
+	channels := &common.ResourceChannels{
+		PodList:        common.GetPodListChannel(client, common.NewSameNamespaceQuery(namespace), 1),
+		ReplicaSetList: common.GetReplicaSetListChannel(client, common.NewSameNamespaceQuery(namespace), 1),
+	}
+
+	rawPods := <-channels.PodList.List
+	if err := <-channels.PodList.Error; err != nil {
+		return nil, err
+	}
+
+	rawRs := <-channels.ReplicaSetList.List
+	if err := <-channels.ReplicaSetList.Error; err != nil {
+		return nil, err
+	}
+
+	return common.FilterDeploymentPodsByOwnerReference(*deployment, rawRs.Items, rawPods.Items), nil
+}
@@ -99,6 +99,11 @@ func GetPodsEvents(client client.Interface, namespace string, pods []v1.Pod) (
 	return events, nil
 }
 
+// FilterPodEvents returns the events targeting the pod among events of its namespace.
+func FilterPodEvents(events []v1.Event, pod v1.Pod) []v1.Event {
+	return FillEventsType(filterEventsByPodsUID(events, []v1.Pod{pod}))
+}
+
 // GetPodEvents gets pods events associated to pod name and namespace
 func GetPodEvents(client client.Interface, namespace, podName string) ([]v1.Event, error) {
 
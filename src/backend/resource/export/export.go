@@ -0,0 +1,186 @@
+package export
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is the format of exported rows
+type Format string
+
+const (
+	// FormatCSV writes a header with column names followed by a line per row
+	FormatCSV Format = "csv"
+	// FormatNDJSON writes a JSON object per line, keys are column names
+	FormatNDJSON Format = "ndjson"
+)
+
+// ParseFormat parses the export format, empty value means csv
+func ParseFormat(value string) (Format, error) {
+	switch Format(value) {
+	case "", FormatCSV:
+		return FormatCSV, nil
+	case FormatNDJSON:
+		return FormatNDJSON, nil
+	}
+	return "", fmt.Errorf("unsupported format '%s', should be one of: csv, ndjson", value)
+}
+
+// ContentType returns the MIME type of the format
+func (f Format) ContentType() string {
+	if f == FormatNDJSON {
+		return "application/x-ndjson"
+	}
+	return "text/csv"
+}
+
+// Extension returns the file extension of the format
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// ContentDisposition returns the Content-Disposition header of an attachment with the file name. Characters
+// other than letters, digits, dots, dashes and underscores, e.g. commas of namespace lists, are replaced
+// with underscores, so the quoted name is always valid.
+func ContentDisposition(filename string) string {
+	sanitized := strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9', r == '.', r == '-', r == '_':
+			return r
+		}
+		return '_'
+	}, filename)
+	return fmt.Sprintf("attachment; filename=\"%s\"", sanitized)
+}
+
+// ParseColumns parses comma separated column names and checks that all of them are supported.
+// Empty value means the default columns.
+func ParseColumns(value string, supported []string, defaults []string) ([]string, error) {
+	if len(strings.TrimSpace(value)) == 0 {
+		return defaults, nil
+	}
+
+	columns := make([]string, 0)
+	for _, column := range strings.Split(value, ",") {
+		column = strings.TrimSpace(column)
+		if len(column) == 0 {
+			continue
+		}
+		if !contains(supported, column) {
+			return nil, fmt.Errorf("unsupported column '%s', should be one of: %s", column, strings.Join(supported, ", "))
+		}
+		columns = append(columns, column)
+	}
+	return columns, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// Writer writes rows with values of the columns in the given format
+type Writer interface {
+	// WriteRow writes a single row, values have to be in the order of the columns
+	WriteRow(values []interface{}) error
+	// Flush writes any buffered data to the underlying writer
+	Flush() error
+}
+
+// NewWriter creates a writer of the format. CSV header is written immediately.
+func NewWriter(w io.Writer, format Format, columns []string) (Writer, error) {
+	if format == FormatNDJSON {
+		return &ndjsonWriter{w: w, columns: columns}, nil
+	}
+
+	writer := &csvWriter{w: csv.NewWriter(w), columns: columns}
+	if err := writer.w.Write(columns); err != nil {
+		return nil, err
+	}
+	return writer, nil
+}
+
+type csvWriter struct {
+	w       *csv.Writer
+	columns []string
+}
+
+func (self *csvWriter) WriteRow(values []interface{}) error {
+	record := make([]string, len(values))
+	for i, value := range values {
+		record[i] = formatValue(value)
+	}
+	return self.w.Write(record)
+}
+
+func (self *csvWriter) Flush() error {
+	self.w.Flush()
+	return self.w.Error()
+}
+
+// formatValue formats a value as CSV field. Nil values are written as empty fields.
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case time.Time:
+		if v.IsZero() {
+			return ""
+		}
+		return v.Format(time.RFC3339)
+	}
+	return fmt.Sprint(value)
+}
+
+type ndjsonWriter struct {
+	w       io.Writer
+	columns []string
+}
+
+// WriteRow writes values as a JSON object keeping the order of the columns
+func (self *ndjsonWriter) WriteRow(values []interface{}) error {
+	var line bytes.Buffer
+	line.WriteByte('{')
+	for i, column := range self.columns {
+		if i > 0 {
+			line.WriteByte(',')
+		}
+		key, err := json.Marshal(column)
+		if err != nil {
+			return err
+		}
+
+		var value interface{}
+		if i < len(values) {
+			value = values[i]
+		}
+		if t, ok := value.(time.Time); ok && t.IsZero() {
+			value = nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+
+		line.Write(key)
+		line.WriteByte(':')
+		line.Write(encoded)
+	}
+	line.WriteString("}\n")
+
+	_, err := self.w.Write(line.Bytes())
+	return err
+}
+
+func (self *ndjsonWriter) Flush() error {
+	return nil
+}
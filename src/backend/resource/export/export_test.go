@@ -0,0 +1,90 @@
+package export
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseColumns(t *testing.T) {
+	supported := []string{"name", "phase", "duration"}
+	defaults := []string{"name", "phase"}
+
+	cases := []struct {
+		value    string
+		expected []string
+		err      bool
+	}{
+		{"", defaults, false},
+		{"duration, name", []string{"duration", "name"}, false},
+		{"name,,phase", []string{"name", "phase"}, false},
+		{"name,unknown", nil, true},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseColumns(c.value, supported, defaults)
+		if (err != nil) != c.err {
+			t.Errorf("ParseColumns(%q) returned error %v", c.value, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("ParseColumns(%q) == %v, expected %v", c.value, actual, c.expected)
+		}
+	}
+}
+
+func TestWriter(t *testing.T) {
+	columns := []string{"name", "startedAt", "duration"}
+	rows := [][]interface{}{
+		{"build-1", time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC), int64(1500)},
+		{"build, \"2\"", time.Time{}, nil},
+	}
+
+	cases := []struct {
+		format   Format
+		expected string
+	}{
+		{FormatCSV, "name,startedAt,duration\n" +
+			"build-1,2018-01-01T00:00:00Z,1500\n" +
+			"\"build, \"\"2\"\"\",,\n"},
+		{FormatNDJSON, `{"name":"build-1","startedAt":"2018-01-01T00:00:00Z","duration":1500}` + "\n" +
+			`{"name":"build, \"2\"","startedAt":null,"duration":null}` + "\n"},
+	}
+
+	for _, c := range cases {
+		var out bytes.Buffer
+		writer, err := NewWriter(&out, c.format, columns)
+		if err != nil {
+			t.Fatalf("NewWriter(%s) returned error %v", c.format, err)
+		}
+		for _, row := range rows {
+			if err := writer.WriteRow(row); err != nil {
+				t.Fatalf("WriteRow(%v) returned error %v", row, err)
+			}
+		}
+		if err := writer.Flush(); err != nil {
+			t.Fatalf("Flush() returned error %v", err)
+		}
+
+		if out.String() != c.expected {
+			t.Errorf("%s export wrote:\n%s\nexpected:\n%s", c.format, out.String(), c.expected)
+		}
+	}
+}
+
+func TestContentDisposition(t *testing.T) {
+	cases := []struct {
+		filename string
+		expected string
+	}{
+		{"pipelines-default.csv", `attachment; filename="pipelines-default.csv"`},
+		{"pipelines-dev,prod.csv", `attachment; filename="pipelines-dev_prod.csv"`},
+		{"a \"b\";c.yaml", `attachment; filename="a__b__c.yaml"`},
+	}
+
+	for _, c := range cases {
+		if actual := ContentDisposition(c.filename); actual != c.expected {
+			t.Errorf("ContentDisposition(%q) == %s, expected %s", c.filename, actual, c.expected)
+		}
+	}
+}
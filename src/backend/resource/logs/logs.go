@@ -143,6 +143,9 @@ type LogLines []LogLine
 type LogLine struct {
 	Timestamp LogTimestamp `json:"timestamp"`
 	Content   string       `json:"content"`
+	// Pod and container the line comes from. Set only for logs aggregated from many containers.
+	PodName       string `json:"podName,omitempty"`
+	ContainerName string `json:"containerName,omitempty"`
 }
 
 // LogTimestamp is a timestamp that appears on the beginning of each log line.
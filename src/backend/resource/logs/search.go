@@ -0,0 +1,191 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"errors"
+	"regexp"
+	"time"
+	"unicode/utf8"
+)
+
+// MaxSearchMatches is the maximum number of matched lines returned by a single search.
+var MaxSearchMatches = 1000
+
+// SearchQuery describes which log lines should be returned by a search.
+type SearchQuery struct {
+	pattern *regexp.Regexp
+	// Number of lines returned before and after every matched line.
+	Before int
+	After  int
+	// Only lines with timestamp in this range are searched. Zero time means no bound. Lines without
+	// timestamp are always searched.
+	Since time.Time
+	Until time.Time
+}
+
+// NewSearchQuery creates search query for given pattern. Pattern is matched as a substring unless
+// regex is true. Empty pattern would match every line, so it is rejected.
+func NewSearchQuery(pattern string, regex, caseSensitive bool, before, after int, since, until time.Time) (*SearchQuery, error) {
+	if len(pattern) == 0 {
+		return nil, errors.New("search pattern is required")
+	}
+	if !regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if !caseSensitive {
+		pattern = "(?i)" + pattern
+	}
+
+	compiled, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	if before < 0 {
+		before = 0
+	}
+	if after < 0 {
+		after = 0
+	}
+
+	return &SearchQuery{pattern: compiled, Before: before, After: after, Since: since, Until: until}, nil
+}
+
+// SearchResult contains matched log lines together with their context lines.
+type SearchResult struct {
+	// Additional information of the logs e.g. container name, dates,...
+	Info LogInfo `json:"info"`
+
+	// Matches in order of appearance in the log.
+	Matches []LogMatch `json:"matches"`
+
+	// Total number of matched lines, can be greater than number of returned matches.
+	TotalMatches int `json:"totalMatches"`
+
+	// Matched lines and their context lines in order of appearance in the log.
+	LogLines `json:"logs"`
+}
+
+// LogMatch describes a single matched line.
+type LogMatch struct {
+	// ID of the matched line, can be used as a reference point of log Selection to show the match in
+	// the log view.
+	LineId LogLineId `json:"lineId"`
+	// Index of the matched line in the searched log.
+	LineIndex int `json:"lineIndex"`
+	// Index of the matched line in returned LogLines.
+	Index int `json:"index"`
+	// Positions of all matches in the line content.
+	Offsets []MatchOffset `json:"offsets"`
+}
+
+// MatchOffset is a position of a match in line content counted in UTF-16 code units, the way JavaScript
+// indexes strings. End is not included.
+type MatchOffset struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
+}
+
+// Search returns lines that match the query together with their context lines.
+func (self LogLines) Search(query *SearchQuery) *SearchResult {
+	result := &SearchResult{
+		Matches:  make([]LogMatch, 0),
+		LogLines: LogLines{},
+	}
+
+	// index of the first line that was not added to the result yet
+	next := 0
+	for idx, line := range self {
+		if !query.inTimeRange(line.Timestamp) {
+			continue
+		}
+
+		positions := query.pattern.FindAllStringIndex(line.Content, -1)
+		if len(positions) == 0 {
+			continue
+		}
+
+		result.TotalMatches++
+		if len(result.Matches) >= MaxSearchMatches {
+			continue
+		}
+
+		from := idx - query.Before
+		if from < next {
+			from = next
+		}
+		to := idx + query.After + 1
+		if to > len(self) {
+			to = len(self)
+		}
+		if to > next {
+			result.LogLines = append(result.LogLines, self[from:to]...)
+			next = to
+		}
+
+		result.Matches = append(result.Matches, LogMatch{
+			LineId:    *self.createLogLineId(idx),
+			LineIndex: idx,
+			Index:     len(result.LogLines) - (next - idx),
+			Offsets:   toMatchOffsets(line.Content, positions),
+		})
+	}
+
+	if len(result.LogLines) > 0 {
+		result.Info.FromDate = result.LogLines[0].Timestamp
+		result.Info.ToDate = result.LogLines[len(result.LogLines)-1].Timestamp
+	}
+	return result
+}
+
+// toMatchOffsets converts byte positions of matches in the content to UTF-16 offsets. Positions are ordered
+// and do not overlap, so the content is walked only once.
+func toMatchOffsets(content string, positions [][]int) []MatchOffset {
+	offsets := make([]MatchOffset, 0, len(positions))
+	// byte position in the content and the matching UTF-16 offset
+	byteIdx, utf16Idx := 0, 0
+	advance := func(to int) int {
+		for byteIdx < to {
+			r, size := utf8.DecodeRuneInString(content[byteIdx:])
+			// runes outside of the basic multilingual plane take a surrogate pair
+			if r > 0xFFFF {
+				utf16Idx += 2
+			} else {
+				utf16Idx++
+			}
+			byteIdx += size
+		}
+		return utf16Idx
+	}
+
+	for _, position := range positions {
+		start := advance(position[0])
+		offsets = append(offsets, MatchOffset{Start: start, End: advance(position[1])})
+	}
+	return offsets
+}
+
+func (self *SearchQuery) inTimeRange(timestamp LogTimestamp) bool {
+	if self.Since.IsZero() && self.Until.IsZero() {
+		return true
+	}
+
+	t, err := time.Parse(time.RFC3339Nano, string(timestamp))
+	if err != nil {
+		return true
+	}
+	return (self.Since.IsZero() || !t.Before(self.Since)) && (self.Until.IsZero() || !t.After(self.Until))
+}
@@ -0,0 +1,113 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logs
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+var searchLines = LogLines{
+	{Timestamp: "2018-01-01T00:00:01Z", Content: "starting"},
+	{Timestamp: "2018-01-01T00:00:02Z", Content: "Error: first"},
+	{Timestamp: "2018-01-01T00:00:03Z", Content: "retrying"},
+	{Timestamp: "2018-01-01T00:00:04Z", Content: "error: second error"},
+	{Timestamp: "2018-01-01T00:00:05Z", Content: "done"},
+	{Timestamp: "2018-01-01T00:00:06Z", Content: "构建错误: 😀 错误"},
+}
+
+func contents(lines LogLines) []string {
+	result := make([]string, 0)
+	for _, line := range lines {
+		result = append(result, line.Content)
+	}
+	return result
+}
+
+func TestSearch(t *testing.T) {
+	cases := []struct {
+		info            string
+		pattern         string
+		regex           bool
+		caseSensitive   bool
+		before, after   int
+		since           time.Time
+		expectedLines   []string
+		expectedIndexes []int
+		expectedOffsets [][]MatchOffset
+	}{
+		{"Case insensitive substring", "error", false, false, 0, 0, time.Time{},
+			[]string{"Error: first", "error: second error"}, []int{0, 1},
+			[][]MatchOffset{{{0, 5}}, {{0, 5}, {14, 19}}}},
+		{"Case sensitive substring", "Error", false, true, 0, 0, time.Time{},
+			[]string{"Error: first"}, []int{0},
+			[][]MatchOffset{{{0, 5}}}},
+		{"Regex with overlapping context", "^[Ee]rror: (first|second)", true, true, 1, 1, time.Time{},
+			[]string{"starting", "Error: first", "retrying", "error: second error", "done"}, []int{1, 3},
+			[][]MatchOffset{{{0, 12}}, {{0, 13}}}},
+		{"Time range", "error", false, false, 0, 0, time.Date(2018, 1, 1, 0, 0, 3, 0, time.UTC),
+			[]string{"error: second error"}, []int{0},
+			[][]MatchOffset{{{0, 5}, {14, 19}}}},
+		{"Regex metacharacters are escaped in substring search", "(first", false, false, 0, 0, time.Time{},
+			[]string{}, []int{}, [][]MatchOffset{}},
+		{"Offsets count UTF-16 code units", "错误", false, false, 0, 0, time.Time{},
+			[]string{"构建错误: 😀 错误"}, []int{0},
+			[][]MatchOffset{{{2, 4}, {9, 11}}}},
+	}
+
+	for _, c := range cases {
+		query, err := NewSearchQuery(c.pattern, c.regex, c.caseSensitive, c.before, c.after, c.since, time.Time{})
+		if err != nil {
+			t.Fatalf("Test Case: %s. Unexpected error: %v", c.info, err)
+		}
+
+		result := searchLines.Search(query)
+		if lines := contents(result.LogLines); !reflect.DeepEqual(lines, c.expectedLines) {
+			t.Errorf("Test Case: %s. Expected lines %v, but got %v.", c.info, c.expectedLines, lines)
+		}
+
+		indexes := make([]int, 0)
+		offsets := make([][]MatchOffset, 0)
+		for _, match := range result.Matches {
+			indexes = append(indexes, match.Index)
+			offsets = append(offsets, match.Offsets)
+			if result.LogLines[match.Index].Content != searchLines[match.LineIndex].Content {
+				t.Errorf("Test Case: %s. Match index %d does not point to line %d.", c.info, match.Index, match.LineIndex)
+			}
+		}
+		if !reflect.DeepEqual(indexes, c.expectedIndexes) {
+			t.Errorf("Test Case: %s. Expected indexes %v, but got %v.", c.info, c.expectedIndexes, indexes)
+		}
+		if !reflect.DeepEqual(offsets, c.expectedOffsets) {
+			t.Errorf("Test Case: %s. Expected offsets %v, but got %v.", c.info, c.expectedOffsets, offsets)
+		}
+		if result.TotalMatches != len(c.expectedIndexes) {
+			t.Errorf("Test Case: %s. Expected %d matches, but got %d.", c.info, len(c.expectedIndexes), result.TotalMatches)
+		}
+	}
+}
+
+func TestNewSearchQueryInvalidRegex(t *testing.T) {
+	if _, err := NewSearchQuery("(first", true, false, 0, 0, time.Time{}, time.Time{}); err == nil {
+		t.Error("Expected error for invalid regex, but got nil.")
+	}
+}
+
+func TestNewSearchQueryEmptyPattern(t *testing.T) {
+	if _, err := NewSearchQuery("", false, false, 0, 0, time.Time{}, time.Time{}); err == nil {
+		t.Error("Expected error for empty pattern, but got nil.")
+	}
+}
@@ -0,0 +1,77 @@
+package pipeline
+
+import (
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"k8s.io/client-go/kubernetes"
+)
+
+// GetBatchTargets returns pipelines selected by the query and by selected which were created after since. Zero
+// since selects pipelines regardless of their age.
+func GetBatchTargets(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery,
+	since time.Time, selected func(Pipeline) bool) ([]common.BatchTarget, error) {
+	// batches act on every selected pipeline, not on a single page
+	query := *dsQuery
+	query.PaginationQuery = dataselect.NoPagination
+
+	pipelineList, err := GetPipelineList(client, namespace, &query)
+	if err != nil {
+		return nil, err
+	}
+
+	targets := make([]common.BatchTarget, 0)
+	for _, pipe := range pipelineList.Items {
+		if !namespace.Matches(pipe.ObjectMeta.Namespace) {
+			continue
+		}
+		if !since.IsZero() && pipe.ObjectMeta.CreationTimestamp.Time.Before(since) {
+			continue
+		}
+		if !selected(pipe) {
+			continue
+		}
+		targets = append(targets, common.BatchTarget{Namespace: pipe.ObjectMeta.Namespace, Name: pipe.ObjectMeta.Name})
+	}
+	return targets, nil
+}
+
+// isRunning selects pipelines which did not finish yet
+func isRunning(pipe Pipeline) bool {
+	return !pipe.Status.Phase.IsFinalPhase()
+}
+
+// isUnsuccessful selects finished pipelines which did not complete, e.g. failed or aborted ones
+func isUnsuccessful(pipe Pipeline) bool {
+	return pipe.Status.Phase.IsFinalPhase() && pipe.Status.Phase != devopsv1alpha1.PipelinePhaseComplete
+}
+
+// AbortPipelines aborts every running pipeline selected by the query
+func AbortPipelines(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace *common.NamespaceQuery,
+	dsQuery *dataselect.DataSelectQuery, since time.Time, options common.BatchOptions) (*common.BatchResult, error) {
+	targets, err := GetBatchTargets(client, namespace, dsQuery, since, isRunning)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.RunBatch(targets, options, func(target common.BatchTarget) (interface{}, error) {
+		return AbortPipeline(client, k8sclient, &AbortRequest{Namespace: target.Namespace, Name: target.Name})
+	}), nil
+}
+
+// RetryPipelines creates a new pipeline for every failed or aborted pipeline selected by the query. Running and
+// completed pipelines are not retried.
+func RetryPipelines(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace *common.NamespaceQuery,
+	dsQuery *dataselect.DataSelectQuery, since time.Time, options common.BatchOptions) (*common.BatchResult, error) {
+	targets, err := GetBatchTargets(client, namespace, dsQuery, since, isUnsuccessful)
+	if err != nil {
+		return nil, err
+	}
+
+	return common.RunBatch(targets, options, func(target common.BatchTarget) (interface{}, error) {
+		return RetryPipeline(client, k8sclient, &RetryRequest{Namespace: target.Namespace, Name: target.Name})
+	}), nil
+}
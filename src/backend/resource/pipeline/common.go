@@ -49,6 +49,10 @@ func (self PipelineCell) GetProperty(name dataselect.PropertyName) dataselect.Co
 		if comparableVal, ok := dataselect.MapStdComparable(self.ObjectMeta.Annotations, common.AnnotationsKeyMultiBranchName); ok {
 			return comparableVal
 		}
+	case dataselect.PipelineConfigProperty:
+		return dataselect.StdComparableString(self.Spec.PipelineConfig.Name)
+	case dataselect.StatusProperty:
+		return dataselect.StdComparableString(self.Status.Phase)
 	case dataselect.PipelineStatusProperty:
 		if self.Status.Jenkins != nil {
 			return dataselect.StdComparableString(self.Status.Jenkins.Status)
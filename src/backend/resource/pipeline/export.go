@@ -0,0 +1,134 @@
+package pipeline
+
+import (
+	"time"
+
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/export"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	ExportColumnName       = "name"
+	ExportColumnConfig     = "config"
+	ExportColumnBranch     = "branch"
+	ExportColumnPhase      = "phase"
+	ExportColumnStartedAt  = "startedAt"
+	ExportColumnFinishedAt = "finishedAt"
+	// ExportColumnDuration is the duration of the run in milliseconds
+	ExportColumnDuration = "duration"
+	ExportColumnCause    = "cause"
+
+	// ExportChunkSize is the number of pipelines listed from the apiserver at once by an export
+	ExportChunkSize = 500
+)
+
+// ExportColumns contains all columns which pipelines can be exported with, it is also the default
+var ExportColumns = []string{
+	ExportColumnName,
+	ExportColumnConfig,
+	ExportColumnBranch,
+	ExportColumnPhase,
+	ExportColumnStartedAt,
+	ExportColumnFinishedAt,
+	ExportColumnDuration,
+	ExportColumnCause,
+}
+
+// ExportPipelineList writes the pipelines selected by dsQuery, pagination is ignored. Pipelines are listed
+// from the apiserver in chunks of ExportChunkSize and written as they come, so that the export does not hold
+// all of them in memory. Sorting needs all pipelines, sorted exports are listed at once.
+// The writer is opened by open once the first chunk was listed, errors returned before that can still be
+// responded to.
+func ExportPipelineList(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery,
+	columns []string, open func() (export.Writer, error)) error {
+	query := *dsQuery
+	query.PaginationQuery = dataselect.NoPagination
+
+	if query.SortQuery != nil && len(query.SortQuery.SortByList) > 0 {
+		list, err := GetPipelineList(client, namespace, &query)
+		if err != nil {
+			return err
+		}
+		writer, err := open()
+		if err != nil {
+			return err
+		}
+		return ExportPipelines(writer, list.Items, columns)
+	}
+
+	var writer export.Writer
+	listOptions := metaV1.ListOptions{
+		LabelSelector: getLabelSelectorByDsQuery(&query).String(),
+		Limit:         ExportChunkSize,
+	}
+	for {
+		chunk, err := client.DevopsV1alpha1().Pipelines(namespace.ToRequestParam()).List(listOptions)
+		if err != nil {
+			return err
+		}
+		if writer == nil {
+			if writer, err = open(); err != nil {
+				return err
+			}
+		}
+		if err := writePipelines(writer, toPipelineList(chunk.Items, nil, &query).Items, columns); err != nil {
+			return err
+		}
+		if chunk.Continue == "" {
+			return writer.Flush()
+		}
+		listOptions.Continue = chunk.Continue
+	}
+}
+
+// ExportPipelines writes a row with the values of the columns for every pipeline
+func ExportPipelines(writer export.Writer, pipelines []Pipeline, columns []string) error {
+	if err := writePipelines(writer, pipelines, columns); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func writePipelines(writer export.Writer, pipelines []Pipeline, columns []string) error {
+	for _, pipe := range pipelines {
+		values := make([]interface{}, len(columns))
+		for i, column := range columns {
+			values[i] = getExportValue(pipe, column)
+		}
+		if err := writer.WriteRow(values); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func getExportValue(pipe Pipeline, column string) interface{} {
+	switch column {
+	case ExportColumnName:
+		return pipe.ObjectMeta.Name
+	case ExportColumnConfig:
+		return pipe.Spec.PipelineConfig.Name
+	case ExportColumnBranch:
+		return pipe.ObjectMeta.Annotations[common.AnnotationsKeyMultiBranchName]
+	case ExportColumnPhase:
+		return string(pipe.Status.Phase)
+	case ExportColumnStartedAt:
+		if pipe.Status.StartedAt != nil {
+			return pipe.Status.StartedAt.Time
+		}
+	case ExportColumnFinishedAt:
+		if pipe.Status.FinishedAt != nil {
+			return pipe.Status.FinishedAt.Time
+		}
+	case ExportColumnDuration:
+		if pipe.Status.StartedAt != nil && pipe.Status.FinishedAt != nil {
+			return int64(pipe.Status.FinishedAt.Time.Sub(pipe.Status.StartedAt.Time) / time.Millisecond)
+		}
+	case ExportColumnCause:
+		return string(pipe.Spec.Cause.Type)
+	}
+	return nil
+}
@@ -2,13 +2,15 @@ package pipeline
 
 import (
 	"fmt"
+	"log"
 
 	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
 	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
 	"k8s.io/client-go/kubernetes"
 )
 
-// InputHandle handle the input request
+// InputHandle handle the input request. The decision is recorded as an event of the pipeline with k8sclient,
+// which should be able to create events regardless of the user.
 func InputHandle(client devopsclient.Interface, k8sclient kubernetes.Interface, opts *InputOptions) (
 	resp *InputResponse, err error) {
 	resp = &InputResponse{
@@ -36,6 +38,11 @@ func InputHandle(client devopsclient.Interface, k8sclient kubernetes.Interface,
 		return
 	}
 
+	if err := recordInputEvent(client, k8sclient, opts); err != nil {
+		log.Printf("Failed to record input event of pipeline %s/%s: %v", opts.Namespace, opts.Name, err)
+		resp.EventError = err.Error()
+	}
+
 	resp.Message = "success"
 	resp.Code = response.StatusCode
 	return
@@ -54,6 +61,8 @@ type InputOptions struct {
 	// Parameters is the parameters of the pipeline input request
 	// +optional
 	Parameters []Parameter `json:"parameters"`
+	// User is the user who submits the input, it is recorded in the event of the pipeline
+	User string `json:"-"`
 }
 
 // InputResponse represents for input action response
@@ -61,6 +70,8 @@ type InputResponse struct {
 	Success bool   `json:"success"`
 	Message string `json:"message"`
 	Code    int    `json:"code"`
+	// EventError is set when the decision was submitted but its event could not be recorded
+	EventError string `json:"eventError,omitempty"`
 }
 
 // Parameter represents for parameters in input requests
@@ -0,0 +1,360 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/common"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	taskStatePaused = "PAUSED"
+
+	// EventReasonInputApproved is the reason of the event recorded when an input is approved
+	EventReasonInputApproved = "InputApproved"
+	// EventReasonInputRejected is the reason of the event recorded when an input is rejected
+	EventReasonInputRejected = "InputRejected"
+)
+
+// PendingInput is an input step of a running pipeline which is waiting for a decision
+type PendingInput struct {
+	Namespace      string `json:"namespace"`
+	Name           string `json:"name"`
+	PipelineConfig string `json:"pipelineConfig"`
+	Stage          int64  `json:"stage"`
+	StageName      string `json:"stageName"`
+	Step           int64  `json:"step"`
+	InputID        string `json:"inputID"`
+	Message        string `json:"message"`
+	OK             string `json:"ok"`
+	// Parameters are the parameters requested by the input as reported by Jenkins
+	Parameters json.RawMessage `json:"parameters,omitempty"`
+	// Submitters are the users or groups allowed to submit the input, empty when anyone who can
+	// submit inputs of the pipeline is allowed
+	Submitters []string `json:"submitters"`
+	// Allowed is true when the current user is allowed to submit the input
+	Allowed     bool   `json:"allowed"`
+	WaitingFrom string `json:"waitingFrom"`
+}
+
+// PendingInputList contains inputs waiting for a decision
+type PendingInputList struct {
+	ListMeta api.ListMeta   `json:"listMeta"`
+	Items    []PendingInput `json:"items"`
+	// List of non-critical errors, that occurred during resource retrieval.
+	Errors []error `json:"errors"`
+}
+
+// InputDecision is a decision on a pending input of a bulk request
+type InputDecision struct {
+	Namespace  string      `json:"namespace"`
+	Name       string      `json:"name"`
+	Stage      int64       `json:"stage"`
+	Step       int64       `json:"step"`
+	InputID    string      `json:"inputID"`
+	Parameters []Parameter `json:"parameters"`
+}
+
+// BulkInputOptions approves or rejects several pending inputs at once
+type BulkInputOptions struct {
+	Approve bool            `json:"approve"`
+	Inputs  []InputDecision `json:"inputs"`
+}
+
+// CanBeSubmittedBy returns true when the user or one of the groups is in submitters of the input
+func (p PendingInput) CanBeSubmittedBy(user string, groups []string) bool {
+	if len(p.Submitters) == 0 {
+		return true
+	}
+	for _, submitter := range p.Submitters {
+		if submitter == user || common.IsInSlice(groups, submitter) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPendingInputs returns inputs waiting for a decision in running pipelines of the namespaces. Tasks of the
+// pipelines are read by a bounded number of workers. Namespaces which cannot be read by the user and pipelines
+// whose tasks cannot be read are reported as non-critical errors.
+func GetPendingInputs(client devopsclient.Interface, namespaces []string) (*PendingInputList, error) {
+	var (
+		lock      sync.Mutex
+		wg        sync.WaitGroup
+		pipelines = make([]devopsv1alpha1.Pipeline, 0)
+		result    = &PendingInputList{Items: make([]PendingInput, 0), Errors: make([]error, 0)}
+		critical  error
+	)
+	for _, namespace := range namespaces {
+		wg.Add(1)
+		go func(namespace string) {
+			defer wg.Done()
+			list, err := client.DevopsV1alpha1().Pipelines(namespace).List(api.ListEverything)
+
+			lock.Lock()
+			defer lock.Unlock()
+			nonCriticalErrors, criticalError := errors.HandleError(err)
+			result.Errors = errors.MergeErrors(result.Errors, nonCriticalErrors)
+			if criticalError != nil {
+				critical = criticalError
+				return
+			}
+			if list == nil {
+				return
+			}
+			for _, pipe := range list.Items {
+				if !pipe.Status.Phase.IsFinalPhase() {
+					pipelines = append(pipelines, pipe)
+				}
+			}
+		}(namespace)
+	}
+	wg.Wait()
+	if critical != nil {
+		return nil, critical
+	}
+
+	targets := make([]common.BatchTarget, len(pipelines))
+	indexes := make(map[common.BatchTarget]int, len(pipelines))
+	for i, pipe := range pipelines {
+		targets[i] = common.BatchTarget{Namespace: pipe.Namespace, Name: pipe.Name}
+		indexes[targets[i]] = i
+	}
+	// errors are kept as they are, the batch result only has their messages
+	failures := make([]error, len(pipelines))
+	batch := common.RunBatch(targets, common.BatchOptions{}, func(target common.BatchTarget) (interface{}, error) {
+		i := indexes[target]
+		inputs, err := getPipelinePendingInputs(client, &pipelines[i])
+		failures[i] = err
+		return inputs, err
+	})
+	for i, item := range batch.Items {
+		if failures[i] != nil {
+			log.Printf("Failed to get pending inputs of pipeline %s/%s: %v", item.Namespace, item.Name, failures[i])
+			result.Errors = append(result.Errors, failures[i])
+			continue
+		}
+		if inputs, ok := item.Result.([]PendingInput); ok {
+			result.Items = append(result.Items, inputs...)
+		}
+	}
+
+	// the longest waiting inputs first
+	sort.SliceStable(result.Items, func(i, j int) bool {
+		left, right := result.Items[i], result.Items[j]
+		if left.WaitingFrom != right.WaitingFrom {
+			return left.WaitingFrom < right.WaitingFrom
+		}
+		return left.Namespace+"/"+left.Name < right.Namespace+"/"+right.Name
+	})
+	result.ListMeta = api.ListMeta{TotalItems: len(result.Items)}
+	return result, nil
+}
+
+// getPipelinePendingInputs returns pending inputs of paused stages and parallel branches of the pipeline
+func getPipelinePendingInputs(client devopsclient.Interface, pipe *devopsv1alpha1.Pipeline) ([]PendingInput, error) {
+	stages, err := getJenkinsTasks(client, pipe.Namespace, pipe.Name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	inputs := make([]PendingInput, 0)
+	for _, stage := range stages {
+		if stage.State != taskStatePaused || hasParallelBranches(stage) {
+			continue
+		}
+		stageID, err := strconv.ParseInt(stage.ID, 10, 64)
+		if err != nil {
+			continue
+		}
+
+		steps, err := getJenkinsTasks(client, pipe.Namespace, pipe.Name, int(stageID))
+		if err != nil {
+			return nil, err
+		}
+		for _, step := range steps {
+			if step.State != taskStatePaused || step.Input == nil {
+				continue
+			}
+			stepID, err := strconv.ParseInt(step.ID, 10, 64)
+			if err != nil {
+				continue
+			}
+
+			inputs = append(inputs, PendingInput{
+				Namespace:      pipe.Namespace,
+				Name:           pipe.Name,
+				PipelineConfig: pipe.Spec.PipelineConfig.Name,
+				Stage:          stageID,
+				StageName:      stage.DisplayName,
+				Step:           stepID,
+				InputID:        step.Input.ID,
+				Message:        step.Input.Message,
+				OK:             step.Input.OK,
+				Parameters:     step.Input.Parameters,
+				Submitters:     splitSubmitters(step.Input.Submitter),
+				Allowed:        true,
+				WaitingFrom:    step.StartTime,
+			})
+		}
+	}
+	return inputs, nil
+}
+
+// hasParallelBranches returns true for a stage which only waits for its parallel branches
+func hasParallelBranches(task jenkinsTask) bool {
+	for _, edge := range task.Edges {
+		if edge.Type == TimingNodeTypeParallel {
+			return true
+		}
+	}
+	return false
+}
+
+// splitSubmitters splits the comma separated submitters of a Jenkins input
+func splitSubmitters(submitter string) []string {
+	submitters := make([]string, 0)
+	for _, s := range strings.Split(submitter, ",") {
+		if s = strings.TrimSpace(s); s != "" {
+			submitters = append(submitters, s)
+		}
+	}
+	return submitters
+}
+
+// InputSubmitter is the user who decides on pending inputs
+type InputSubmitter struct {
+	User   string
+	Groups []string
+	// CanSubmit returns true when the user may submit inputs of pipelines in the namespace
+	CanSubmit func(namespace string) bool
+}
+
+// HandleInputs approves or rejects every input of the options. Every input is checked to be pending and
+// allowed for the submitter the same way as the list of pending inputs does. A failure of an input does not
+// stop the others. Decisions are recorded as events with k8sclient, see InputHandle.
+func HandleInputs(client devopsclient.Interface, k8sclient kubernetes.Interface, opts *BulkInputOptions,
+	submitter InputSubmitter) *common.BatchResult {
+	result := &common.BatchResult{
+		Total: len(opts.Inputs),
+		Items: make([]common.BatchItemResult, 0, len(opts.Inputs)),
+	}
+	// pending inputs of every pipeline, read once for all its decisions
+	pending := make(map[common.BatchTarget][]PendingInput, 0)
+	for _, input := range opts.Inputs {
+		target := common.BatchTarget{Namespace: input.Namespace, Name: input.Name}
+		item := common.BatchItemResult{BatchTarget: target}
+		var resp *InputResponse
+		err := checkInputAllowed(client, pending, input, submitter)
+		if err == nil {
+			resp, err = InputHandle(client, k8sclient, &InputOptions{
+				Namespace:  input.Namespace,
+				Name:       input.Name,
+				Stage:      input.Stage,
+				Step:       input.Step,
+				Approve:    opts.Approve,
+				InputID:    input.InputID,
+				Parameters: input.Parameters,
+				User:       submitter.User,
+			})
+		}
+		if err != nil {
+			item.Error = err.Error()
+			result.Failed++
+		} else {
+			item.Succeeded = true
+			item.Result = resp
+			result.Succeeded++
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result
+}
+
+// checkInputAllowed returns an error when the input of the decision is not pending or when the submitter is
+// not allowed to submit it
+func checkInputAllowed(client devopsclient.Interface, pending map[common.BatchTarget][]PendingInput,
+	decision InputDecision, submitter InputSubmitter) error {
+	target := common.BatchTarget{Namespace: decision.Namespace, Name: decision.Name}
+	inputs, ok := pending[target]
+	if !ok {
+		pipe, err := client.DevopsV1alpha1().Pipelines(decision.Namespace).Get(decision.Name, api.GetOptionsInCache)
+		if err != nil {
+			return err
+		}
+		if inputs, err = getPipelinePendingInputs(client, pipe); err != nil {
+			return err
+		}
+		pending[target] = inputs
+	}
+
+	resource := schema.GroupResource{Group: devopsv1alpha1.SchemeGroupVersion.Group, Resource: "pipelines/input"}
+	for _, input := range inputs {
+		if input.Stage != decision.Stage || input.Step != decision.Step || input.InputID != decision.InputID {
+			continue
+		}
+		if !submitter.CanSubmit(decision.Namespace) || !input.CanBeSubmittedBy(submitter.User, submitter.Groups) {
+			return k8serrors.NewForbidden(resource, decision.Name,
+				fmt.Errorf("user %q is not allowed to submit input %s", submitter.User, decision.InputID))
+		}
+		return nil
+	}
+	return k8serrors.NewNotFound(resource, fmt.Sprintf("%s/%s", decision.Name, decision.InputID))
+}
+
+// recordInputEvent records the decision on an input as an event of the pipeline
+func recordInputEvent(client devopsclient.Interface, k8sclient kubernetes.Interface, opts *InputOptions) error {
+	pipe, err := client.DevopsV1alpha1().Pipelines(opts.Namespace).Get(opts.Name, api.GetOptionsInCache)
+	if err != nil {
+		return err
+	}
+
+	reason, decision := EventReasonInputRejected, "rejected"
+	if opts.Approve {
+		reason, decision = EventReasonInputApproved, "approved"
+	}
+	user := opts.User
+	if user == "" {
+		user = "unknown user"
+	}
+
+	now := metaV1.NewTime(time.Now())
+	event := &v1.Event{
+		ObjectMeta: metaV1.ObjectMeta{
+			GenerateName: pipe.Name + ".",
+			Namespace:    pipe.Namespace,
+		},
+		InvolvedObject: v1.ObjectReference{
+			APIVersion:      devopsv1alpha1.SchemeGroupVersion.String(),
+			Kind:            "Pipeline",
+			Namespace:       pipe.Namespace,
+			Name:            pipe.Name,
+			UID:             pipe.UID,
+			ResourceVersion: pipe.ResourceVersion,
+		},
+		Reason:         reason,
+		Message:        fmt.Sprintf("%s %s input %s of stage %d step %d", user, decision, opts.InputID, opts.Stage, opts.Step),
+		Source:         v1.EventSource{Component: "diablo"},
+		FirstTimestamp: now,
+		LastTimestamp:  now,
+		Count:          1,
+		Type:           v1.EventTypeNormal,
+	}
+	_, err = k8sclient.CoreV1().Events(pipe.Namespace).Create(event)
+	return err
+}
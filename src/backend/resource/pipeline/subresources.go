@@ -2,11 +2,22 @@ package pipeline
 
 import (
 	"log"
+	"strings"
+	"time"
 
 	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
 	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/logs"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+// LogFollowInterval is how often new logs are fetched while following a running pipeline.
+const LogFollowInterval = 2 * time.Second
+
+// MaxSearchLogSize is the maximum number of bytes of a pipeline log read by a search. Logs beyond it are not
+// searched and the result is marked as truncated.
+const MaxSearchLogSize = 32 * 1024 * 1024
+
 // LogDetails log details
 type LogDetails struct {
 	*devopsv1alpha1.PipelineLog
@@ -33,6 +44,91 @@ func GetLogDetails(client devopsclient.Interface, namespace, name string, start,
 	}, nil
 }
 
+// SearchLogs reads the pipeline log up to MaxSearchLogSize and returns lines that match the query. Pipeline
+// log lines have no timestamps, so they are searched regardless of the time range of the query.
+func SearchLogs(client devopsclient.Interface, namespace, name string, stage, step int, query *logs.SearchQuery) (*logs.SearchResult, error) {
+	logOpts := &devopsv1alpha1.PipelineLogOptions{
+		Stage: int64(stage),
+		Step:  int64(step),
+	}
+
+	text := strings.Builder{}
+	truncated := false
+	for {
+		pipelineLog, err := client.DevopsV1alpha1().Pipelines(namespace).GetLogs(name, logOpts)
+		if err != nil {
+			log.Println("Error fetching logs: ", err)
+			return nil, err
+		}
+		if text.Len()+len(pipelineLog.Text) > MaxSearchLogSize {
+			text.WriteString(pipelineLog.Text[:MaxSearchLogSize-text.Len()])
+			truncated = true
+			break
+		}
+		text.WriteString(pipelineLog.Text)
+
+		if !pipelineLog.HasMore || pipelineLog.NextStart == nil || *pipelineLog.NextStart <= logOpts.Start {
+			break
+		}
+		logOpts.Start = *pipelineLog.NextStart
+	}
+
+	lines := logs.LogLines{}
+	for _, line := range strings.Split(strings.TrimSuffix(text.String(), "\n"), "\n") {
+		lines = append(lines, logs.LogLine{Content: line})
+	}
+	result := lines.Search(query)
+	result.Info.Truncated = truncated
+	return result, nil
+}
+
+// FollowLogs fetches logs of the pipeline from given offset and passes every new chunk to the
+// callback. It returns when the pipeline reached a final phase and all logs were read, when the
+// callback fails or when stopCh is closed.
+func FollowLogs(client devopsclient.Interface, namespace, name string, start, stage, step int,
+	stopCh <-chan struct{}, callback func(*LogDetails) error) error {
+	logOpts := &devopsv1alpha1.PipelineLogOptions{
+		Start: int64(start),
+		Stage: int64(stage),
+		Step:  int64(step),
+	}
+
+	for {
+		pipe, err := client.DevopsV1alpha1().Pipelines(namespace).Get(name, metaV1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		finished := pipe.Status.Phase.IsFinalPhase()
+
+		pipelineLog, err := client.DevopsV1alpha1().Pipelines(namespace).GetLogs(name, logOpts)
+		switch {
+		case err != nil && finished:
+			return err
+		case err != nil:
+			// Logs are not available until the build is started in Jenkins.
+			log.Println("Error fetching logs of running pipeline, will retry: ", err)
+		default:
+			if len(pipelineLog.Text) > 0 {
+				if err := callback(&LogDetails{PipelineLog: pipelineLog}); err != nil {
+					return err
+				}
+			}
+			if pipelineLog.NextStart != nil {
+				logOpts.Start = *pipelineLog.NextStart
+			}
+			if finished && !pipelineLog.HasMore {
+				return nil
+			}
+		}
+
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(LogFollowInterval):
+		}
+	}
+}
+
 // TaskDetails jenkins step details
 type TaskDetails struct {
 	*devopsv1alpha1.PipelineTask
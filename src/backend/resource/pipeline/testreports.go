@@ -65,6 +65,30 @@ func fetchReport(client devopsclient.Interface, k8sclient kubernetes.Interface,
 	return client.DevopsV1alpha1().Pipelines(opt.Namespace).GetTestReports(opt.Name, pipelineTestReportOption)
 }
 
+// TestReportPageSize is the number of test report items fetched at once by GetTestReportItems
+const TestReportPageSize = 500
+
+// GetTestReportItems returns all test report items of the pipeline, the report is fetched page by page
+func GetTestReportItems(client devopsclient.Interface, namespace, name string) ([]devopsv1alpha1.PipelineTestReportItem, error) {
+	items := make([]devopsv1alpha1.PipelineTestReportItem, 0)
+	opt := &PipelineTestReportsOptions{
+		Namespace: namespace,
+		Name:      name,
+		Limit:     TestReportPageSize,
+	}
+	for {
+		report, err := fetchReport(client, nil, opt, "")
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, report.Items...)
+		if len(report.Items) < opt.Limit {
+			return items, nil
+		}
+		opt.Start += len(report.Items)
+	}
+}
+
 type PipelineTestReportsOptions struct {
 	Namespace string
 	Name      string
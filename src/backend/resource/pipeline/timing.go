@@ -0,0 +1,332 @@
+package pipeline
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const (
+	// TimingNodeTypeStage is a stage of the pipeline
+	TimingNodeTypeStage = "STAGE"
+	// TimingNodeTypeParallel is a parallel branch of a stage
+	TimingNodeTypeParallel = "PARALLEL"
+	// TimingNodeTypeStep is a step of a stage or a parallel branch
+	TimingNodeTypeStep = "STEP"
+)
+
+// PipelineTiming is the stage and step tree of a pipeline run with timing of every node
+type PipelineTiming struct {
+	Name           string     `json:"name"`
+	PipelineConfig string     `json:"pipelineConfig"`
+	Phase          string     `json:"phase"`
+	StartedAt      *time.Time `json:"startedAt"`
+	FinishedAt     *time.Time `json:"finishedAt"`
+	DurationMillis int64      `json:"durationMillis"`
+	// Stages in order of execution, parallel branches are children of their stage
+	Stages []*TimingNode `json:"stages"`
+}
+
+// TimingNode is a stage, parallel branch or step of a pipeline run
+type TimingNode struct {
+	ID             string `json:"id"`
+	Name           string `json:"name"`
+	Type           string `json:"type"`
+	Result         string `json:"result"`
+	State          string `json:"state"`
+	StartTime      string `json:"startTime"`
+	DurationMillis int64  `json:"durationMillis"`
+	// Children are parallel branches of a stage or steps of a stage or branch
+	Children []*TimingNode `json:"children"`
+}
+
+// jenkinsTasks is the JSON representation of PipelineTask
+type jenkinsTasks struct {
+	Tasks []jenkinsTask `json:"tasks"`
+}
+
+type jenkinsTask struct {
+	ID               string `json:"id"`
+	Type             string `json:"type"`
+	DisplayName      string `json:"displayName"`
+	DisplayDesc      string `json:"displayDescription"`
+	Result           string `json:"result"`
+	State            string `json:"state"`
+	StartTime        string `json:"startTime"`
+	DurationInMillis int64  `json:"durationInMillis"`
+	Edges            []struct {
+		ID   string `json:"id"`
+		Type string `json:"type"`
+	} `json:"edges"`
+	// Input is the pending input of a paused step
+	Input *jenkinsInput `json:"input"`
+}
+
+type jenkinsInput struct {
+	ID         string          `json:"id"`
+	Message    string          `json:"message"`
+	OK         string          `json:"ok"`
+	Parameters json.RawMessage `json:"parameters"`
+	Submitter  string          `json:"submitter"`
+}
+
+// GetPipelineTiming returns the stage and step tree of the pipeline with start time, duration and status
+// of every node. Steps of all stages are fetched concurrently.
+func GetPipelineTiming(client devopsclient.Interface, namespace, name string) (*PipelineTiming, error) {
+	pipe, err := client.DevopsV1alpha1().Pipelines(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+
+	stages, err := getJenkinsTasks(client, namespace, name, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	timing := newPipelineTiming(pipe)
+	timing.Stages = toTimingTree(stages)
+
+	nodes := make([]*TimingNode, 0)
+	for _, stage := range timing.Stages {
+		nodes = append(nodes, stage)
+		nodes = append(nodes, stage.Children...)
+	}
+
+	errs := make([]error, len(nodes))
+	var wg sync.WaitGroup
+	for i, node := range nodes {
+		id, err := strconv.Atoi(node.ID)
+		if err != nil {
+			continue
+		}
+
+		wg.Add(1)
+		go func(i, id int, node *TimingNode) {
+			defer wg.Done()
+			steps, err := getJenkinsTasks(client, namespace, name, id)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			for _, step := range steps {
+				node.Children = append(node.Children, toTimingNode(step, TimingNodeTypeStep))
+			}
+		}(i, id, node)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+	return timing, nil
+}
+
+func newPipelineTiming(pipe *devopsv1alpha1.Pipeline) *PipelineTiming {
+	timing := &PipelineTiming{
+		Name:           pipe.GetName(),
+		PipelineConfig: pipe.Spec.PipelineConfig.Name,
+		Phase:          string(pipe.Status.Phase),
+		Stages:         make([]*TimingNode, 0),
+	}
+	if pipe.Status.StartedAt != nil {
+		timing.StartedAt = &pipe.Status.StartedAt.Time
+	}
+	if pipe.Status.FinishedAt != nil {
+		timing.FinishedAt = &pipe.Status.FinishedAt.Time
+	}
+	if timing.StartedAt != nil && timing.FinishedAt != nil {
+		timing.DurationMillis = int64(timing.FinishedAt.Sub(*timing.StartedAt) / time.Millisecond)
+	}
+	return timing
+}
+
+// getJenkinsTasks returns stages of the pipeline when stage is 0, otherwise steps of the stage
+func getJenkinsTasks(client devopsclient.Interface, namespace, name string, stage int) ([]jenkinsTask, error) {
+	details, err := GetTaskDetails(client, namespace, name, stage)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(details)
+	if err != nil {
+		return nil, err
+	}
+	tasks := jenkinsTasks{}
+	if err := json.Unmarshal(raw, &tasks); err != nil {
+		log.Printf("Failed to parse tasks of pipeline %s/%s: %v", namespace, name, err)
+		return nil, err
+	}
+	return tasks.Tasks, nil
+}
+
+// toTimingTree builds stages in order of execution. Parallel branches become children of the
+// stage which has an edge to them.
+func toTimingTree(tasks []jenkinsTask) []*TimingNode {
+	parents := make(map[string]string, 0)
+	for _, task := range tasks {
+		for _, edge := range task.Edges {
+			parents[edge.ID] = task.ID
+		}
+	}
+
+	nodes := make(map[string]*TimingNode, len(tasks))
+	for _, task := range tasks {
+		nodeType := TimingNodeTypeStage
+		if task.Type == TimingNodeTypeParallel {
+			nodeType = TimingNodeTypeParallel
+		}
+		nodes[task.ID] = toTimingNode(task, nodeType)
+	}
+
+	stages := make([]*TimingNode, 0)
+	for _, task := range tasks {
+		node := nodes[task.ID]
+		if node.Type == TimingNodeTypeParallel {
+			if parent, ok := nodes[parents[task.ID]]; ok && parent.Type == TimingNodeTypeStage {
+				parent.Children = append(parent.Children, node)
+				continue
+			}
+		}
+		stages = append(stages, node)
+	}
+	return stages
+}
+
+func toTimingNode(task jenkinsTask, nodeType string) *TimingNode {
+	name := task.DisplayName
+	if nodeType == TimingNodeTypeStep && len(task.DisplayDesc) > 0 {
+		name = fmt.Sprintf("%s: %s", task.DisplayName, task.DisplayDesc)
+	}
+	return &TimingNode{
+		ID:             task.ID,
+		Name:           name,
+		Type:           nodeType,
+		Result:         task.Result,
+		State:          task.State,
+		StartTime:      task.StartTime,
+		DurationMillis: task.DurationInMillis,
+		Children:       make([]*TimingNode, 0),
+	}
+}
+
+// TimingDiff compares stage durations of two runs of the same pipeline config
+type TimingDiff struct {
+	Base   *PipelineTiming `json:"base"`
+	Target *PipelineTiming `json:"target"`
+	// Stages of the target run followed by stages only present in the base run
+	Stages []StageTimingDiff `json:"stages"`
+}
+
+// StageTimingDiff is the difference of durations of a stage or parallel branch
+type StageTimingDiff struct {
+	// Path is the stage name, parallel branches are prefixed by their stage name
+	Path               string `json:"path"`
+	BaseDurationMillis *int64 `json:"baseDurationMillis"`
+	DurationMillis     *int64 `json:"durationMillis"`
+	// DeltaMillis is positive when the stage of the target run took longer
+	DeltaMillis int64 `json:"deltaMillis"`
+	// DeltaPercent is relative to the base duration, zero when the stage is missing in any run
+	DeltaPercent float64 `json:"deltaPercent"`
+}
+
+// DiffPipelineTiming compares stage timing of target and base runs. Both of them have to be runs
+// of the same pipeline config.
+func DiffPipelineTiming(client devopsclient.Interface, namespace, name, base string) (*TimingDiff, error) {
+	var (
+		timings [2]*PipelineTiming
+		errs    [2]error
+		wg      sync.WaitGroup
+	)
+	for i, n := range []string{name, base} {
+		wg.Add(1)
+		go func(i int, n string) {
+			defer wg.Done()
+			timings[i], errs[i] = GetPipelineTiming(client, namespace, n)
+		}(i, n)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	target, baseTiming := timings[0], timings[1]
+	if target.PipelineConfig != baseTiming.PipelineConfig {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("pipelines %s and %s belong to different pipeline configs", name, base))
+	}
+
+	return &TimingDiff{
+		Base:   baseTiming,
+		Target: target,
+		Stages: diffStages(baseTiming.Stages, target.Stages),
+	}, nil
+}
+
+// diffStages matches stages and parallel branches of two runs by their path
+func diffStages(base, target []*TimingNode) []StageTimingDiff {
+	baseDurations, basePaths := stageDurations(base)
+	targetDurations, targetPaths := stageDurations(target)
+
+	result := make([]StageTimingDiff, 0)
+	add := func(path string) {
+		diff := StageTimingDiff{Path: path}
+		if d, ok := baseDurations[path]; ok {
+			diff.BaseDurationMillis = &d
+		}
+		if d, ok := targetDurations[path]; ok {
+			diff.DurationMillis = &d
+		}
+		if diff.BaseDurationMillis != nil && diff.DurationMillis != nil {
+			diff.DeltaMillis = *diff.DurationMillis - *diff.BaseDurationMillis
+			if *diff.BaseDurationMillis > 0 {
+				diff.DeltaPercent = float64(diff.DeltaMillis) * 100 / float64(*diff.BaseDurationMillis)
+			}
+		}
+		result = append(result, diff)
+	}
+
+	for _, path := range targetPaths {
+		add(path)
+	}
+	for _, path := range basePaths {
+		if _, ok := targetDurations[path]; !ok {
+			add(path)
+		}
+	}
+	return result
+}
+
+// stageDurations returns durations of stages and parallel branches by path together with paths in order
+func stageDurations(stages []*TimingNode) (map[string]int64, []string) {
+	durations := make(map[string]int64, 0)
+	paths := make([]string, 0)
+	add := func(path string, node *TimingNode) {
+		if _, ok := durations[path]; ok {
+			return
+		}
+		durations[path] = node.DurationMillis
+		paths = append(paths, path)
+	}
+
+	for _, stage := range stages {
+		add(stage.Name, stage)
+		for _, child := range stage.Children {
+			if child.Type == TimingNodeTypeParallel {
+				add(stage.Name+"/"+child.Name, child)
+			}
+		}
+	}
+	return durations, paths
+}
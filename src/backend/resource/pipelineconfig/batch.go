@@ -0,0 +1,41 @@
+package pipelineconfig
+
+import (
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"k8s.io/client-go/kubernetes"
+)
+
+// TriggerPipelineConfigs triggers every PipelineConfig selected by the query. Branch, commit and
+// params of the spec are used for every trigger, its name and namespace are ignored.
+func TriggerPipelineConfigs(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace *common.NamespaceQuery,
+	dsQuery *dataselect.DataSelectQuery, spec *PipelineConfigTrigger, options common.BatchOptions) (*common.BatchResult, error) {
+	pipelineConfigList, err := client.DevopsV1alpha1().PipelineConfigs(namespace.ToRequestParam()).List(api.ListEverything)
+	_, err = errors.HandleError(err)
+	if err != nil {
+		return nil, err
+	}
+
+	// batches act on every selected PipelineConfig, not on a single page
+	query := *dsQuery
+	query.PaginationQuery = dataselect.NoPagination
+	configCells, _ := dataselect.GenericDataSelectWithFilter(toCells(pipelineConfigList.Items), &query)
+
+	targets := make([]common.BatchTarget, 0)
+	for _, config := range fromCells(configCells) {
+		if !namespace.Matches(config.Namespace) {
+			continue
+		}
+		targets = append(targets, common.BatchTarget{Namespace: config.Namespace, Name: config.Name})
+	}
+
+	return common.RunBatch(targets, options, func(target common.BatchTarget) (interface{}, error) {
+		trigger := *spec
+		trigger.Namespace = target.Namespace
+		trigger.Name = target.Name
+		return TriggerPipelineConfig(client, k8sclient, &trigger)
+	}), nil
+}
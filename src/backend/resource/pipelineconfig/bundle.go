@@ -0,0 +1,472 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	"github.com/ghodss/yaml"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// BundleAPIVersion is the version of the pipeline config bundle format
+	BundleAPIVersion = "diablo.alauda.io/v1"
+	// BundleKind is the kind of pipeline config bundles
+	BundleKind = "PipelineConfigBundle"
+
+	// PlaceholderKindJenkinsBinding placeholder of the JenkinsBinding used by a pipeline config
+	PlaceholderKindJenkinsBinding = "JenkinsBinding"
+	// PlaceholderKindCodeRepository placeholder of a CodeRepository bound by a CodeRepoBinding
+	PlaceholderKindCodeRepository = "CodeRepository"
+	// PlaceholderKindSecret placeholder of a secret, its values are namespace/name
+	PlaceholderKindSecret = "Secret"
+
+	displayTypeJenkinsCredentials = "alauda.io/jenkinscredentials"
+)
+
+// PipelineConfigBundle is a portable set of pipeline configs. Names of bindings, code repositories and
+// secrets in the specs are replaced with placeholders which are remapped when the bundle is imported.
+type PipelineConfigBundle struct {
+	APIVersion      string                    `json:"apiVersion"`
+	Kind            string                    `json:"kind"`
+	SourceNamespace string                    `json:"sourceNamespace"`
+	ExportedAt      time.Time                 `json:"exportedAt"`
+	Placeholders    []BundlePlaceholder       `json:"placeholders"`
+	Templates       []BundleTemplateReference `json:"templates"`
+	PipelineConfigs []BundlePipelineConfig    `json:"pipelineConfigs"`
+}
+
+// BundlePlaceholder is a value of the source namespace abstracted in the bundle
+type BundlePlaceholder struct {
+	// Name is used as ${Name} in the specs, e.g. ${JenkinsBinding:jenkins}
+	Name string `json:"name"`
+	Kind string `json:"kind"`
+	// Value is the value in the source namespace
+	Value string `json:"value"`
+}
+
+// BundleTemplateReference is a template used by pipeline configs of the bundle
+type BundleTemplateReference struct {
+	Kind string `json:"kind"`
+	Name string `json:"name"`
+}
+
+// BundlePipelineConfig is a pipeline config without its namespace and status
+type BundlePipelineConfig struct {
+	Name        string                 `json:"name"`
+	Labels      map[string]string      `json:"labels,omitempty"`
+	Annotations map[string]string      `json:"annotations,omitempty"`
+	Spec        map[string]interface{} `json:"spec"`
+}
+
+// BundleImportOptions contains a bundle in YAML and values of its placeholders in the target namespace
+type BundleImportOptions struct {
+	Bundle string `json:"bundle"`
+	// Mappings are values of placeholders by name. Placeholders without a mapping are resolved to a
+	// resource with the same name or the only resource of the kind in the target namespace.
+	Mappings map[string]string `json:"mappings"`
+}
+
+// BundleImportResult is the preview or the result of a bundle import
+type BundleImportResult struct {
+	DryRun       bool                  `json:"dryRun"`
+	Placeholders []ResolvedPlaceholder `json:"placeholders"`
+	Items        []BundleImportItem    `json:"items"`
+	// Errors are problems preventing the import, e.g. missing templates or unresolved placeholders
+	Errors []string `json:"errors"`
+}
+
+// ResolvedPlaceholder is a placeholder with its value in the target namespace
+type ResolvedPlaceholder struct {
+	BundlePlaceholder
+	// Target is empty when the placeholder could not be resolved
+	Target string `json:"target"`
+	// Candidates are resources of the kind in the target namespace
+	Candidates []string `json:"candidates"`
+}
+
+// BundleImportItem is the result of importing a pipeline config
+type BundleImportItem struct {
+	Name string `json:"name"`
+	// Exists is true when a pipeline config with the name exists in the target namespace, it is not imported
+	Exists  bool   `json:"exists"`
+	Created bool   `json:"created"`
+	Error   string `json:"error,omitempty"`
+}
+
+func placeholderToken(name string) string {
+	return "${" + name + "}"
+}
+
+// ExportPipelineConfigs exports the named pipeline configs of the namespace, all of them when names is empty
+func ExportPipelineConfigs(client devopsclient.Interface, namespace string, names []string) (*PipelineConfigBundle, error) {
+	configs := make([]devopsv1alpha1.PipelineConfig, 0)
+	if len(names) == 0 {
+		list, err := client.DevopsV1alpha1().PipelineConfigs(namespace).List(api.ListEverything)
+		if err != nil {
+			return nil, err
+		}
+		configs = list.Items
+	} else {
+		for _, name := range names {
+			config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+			if err != nil {
+				return nil, err
+			}
+			configs = append(configs, *config)
+		}
+	}
+
+	bundle := &PipelineConfigBundle{
+		APIVersion:      BundleAPIVersion,
+		Kind:            BundleKind,
+		SourceNamespace: namespace,
+		ExportedAt:      time.Now(),
+		Placeholders:    make([]BundlePlaceholder, 0),
+		Templates:       make([]BundleTemplateReference, 0),
+		PipelineConfigs: make([]BundlePipelineConfig, 0, len(configs)),
+	}
+	placeholders := make(map[string]BundlePlaceholder, 0)
+	templates := make(map[BundleTemplateReference]struct{}, 0)
+	addPlaceholder := func(kind, value string) string {
+		placeholder := BundlePlaceholder{Name: kind + ":" + value, Kind: kind, Value: value}
+		placeholders[placeholder.Name] = placeholder
+		return placeholderToken(placeholder.Name)
+	}
+
+	for _, config := range configs {
+		spec := make(map[string]interface{}, 0)
+		if err := convertJSON(config.Spec, &spec); err != nil {
+			return nil, err
+		}
+		if template := abstractSpec(spec, namespace, addPlaceholder); template != nil {
+			templates[*template] = struct{}{}
+		}
+		bundle.PipelineConfigs = append(bundle.PipelineConfigs, BundlePipelineConfig{
+			Name:        config.Name,
+			Labels:      config.Labels,
+			Annotations: portableAnnotations(config.Annotations),
+			Spec:        spec,
+		})
+	}
+
+	for _, placeholder := range placeholders {
+		bundle.Placeholders = append(bundle.Placeholders, placeholder)
+	}
+	sort.Slice(bundle.Placeholders, func(i, j int) bool {
+		return bundle.Placeholders[i].Name < bundle.Placeholders[j].Name
+	})
+	for template := range templates {
+		bundle.Templates = append(bundle.Templates, template)
+	}
+	sort.Slice(bundle.Templates, func(i, j int) bool {
+		return bundle.Templates[i].Kind+"/"+bundle.Templates[i].Name < bundle.Templates[j].Kind+"/"+bundle.Templates[j].Name
+	})
+	return bundle, nil
+}
+
+// MarshalBundle returns the bundle in YAML
+func MarshalBundle(bundle *PipelineConfigBundle) ([]byte, error) {
+	return yaml.Marshal(bundle)
+}
+
+// abstractSpec replaces names of the source namespace in the spec with placeholders and returns the
+// template used by the spec
+func abstractSpec(spec map[string]interface{}, namespace string, addPlaceholder func(kind, value string) string) *BundleTemplateReference {
+	if binding := nestedMap(spec, "jenkinsBinding"); binding != nil {
+		if name, ok := binding["name"].(string); ok && name != "" {
+			binding["name"] = addPlaceholder(PlaceholderKindJenkinsBinding, name)
+		}
+	}
+
+	if source := nestedMap(spec, "source"); source != nil {
+		if repository := nestedMap(source, "codeRepository"); repository != nil {
+			if name, ok := repository["name"].(string); ok && name != "" {
+				repository["name"] = addPlaceholder(PlaceholderKindCodeRepository, name)
+			}
+		}
+		if secret := nestedMap(source, "secret"); secret != nil {
+			if name, ok := secret["name"].(string); ok && name != "" {
+				secretNamespace, _ := secret["namespace"].(string)
+				if secretNamespace == "" {
+					secretNamespace = namespace
+				}
+				secret["name"] = addPlaceholder(PlaceholderKindSecret, secretNamespace+"/"+name)
+				delete(secret, "namespace")
+			}
+		}
+	}
+
+	template := nestedMap(spec, "strategy", "template")
+	if template == nil {
+		return nil
+	}
+	for _, item := range templateArgumentItems(template) {
+		display := nestedMap(item, "display")
+		if value, ok := item["value"].(string); ok && value != "" && display != nil && display["type"] == displayTypeJenkinsCredentials {
+			item["value"] = addPlaceholder(PlaceholderKindSecret, value)
+		}
+	}
+	kind, _ := template["kind"].(string)
+	metadata := nestedMap(template, "metadata")
+	name, _ := metadata["name"].(string)
+	// namespaced templates are taken from the target namespace
+	delete(metadata, "namespace")
+	if name == "" {
+		return nil
+	}
+	return &BundleTemplateReference{Kind: kind, Name: name}
+}
+
+// portableAnnotations drops annotations maintained by Jenkins sync and the dashboard
+func portableAnnotations(annotations map[string]string) map[string]string {
+	result := make(map[string]string, len(annotations))
+	for key, value := range annotations {
+		if strings.HasPrefix(key, "alauda.io/jenkins.") || key == common.AnnotationsKeySchedules ||
+			key == "kubectl.kubernetes.io/last-applied-configuration" {
+			continue
+		}
+		result[key] = value
+	}
+	return result
+}
+
+func templateArgumentItems(template map[string]interface{}) []map[string]interface{} {
+	items := make([]map[string]interface{}, 0)
+	arguments, _ := nestedValue(template, "spec", "arguments").([]interface{})
+	for _, argument := range arguments {
+		argumentItems, _ := nestedValue(argument, "items").([]interface{})
+		for _, item := range argumentItems {
+			if item, ok := item.(map[string]interface{}); ok {
+				items = append(items, item)
+			}
+		}
+	}
+	return items
+}
+
+// ImportPipelineConfigs creates pipeline configs of the bundle in the namespace. With dryRun only the
+// preview of the import is returned. Nothing is created when placeholders cannot be resolved or
+// templates are missing, pipeline configs which exist already are skipped.
+func ImportPipelineConfigs(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace string, opts *BundleImportOptions,
+	dryRun bool) (*BundleImportResult, error) {
+	bundle := new(PipelineConfigBundle)
+	if err := yaml.Unmarshal([]byte(opts.Bundle), bundle); err != nil {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("invalid bundle: %v", err))
+	}
+	if bundle.Kind != BundleKind {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("invalid bundle kind %q, expected %s", bundle.Kind, BundleKind))
+	}
+
+	result := &BundleImportResult{
+		DryRun:       dryRun,
+		Placeholders: make([]ResolvedPlaceholder, 0, len(bundle.Placeholders)),
+		Items:        make([]BundleImportItem, 0, len(bundle.PipelineConfigs)),
+		Errors:       make([]string, 0),
+	}
+
+	candidates, err := getPlaceholderCandidates(client, k8sclient, namespace)
+	if err != nil {
+		return nil, err
+	}
+	targets := make(map[string]string, len(bundle.Placeholders))
+	for _, placeholder := range bundle.Placeholders {
+		resolved := resolvePlaceholder(placeholder, namespace, opts.Mappings[placeholder.Name], candidates[placeholder.Kind])
+		if resolved.Target == "" {
+			result.Errors = append(result.Errors, fmt.Sprintf("placeholder %s is not resolved", placeholder.Name))
+		}
+		targets[placeholderToken(placeholder.Name)] = resolved.Target
+		result.Placeholders = append(result.Placeholders, resolved)
+	}
+
+	for _, template := range bundle.Templates {
+		var err error
+		switch template.Kind {
+		case "ClusterPipelineTemplate":
+			_, err = client.DevopsV1alpha1().ClusterPipelineTemplates().Get(template.Name, api.GetOptionsInCache)
+		default:
+			_, err = client.DevopsV1alpha1().PipelineTemplates(namespace).Get(template.Name, api.GetOptionsInCache)
+		}
+		if err != nil {
+			result.Errors = append(result.Errors, fmt.Sprintf("%s %s: %v", template.Kind, template.Name, err))
+		}
+	}
+
+	configs := make([]*PipelineConfigDetail, 0, len(bundle.PipelineConfigs))
+	for _, item := range bundle.PipelineConfigs {
+		detail, err := toImportedPipelineConfig(item, namespace, targets)
+		if err != nil {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("invalid pipeline config %s: %v", item.Name, err))
+		}
+		configs = append(configs, detail)
+	}
+
+	canCreate := !dryRun && len(result.Errors) == 0
+	for _, config := range configs {
+		item := BundleImportItem{Name: config.ObjectMeta.Name}
+		_, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(item.Name, api.GetOptionsInCache)
+		switch {
+		case err == nil:
+			item.Exists = true
+			item.Error = "pipeline config already exists"
+		case !k8serrors.IsNotFound(err):
+			item.Error = err.Error()
+		case canCreate:
+			if _, err := CreatePipelineConfigDetail(client, config); err != nil {
+				item.Error = err.Error()
+			} else {
+				item.Created = true
+			}
+		}
+		result.Items = append(result.Items, item)
+	}
+	return result, nil
+}
+
+// getPlaceholderCandidates returns names of resources in the namespace by placeholder kind
+func getPlaceholderCandidates(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace string) (map[string][]string, error) {
+	candidates := make(map[string][]string, 0)
+
+	bindings, err := client.DevopsV1alpha1().JenkinsBindings(namespace).List(api.ListEverything)
+	if err != nil {
+		return nil, err
+	}
+	for _, binding := range bindings.Items {
+		candidates[PlaceholderKindJenkinsBinding] = append(candidates[PlaceholderKindJenkinsBinding], binding.Name)
+	}
+
+	repositories, err := client.DevopsV1alpha1().CodeRepositories(namespace).List(api.ListEverything)
+	if err != nil {
+		return nil, err
+	}
+	for _, repository := range repositories.Items {
+		candidates[PlaceholderKindCodeRepository] = append(candidates[PlaceholderKindCodeRepository], repository.Name)
+	}
+
+	secrets, err := k8sclient.CoreV1().Secrets(namespace).List(api.ListEverything)
+	if err != nil {
+		return nil, err
+	}
+	for _, secret := range secrets.Items {
+		candidates[PlaceholderKindSecret] = append(candidates[PlaceholderKindSecret], namespace+"/"+secret.Name)
+	}
+	return candidates, nil
+}
+
+// resolvePlaceholder returns the mapping when it is a candidate, otherwise the candidate with the same
+// name as the value in the source namespace or the only candidate
+func resolvePlaceholder(placeholder BundlePlaceholder, namespace, mapping string, candidates []string) ResolvedPlaceholder {
+	resolved := ResolvedPlaceholder{BundlePlaceholder: placeholder, Candidates: candidates}
+	if resolved.Candidates == nil {
+		resolved.Candidates = make([]string, 0)
+	}
+
+	sameName := placeholder.Value
+	if placeholder.Kind == PlaceholderKindSecret {
+		if mapping != "" && !strings.Contains(mapping, "/") {
+			mapping = namespace + "/" + mapping
+		}
+		sameName = namespace + "/" + placeholder.Value[strings.LastIndex(placeholder.Value, "/")+1:]
+	}
+
+	switch {
+	case mapping != "":
+		// secrets of other namespaces, e.g. global credentials, are not candidates
+		if common.IsInSlice(candidates, mapping) || (placeholder.Kind == PlaceholderKindSecret && !strings.HasPrefix(mapping, namespace+"/")) {
+			resolved.Target = mapping
+		}
+	case common.IsInSlice(candidates, sameName):
+		resolved.Target = sameName
+	case len(candidates) == 1:
+		resolved.Target = candidates[0]
+	}
+	return resolved
+}
+
+// toImportedPipelineConfig replaces placeholders in the pipeline config of the bundle with their targets
+func toImportedPipelineConfig(item BundlePipelineConfig, namespace string, targets map[string]string) (*PipelineConfigDetail, error) {
+	spec := replacePlaceholders(item.Spec, targets).(map[string]interface{})
+
+	// secrets of code sources are referenced by name and namespace
+	if secret := nestedMap(spec, "source", "secret"); secret != nil {
+		if value, ok := secret["name"].(string); ok && strings.Contains(value, "/") {
+			parts := strings.SplitN(value, "/", 2)
+			secret["namespace"], secret["name"] = parts[0], parts[1]
+		}
+	}
+	if template := nestedMap(spec, "strategy", "template"); template != nil && template["kind"] != "ClusterPipelineTemplate" {
+		if metadata := nestedMap(template, "metadata"); metadata != nil {
+			metadata["namespace"] = namespace
+		}
+	}
+
+	detail := &PipelineConfigDetail{}
+	if err := convertJSON(spec, &detail.Spec); err != nil {
+		return nil, err
+	}
+	detail.ObjectMeta = api.ObjectMeta{
+		Name:        item.Name,
+		Namespace:   namespace,
+		Labels:      item.Labels,
+		Annotations: item.Annotations,
+	}
+	return detail, nil
+}
+
+// replacePlaceholders returns a copy of the value with placeholder tokens replaced by their targets
+func replacePlaceholders(value interface{}, targets map[string]string) interface{} {
+	switch value := value.(type) {
+	case map[string]interface{}:
+		result := make(map[string]interface{}, len(value))
+		for key, item := range value {
+			result[key] = replacePlaceholders(item, targets)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(value))
+		for i, item := range value {
+			result[i] = replacePlaceholders(item, targets)
+		}
+		return result
+	case string:
+		if target, ok := targets[value]; ok {
+			return target
+		}
+		return value
+	default:
+		return value
+	}
+}
+
+// convertJSON converts between types with the same JSON representation
+func convertJSON(in, out interface{}) error {
+	raw, err := json.Marshal(in)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(raw, out)
+}
+
+func nestedValue(value interface{}, keys ...string) interface{} {
+	for _, key := range keys {
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		value = object[key]
+	}
+	return value
+}
+
+func nestedMap(value interface{}, keys ...string) map[string]interface{} {
+	result, _ := nestedValue(value, keys...).(map[string]interface{})
+	return result
+}
@@ -0,0 +1,146 @@
+package pipelineconfig
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestAbstractSpec(t *testing.T) {
+	spec := map[string]interface{}{
+		"jenkinsBinding": map[string]interface{}{"name": "jenkins"},
+		"source": map[string]interface{}{
+			"codeRepository": map[string]interface{}{"name": "repo"},
+			"secret":         map[string]interface{}{"name": "git-credentials"},
+		},
+		"strategy": map[string]interface{}{
+			"template": map[string]interface{}{
+				"kind":     "ClusterPipelineTemplate",
+				"metadata": map[string]interface{}{"name": "golang-build", "namespace": "source"},
+				"spec": map[string]interface{}{
+					"arguments": []interface{}{
+						map[string]interface{}{
+							"items": []interface{}{
+								map[string]interface{}{
+									"value":   "global-credentials/registry",
+									"display": map[string]interface{}{"type": displayTypeJenkinsCredentials},
+								},
+								map[string]interface{}{
+									"value":   "golang:1.12",
+									"display": map[string]interface{}{"type": "string"},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+
+	added := make(map[string]string, 0)
+	reference := abstractSpec(spec, "source", func(kind, value string) string {
+		token := placeholderToken(kind + ":" + value)
+		added[token] = value
+		return token
+	})
+
+	if expected := (&BundleTemplateReference{Kind: "ClusterPipelineTemplate", Name: "golang-build"}); !reflect.DeepEqual(reference, expected) {
+		t.Errorf("abstractSpec() == %v, expected template %v", reference, expected)
+	}
+	expected := map[string]string{
+		"${JenkinsBinding:jenkins}":             "jenkins",
+		"${CodeRepository:repo}":                "repo",
+		"${Secret:source/git-credentials}":      "source/git-credentials",
+		"${Secret:global-credentials/registry}": "global-credentials/registry",
+	}
+	if !reflect.DeepEqual(added, expected) {
+		t.Errorf("abstractSpec() added placeholders %v, expected %v", added, expected)
+	}
+
+	secret := nestedMap(spec, "source", "secret")
+	if _, ok := secret["namespace"]; ok || secret["name"] != "${Secret:source/git-credentials}" {
+		t.Errorf("abstractSpec() left secret %v, expected placeholder without namespace", secret)
+	}
+	if _, ok := nestedMap(spec, "strategy", "template", "metadata")["namespace"]; ok {
+		t.Errorf("abstractSpec() kept namespace of the template")
+	}
+	items := templateArgumentItems(nestedMap(spec, "strategy", "template"))
+	if items[1]["value"] != "golang:1.12" {
+		t.Errorf("abstractSpec() replaced argument %v which is not a credential", items[1])
+	}
+
+	if reference := abstractSpec(map[string]interface{}{}, "source", nil); reference != nil {
+		t.Errorf("abstractSpec() of spec without template == %v, expected nil", reference)
+	}
+}
+
+func TestResolvePlaceholder(t *testing.T) {
+	binding := BundlePlaceholder{Name: "JenkinsBinding:jenkins", Kind: PlaceholderKindJenkinsBinding, Value: "jenkins"}
+	secret := BundlePlaceholder{Name: "Secret:source/git", Kind: PlaceholderKindSecret, Value: "source/git"}
+
+	cases := []struct {
+		info        string
+		placeholder BundlePlaceholder
+		mapping     string
+		candidates  []string
+		expected    string
+	}{
+		{"same name", binding, "", []string{"other", "jenkins"}, "jenkins"},
+		{"only candidate", binding, "", []string{"other"}, "other"},
+		{"ambiguous", binding, "", []string{"a", "b"}, ""},
+		{"no candidates", binding, "", nil, ""},
+		{"mapping", binding, "b", []string{"a", "b"}, "b"},
+		{"mapping to missing resource", binding, "c", []string{"a", "b"}, ""},
+		{"secret of the same name", secret, "", []string{"target/other", "target/git"}, "target/git"},
+		{"secret mapping without namespace", secret, "other", []string{"target/other"}, "target/other"},
+		{"secret mapping to missing secret", secret, "missing", []string{"target/other"}, ""},
+		{"secret mapping to another namespace", secret, "global-credentials/git", nil, "global-credentials/git"},
+	}
+
+	for _, c := range cases {
+		resolved := resolvePlaceholder(c.placeholder, "target", c.mapping, c.candidates)
+		if resolved.Target != c.expected {
+			t.Errorf("Test Case: %s. resolvePlaceholder() target == %q, expected %q", c.info, resolved.Target, c.expected)
+		}
+		if resolved.Candidates == nil {
+			t.Errorf("Test Case: %s. resolvePlaceholder() candidates are nil, expected a list", c.info)
+		}
+	}
+}
+
+func TestReplacePlaceholders(t *testing.T) {
+	targets := map[string]string{"${JenkinsBinding:jenkins}": "jenkins-prod", "${Secret:source/git}": "target/git"}
+
+	cases := []struct {
+		info     string
+		value    interface{}
+		expected interface{}
+	}{
+		{"placeholder", "${JenkinsBinding:jenkins}", "jenkins-prod"},
+		{"other string", "golang:1.12", "golang:1.12"},
+		{"embedded placeholder", "prefix-${JenkinsBinding:jenkins}", "prefix-${JenkinsBinding:jenkins}"},
+		{"number", float64(3), float64(3)},
+		{
+			"nested",
+			map[string]interface{}{
+				"jenkinsBinding": map[string]interface{}{"name": "${JenkinsBinding:jenkins}"},
+				"items":          []interface{}{"${Secret:source/git}", true},
+			},
+			map[string]interface{}{
+				"jenkinsBinding": map[string]interface{}{"name": "jenkins-prod"},
+				"items":          []interface{}{"target/git", true},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		if actual := replacePlaceholders(c.value, targets); !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Test Case: %s. replacePlaceholders() == %v, expected %v", c.info, actual, c.expected)
+		}
+	}
+
+	original := map[string]interface{}{"name": "${JenkinsBinding:jenkins}"}
+	replacePlaceholders(original, targets)
+	if original["name"] != "${JenkinsBinding:jenkins}" {
+		t.Errorf("replacePlaceholders() changed its input %v", original)
+	}
+}
@@ -0,0 +1,146 @@
+package pipelineconfig
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+const displayTypeCodeBranch = "alauda.io/codebranch"
+
+// PipelineConfigClone contains the name of the copy of a PipelineConfig and values overriding the
+// original ones. Empty values keep the original ones.
+type PipelineConfigClone struct {
+	Name string `json:"name"`
+	// Namespace defaults to the namespace of the original PipelineConfig
+	Namespace      string `json:"namespace"`
+	DisplayName    string `json:"displayName"`
+	JenkinsBinding string `json:"jenkinsBinding"`
+	// Repository is the name of a bound CodeRepository or the uri of a git or svn repository
+	Repository string `json:"repository"`
+	Branch     string `json:"branch"`
+	// Parameters override values of template arguments and parameters by name
+	Parameters map[string]string `json:"parameters"`
+}
+
+// ClonePipelineConfig creates a copy of the PipelineConfig with the overrides. Templates are instantiated
+// again as on creation, so that template-based copies stay valid.
+func ClonePipelineConfig(client devopsclient.Interface, namespace, name string, clone *PipelineConfigClone) (*devopsv1alpha1.PipelineConfig, error) {
+	clone.Name = strings.TrimSpace(clone.Name)
+	if clone.Name == "" {
+		return nil, k8serrors.NewBadRequest("name of the copy is required")
+	}
+	if clone.Namespace == "" {
+		clone.Namespace = namespace
+	}
+
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	spec := make(map[string]interface{}, 0)
+	if err := convertJSON(config.Spec, &spec); err != nil {
+		return nil, err
+	}
+	if err := overrideSpec(spec, namespace, clone); err != nil {
+		return nil, err
+	}
+
+	detail := &PipelineConfigDetail{}
+	if err := convertJSON(spec, &detail.Spec); err != nil {
+		return nil, err
+	}
+	detail.ObjectMeta = api.ObjectMeta{
+		Name:        clone.Name,
+		Namespace:   clone.Namespace,
+		Labels:      config.Labels,
+		Annotations: portableAnnotations(config.Annotations),
+	}
+	if clone.DisplayName != "" {
+		detail.ObjectMeta.Annotations[common.AnnotationsKeyDisplayName] = clone.DisplayName
+	}
+	return CreatePipelineConfigDetail(client, detail)
+}
+
+// overrideSpec applies the overrides of the clone to the spec of the PipelineConfig in namespace. Parameters
+// which match no template argument or parameter are rejected, as they would be silently ignored.
+func overrideSpec(spec map[string]interface{}, namespace string, clone *PipelineConfigClone) error {
+	if clone.JenkinsBinding != "" {
+		if binding := nestedMap(spec, "jenkinsBinding"); binding != nil {
+			binding["name"] = clone.JenkinsBinding
+		}
+	}
+
+	if source := nestedMap(spec, "source"); source != nil {
+		for _, kind := range []string{"codeRepository", "git", "svn"} {
+			repository := nestedMap(source, kind)
+			if repository == nil {
+				continue
+			}
+			if clone.Repository != "" {
+				if kind == "codeRepository" {
+					repository["name"] = clone.Repository
+				} else {
+					repository["uri"] = clone.Repository
+				}
+			}
+			if clone.Branch != "" {
+				repository["ref"] = clone.Branch
+			}
+		}
+		// secrets of the original namespace are expected in the namespace of the copy
+		if secret := nestedMap(source, "secret"); secret != nil && secret["namespace"] == namespace {
+			secret["namespace"] = clone.Namespace
+		}
+	}
+
+	applied := make(map[string]bool, len(clone.Parameters))
+	template := nestedMap(spec, "strategy", "template")
+	if template == nil {
+		return unknownParameters(clone.Parameters, applied)
+	}
+	if metadata := nestedMap(template, "metadata"); metadata != nil && template["kind"] != "ClusterPipelineTemplate" {
+		metadata["namespace"] = clone.Namespace
+	}
+	for _, item := range templateArgumentItems(template) {
+		itemName, _ := item["name"].(string)
+		if value, ok := clone.Parameters[itemName]; ok {
+			item["value"] = value
+			applied[itemName] = true
+		} else if display := nestedMap(item, "display"); clone.Branch != "" && display != nil && display["type"] == displayTypeCodeBranch {
+			item["value"] = clone.Branch
+		}
+	}
+	parameters, _ := nestedValue(template, "spec", "parameters").([]interface{})
+	for _, parameter := range parameters {
+		if parameter, ok := parameter.(map[string]interface{}); ok {
+			parameterName, _ := parameter["name"].(string)
+			if value, ok := clone.Parameters[parameterName]; ok {
+				parameter["value"] = value
+				applied[parameterName] = true
+			}
+		}
+	}
+	return unknownParameters(clone.Parameters, applied)
+}
+
+// unknownParameters returns a BadRequest listing parameters which were not applied, nil when all were
+func unknownParameters(parameters map[string]string, applied map[string]bool) error {
+	unknown := make([]string, 0)
+	for name := range parameters {
+		if !applied[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) == 0 {
+		return nil
+	}
+	sort.Strings(unknown)
+	return k8serrors.NewBadRequest(fmt.Sprintf("unknown template arguments or parameters: %s", strings.Join(unknown, ", ")))
+}
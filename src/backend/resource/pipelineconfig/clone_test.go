@@ -0,0 +1,52 @@
+package pipelineconfig
+
+import "testing"
+
+func TestOverrideSpecParameters(t *testing.T) {
+	newSpec := func() map[string]interface{} {
+		return map[string]interface{}{
+			"strategy": map[string]interface{}{
+				"template": map[string]interface{}{
+					"kind":     "PipelineTemplate",
+					"metadata": map[string]interface{}{"name": "build"},
+					"spec": map[string]interface{}{
+						"arguments": []interface{}{
+							map[string]interface{}{
+								"items": []interface{}{map[string]interface{}{"name": "image", "value": "golang"}},
+							},
+						},
+						"parameters": []interface{}{map[string]interface{}{"name": "env", "value": "dev"}},
+					},
+				},
+			},
+		}
+	}
+
+	cases := []struct {
+		info       string
+		spec       map[string]interface{}
+		parameters map[string]string
+		valid      bool
+	}{
+		{"argument and parameter", newSpec(), map[string]string{"image": "alpine", "env": "prod"}, true},
+		{"no parameters", newSpec(), nil, true},
+		{"unknown parameter", newSpec(), map[string]string{"image": "alpine", "imgae": "alpine"}, false},
+		{"parameters without template", map[string]interface{}{}, map[string]string{"env": "prod"}, false},
+	}
+
+	for _, c := range cases {
+		err := overrideSpec(c.spec, "ns", &PipelineConfigClone{Namespace: "ns", Parameters: c.parameters})
+		if (err == nil) != c.valid {
+			t.Errorf("Test Case: %s. overrideSpec() returned %v, expected valid: %t", c.info, err, c.valid)
+		}
+	}
+
+	spec := newSpec()
+	if err := overrideSpec(spec, "ns", &PipelineConfigClone{Namespace: "ns", Parameters: map[string]string{"env": "prod"}}); err != nil {
+		t.Fatalf("overrideSpec() returned %v", err)
+	}
+	parameters := nestedValue(spec, "strategy", "template", "spec", "parameters").([]interface{})
+	if value := parameters[0].(map[string]interface{})["value"]; value != "prod" {
+		t.Errorf("overrideSpec() set parameter env to %v, expected prod", value)
+	}
+}
@@ -35,6 +35,11 @@ type PipelineConfigTrigger struct {
 	Branch    string                             `json:"branch"`
 	Commit    string                             `json:"commit"`
 	Params    []devopsv1alpha1.PipelineParameter `json:"params"`
+	// Preset is the name of saved parameter values used for parameters which are not in Params
+	Preset string `json:"preset"`
+	// SkipValidation passes parameters through without checking them against their definitions and
+	// without filling defaults. Parameters are validated unless it is set.
+	SkipValidation bool `json:"skipValidation"`
 }
 
 type PipelineTriggerResponse struct {
@@ -268,13 +273,16 @@ func pipelinesFilter(branch string, pipelines []devopsv1alpha1.Pipeline, max int
 	return
 }
 
-// UpdatePipelineConfigDetail update pipeline config detail
-func UpdatePipelineConfigDetail(client devopsclient.Interface, k8sclient kubernetes.Interface, spec *PipelineConfigDetail) (*PipelineConfigDetail, error) {
+// UpdatePipelineConfigDetail update pipeline config detail and records the new spec as a revision by author
+func UpdatePipelineConfigDetail(client devopsclient.Interface, k8sclient kubernetes.Interface, spec *PipelineConfigDetail,
+	author string) (*PipelineConfigDetail, error) {
 	namespace := spec.ObjectMeta.Namespace
 	old, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(spec.ObjectMeta.Name, api.GetOptionsInCache)
 	if err != nil {
 		return nil, err
 	}
+	old = old.DeepCopy()
+	previous := old.Spec
 	old.Spec = spec.Spec
 
 	old.SetAnnotations(common.MergeAnnotations(old.ObjectMeta.Annotations, spec.ObjectMeta.Annotations))
@@ -286,6 +294,7 @@ func UpdatePipelineConfigDetail(client devopsclient.Interface, k8sclient kuberne
 	if err != nil {
 		return nil, err
 	}
+	logRecordRevision(k8sclient, old, previous, author)
 	return spec, nil
 }
 
@@ -363,7 +372,13 @@ func TriggerPipelineConfig(client devopsclient.Interface, k8sclient kubernetes.I
 
 	log.Println("Receive params: ", spec.Params)
 
-	pipe.Spec.Parameters = append(pipe.Spec.Parameters, spec.Params...)
+	_, params, err := getTriggerParameters(config, branchName, spec.Preset, spec.Params, !spec.SkipValidation)
+	if err != nil {
+		return
+	}
+	delete(pipe.Annotations, common.AnnotationsKeyParameterPresets)
+	delete(pipe.Annotations, common.AnnotationsKeySchedules)
+	pipe.Spec.Parameters = append(pipe.Spec.Parameters, params...)
 
 	pipe, err = client.DevopsV1alpha1().Pipelines(spec.Namespace).Create(pipe)
 	if err != nil {
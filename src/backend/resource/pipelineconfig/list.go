@@ -312,6 +312,18 @@ func getPipelineConfigSelector(name string) labels.Selector {
 	return selector
 }
 
+// FilterPipelineConfigPipelines returns the pipelines of the pipeline config among pipelines of its namespace.
+func FilterPipelineConfigPipelines(name string, pipelines []devopsv1alpha1.Pipeline) []devopsv1alpha1.Pipeline {
+	selector := getPipelineConfigSelector(name)
+	result := make([]devopsv1alpha1.Pipeline, 0)
+	for _, p := range pipelines {
+		if selector.Matches(labels.Set(p.Labels)) {
+			result = append(result, p)
+		}
+	}
+	return result
+}
+
 // isPipelineSortedAsc sort the pipeline asc or not
 func isPipelineSortedAsc(dsQuery *dataselect.DataSelectQuery) (bool, bool) {
 	var isSortedByPipelineCreatedAt, sortedAscending bool
@@ -386,6 +398,11 @@ func sortPipelineConfigList(configList *PipelineConfigList, dsQuery *dataselect.
 	return configList
 }
 
+// ToPipelineConfig returns presentation view of a pipeline config together with its latest pipelines.
+func ToPipelineConfig(config devopsv1alpha1.PipelineConfig, pipelines []devopsv1alpha1.Pipeline) PipelineConfig {
+	return toPipelineConfig(config, pipelines)
+}
+
 // toPipelineConfig append pipelines sorted by CreationTimestamp to PipelineConfig
 func toPipelineConfig(config devopsv1alpha1.PipelineConfig, pipelines []devopsv1alpha1.Pipeline) PipelineConfig {
 	maxLen := 5
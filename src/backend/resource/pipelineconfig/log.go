@@ -1,8 +1,11 @@
 package pipelineconfig
 
 import (
+	"time"
+
 	"alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
 	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/pipeline"
 )
 
 // GetLogDetails get scan log from multi-branch pipeline
@@ -11,3 +14,34 @@ func GetLogDetails(client devopsclient.Interface, namespace string, name string,
 		Start: int64(start),
 	})
 }
+
+// FollowLogs fetches scan logs of multi-branch pipeline from given offset and passes every new
+// chunk to the callback until Jenkins reports there are no more logs, the callback fails or
+// stopCh is closed.
+func FollowLogs(client devopsclient.Interface, namespace string, name string, start int,
+	stopCh <-chan struct{}, callback func(*v1alpha1.PipelineConfigLog) error) error {
+	for {
+		configLog, err := GetLogDetails(client, namespace, name, start)
+		if err != nil {
+			return err
+		}
+
+		if len(configLog.Text) > 0 {
+			if err := callback(configLog); err != nil {
+				return err
+			}
+		}
+		if configLog.NextStart != nil {
+			start = int(*configLog.NextStart)
+		}
+		if !configLog.HasMore {
+			return nil
+		}
+
+		select {
+		case <-stopCh:
+			return nil
+		case <-time.After(pipeline.LogFollowInterval):
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package pipelineconfig
+
+import (
+	"fmt"
+	"log"
+	"path"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/kubernetes"
+)
+
+// MaxMultiBranchTriggers is the maximum number of branches and pull requests triggered at once
+const MaxMultiBranchTriggers = 50
+
+// MultiBranchTrigger selects branches and pull requests of a multi-branch PipelineConfig to trigger
+type MultiBranchTrigger struct {
+	// Branches selects active branches
+	Branches bool `json:"branches"`
+	// PullRequests selects open pull requests
+	PullRequests bool `json:"pullRequests"`
+	// Pattern selects branches and pull requests whose name matches the glob, e.g. feature/* or PR-*.
+	// Together with Branches or PullRequests only those of the selected kinds are matched.
+	Pattern string `json:"pattern"`
+	// IncludeStale also selects stale branches and pull requests
+	IncludeStale bool                               `json:"includeStale"`
+	Params       []devopsv1alpha1.PipelineParameter `json:"params"`
+	// Preset is the name of saved parameter values used for parameters which are not in Params
+	Preset string `json:"preset"`
+	// SkipValidation passes parameters of every trigger through, see PipelineConfigTrigger
+	SkipValidation bool `json:"skipValidation"`
+}
+
+// MultiBranchTriggerResponse contains pipelines created for the selected branches
+type MultiBranchTriggerResponse struct {
+	DryRun bool `json:"dryRun"`
+	// Branches are the selected branches and pull requests
+	Branches  []string                   `json:"branches"`
+	Pipelines []*devopsv1alpha1.Pipeline `json:"pipelines"`
+	Errors    []BranchTriggerError       `json:"errors"`
+}
+
+// BranchTriggerError is the error of triggering a single branch
+type BranchTriggerError struct {
+	Branch string `json:"branch"`
+	Error  string `json:"error"`
+}
+
+// TriggerMultiBranchPipelineConfig triggers a pipeline for every branch and pull request selected by
+// the trigger. A failure of a branch does not stop the others. Selecting every branch or pull request
+// without a pattern has to be confirmed, and at most MaxMultiBranchTriggers are triggered at once.
+func TriggerMultiBranchPipelineConfig(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace, name string,
+	trigger *MultiBranchTrigger, options common.BatchOptions) (*MultiBranchTriggerResponse, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	if config.Labels == nil || config.Labels[devopsv1alpha1.LabelPipelineKind] != devopsv1alpha1.LabelPipelineKindMultiBranch {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("pipeline config %s/%s is not multi-branch", namespace, name))
+	}
+
+	branches, err := selectBranches(config.Annotations, trigger)
+	if err != nil {
+		return nil, err
+	}
+	if err := common.CheckBatchSelection(trigger.Pattern != "", options); err != nil {
+		return nil, err
+	}
+	if len(branches) > MaxMultiBranchTriggers {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("%d branches and pull requests are selected, at most %d can be "+
+			"triggered at once, narrow them down with a pattern", len(branches), MaxMultiBranchTriggers))
+	}
+
+	response := &MultiBranchTriggerResponse{
+		DryRun:    options.DryRun,
+		Branches:  branches,
+		Pipelines: make([]*devopsv1alpha1.Pipeline, 0),
+		Errors:    make([]BranchTriggerError, 0),
+	}
+	if options.DryRun {
+		return response, nil
+	}
+	for _, branch := range branches {
+		result, err := TriggerPipelineConfig(client, k8sclient, &PipelineConfigTrigger{
+			Namespace:      namespace,
+			Name:           name,
+			Branch:         branch,
+			Params:         trigger.Params,
+			Preset:         trigger.Preset,
+			SkipValidation: trigger.SkipValidation,
+		})
+		if err != nil {
+			log.Printf("Failed to trigger branch %s of pipeline config %s/%s: %v", branch, namespace, name, err)
+			response.Errors = append(response.Errors, BranchTriggerError{Branch: branch, Error: err.Error()})
+			continue
+		}
+		response.Pipelines = append(response.Pipelines, result.Pipeline)
+	}
+	return response, nil
+}
+
+// selectBranches returns branches followed by pull requests selected by the trigger without duplicates.
+// The pattern narrows down the kinds selected by Branches and PullRequests, both kinds are matched when
+// none is selected.
+func selectBranches(annotations map[string]string, trigger *MultiBranchTrigger) ([]string, error) {
+	if !trigger.Branches && !trigger.PullRequests && trigger.Pattern == "" {
+		return nil, k8serrors.NewBadRequest("branches, pullRequests or pattern is required")
+	}
+	if trigger.Pattern != "" {
+		if _, err := path.Match(trigger.Pattern, ""); err != nil {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("invalid pattern %q: %v", trigger.Pattern, err))
+		}
+	}
+
+	branches := collectBranches(common.AnnotationsKeyMultiBranchBranchList, annotations, make([]string, 0))
+	prs := collectBranches(common.AnnotationsKeyMultiBranchPRList, annotations, make([]string, 0))
+	if trigger.IncludeStale {
+		branches = collectBranches(common.AnnotationsKeyMultiBranchStaleBranchList, annotations, branches)
+		prs = collectBranches(common.AnnotationsKeyMultiBranchStalePRList, annotations, prs)
+	}
+
+	selected := make([]string, 0)
+	seen := make(map[string]struct{}, 0)
+	add := func(names []string) {
+		for _, name := range names {
+			if _, ok := seen[name]; ok {
+				continue
+			}
+			if matched, _ := path.Match(trigger.Pattern, name); trigger.Pattern == "" || matched {
+				seen[name] = struct{}{}
+				selected = append(selected, name)
+			}
+		}
+	}
+	allKinds := !trigger.Branches && !trigger.PullRequests
+	if trigger.Branches || allKinds {
+		add(branches)
+	}
+	if trigger.PullRequests || allKinds {
+		add(prs)
+	}
+	return selected, nil
+}
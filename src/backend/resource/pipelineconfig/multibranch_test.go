@@ -0,0 +1,88 @@
+package pipelineconfig
+
+import (
+	"reflect"
+	"testing"
+
+	"alauda.io/diablo/src/backend/resource/common"
+)
+
+func TestSelectBranches(t *testing.T) {
+	annotations := map[string]string{
+		common.AnnotationsKeyMultiBranchBranchList:      `["master","feature/a","feature/b"]`,
+		common.AnnotationsKeyMultiBranchStaleBranchList: `["feature/old"]`,
+		common.AnnotationsKeyMultiBranchPRList:          `["PR-1","PR-2"]`,
+		common.AnnotationsKeyMultiBranchStalePRList:     `["PR-0"]`,
+	}
+
+	cases := []struct {
+		info     string
+		trigger  MultiBranchTrigger
+		expected []string
+		valid    bool
+	}{
+		{
+			info:  "nothing selected",
+			valid: false,
+		},
+		{
+			info:    "invalid pattern",
+			trigger: MultiBranchTrigger{Pattern: "feature/["},
+			valid:   false,
+		},
+		{
+			info:     "branches",
+			trigger:  MultiBranchTrigger{Branches: true},
+			expected: []string{"master", "feature/a", "feature/b"},
+			valid:    true,
+		},
+		{
+			info:     "pull requests",
+			trigger:  MultiBranchTrigger{PullRequests: true},
+			expected: []string{"PR-1", "PR-2"},
+			valid:    true,
+		},
+		{
+			info:     "branches and pull requests with stale ones",
+			trigger:  MultiBranchTrigger{Branches: true, PullRequests: true, IncludeStale: true},
+			expected: []string{"master", "feature/a", "feature/b", "feature/old", "PR-1", "PR-2", "PR-0"},
+			valid:    true,
+		},
+		{
+			info:     "pattern matches both kinds",
+			trigger:  MultiBranchTrigger{Pattern: "*-1"},
+			expected: []string{"PR-1"},
+			valid:    true,
+		},
+		{
+			info:     "pattern narrows down branches",
+			trigger:  MultiBranchTrigger{Branches: true, Pattern: "feature/*"},
+			expected: []string{"feature/a", "feature/b"},
+			valid:    true,
+		},
+		{
+			info:     "pattern does not select pull requests when only branches are selected",
+			trigger:  MultiBranchTrigger{Branches: true, Pattern: "PR-*"},
+			expected: []string{},
+			valid:    true,
+		},
+		{
+			info:     "pattern with stale branches",
+			trigger:  MultiBranchTrigger{Pattern: "feature/*", IncludeStale: true},
+			expected: []string{"feature/a", "feature/b", "feature/old"},
+			valid:    true,
+		},
+	}
+
+	for _, c := range cases {
+		trigger := c.trigger
+		selected, err := selectBranches(annotations, &trigger)
+		if (err == nil) != c.valid {
+			t.Errorf("Test Case: %s. selectBranches() returned %v, expected valid: %t", c.info, err, c.valid)
+			continue
+		}
+		if c.valid && !reflect.DeepEqual(selected, c.expected) {
+			t.Errorf("Test Case: %s. selectBranches() == %v, expected %v", c.info, selected, c.expected)
+		}
+	}
+}
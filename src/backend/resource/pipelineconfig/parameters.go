@@ -0,0 +1,292 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ParameterDefinition is a parameter declared by a PipelineConfig or by the Jenkinsfile of a branch
+type ParameterDefinition struct {
+	Name        string `json:"name"`
+	Type        string `json:"type"`
+	Description string `json:"description,omitempty"`
+	// Value is the default value declared by the PipelineConfig
+	Value string `json:"value,omitempty"`
+	// DefaultParameterValue is the default value reported by Jenkins for branches
+	DefaultParameterValue *struct {
+		Value interface{} `json:"value"`
+	} `json:"defaultParameterValue,omitempty"`
+	// Choices are the allowed values of a choice parameter
+	Choices  []string `json:"choices,omitempty"`
+	Required bool     `json:"required,omitempty"`
+}
+
+// ParameterPresets are saved parameter values by preset name
+type ParameterPresets map[string]map[string]string
+
+// ParameterValidationResponse contains parameters resolved for a trigger
+type ParameterValidationResponse struct {
+	Definitions []ParameterDefinition              `json:"definitions"`
+	Params      []devopsv1alpha1.PipelineParameter `json:"params"`
+}
+
+var branchParamsReplacer = regexp.MustCompile("[^0-9a-zA-Z-]")
+
+// defaultValue returns the declared default value of the parameter
+func (d ParameterDefinition) defaultValue() string {
+	if d.DefaultParameterValue != nil && d.DefaultParameterValue.Value != nil {
+		return fmt.Sprintf("%v", d.DefaultParameterValue.Value)
+	}
+	return d.Value
+}
+
+// validate checks the value against the type of the parameter
+func (d ParameterDefinition) validate(value string) error {
+	switch d.Type {
+	case "boolean", "BooleanParameterDefinition":
+		if value != "true" && value != "false" {
+			return fmt.Errorf("parameter %s must be true or false, got %q", d.Name, value)
+		}
+	case "choice", "ChoiceParameterDefinition":
+		// choices are not always declared, e.g. by definitions created in the frontend
+		if len(d.Choices) > 0 && !common.IsInSlice(d.Choices, value) {
+			return fmt.Errorf("parameter %s must be one of %s, got %q", d.Name, strings.Join(d.Choices, ", "), value)
+		}
+	}
+	if d.Required && value == "" {
+		return fmt.Errorf("parameter %s is required", d.Name)
+	}
+	return nil
+}
+
+// getParameterDefinitions returns parameters declared for the branch of a multi-branch PipelineConfig
+// or by the PipelineConfig itself. Branch names are stored in annotations with special characters
+// replaced by '-'.
+func getParameterDefinitions(config *devopsv1alpha1.PipelineConfig, branch string) ([]ParameterDefinition, error) {
+	definitions := make([]ParameterDefinition, 0)
+	if branch != "" {
+		key := fmt.Sprintf("alauda.io/jenkins.%s.params", branchParamsReplacer.ReplaceAllString(branch, "-"))
+		if value, ok := config.Annotations[key]; ok {
+			err := json.Unmarshal([]byte(value), &definitions)
+			return definitions, err
+		}
+	}
+
+	raw, err := json.Marshal(config.Spec.Parameters)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(raw, &definitions)
+	return definitions, err
+}
+
+// getParameterPresets returns presets saved in the PipelineConfig annotations
+func getParameterPresets(config *devopsv1alpha1.PipelineConfig) (ParameterPresets, error) {
+	presets := make(ParameterPresets, 0)
+	if value, ok := config.Annotations[common.AnnotationsKeyParameterPresets]; ok {
+		if err := json.Unmarshal([]byte(value), &presets); err != nil {
+			return nil, err
+		}
+	}
+	return presets, nil
+}
+
+// resolveParameters validates supplied parameters against the definitions and fills values of the
+// preset and defaults for parameters which are not supplied. Parameters are passed through unchanged
+// when nothing is declared, as they cannot be validated.
+func resolveParameters(definitions []ParameterDefinition, preset map[string]string,
+	params []devopsv1alpha1.PipelineParameter) ([]devopsv1alpha1.PipelineParameter, error) {
+	if len(definitions) == 0 {
+		return params, nil
+	}
+
+	declared := make(map[string]ParameterDefinition, len(definitions))
+	for _, d := range definitions {
+		declared[d.Name] = d
+	}
+
+	values := make(map[string]string, 0)
+	errs := make([]string, 0)
+	for name, value := range preset {
+		values[name] = value
+	}
+	for _, p := range params {
+		values[p.Name] = p.Value
+	}
+	for name := range values {
+		if _, ok := declared[name]; !ok {
+			errs = append(errs, fmt.Sprintf("unknown parameter %s", name))
+		}
+	}
+	sort.Strings(errs)
+
+	resolved := make([]map[string]string, 0, len(definitions))
+	for _, d := range definitions {
+		value, ok := values[d.Name]
+		if !ok {
+			value = d.defaultValue()
+		}
+		if err := d.validate(value); err != nil {
+			errs = append(errs, err.Error())
+		}
+		resolved = append(resolved, map[string]string{"name": d.Name, "type": d.Type, "value": value})
+	}
+	if len(errs) > 0 {
+		return nil, k8serrors.NewBadRequest(strings.Join(errs, "; "))
+	}
+
+	raw, err := json.Marshal(resolved)
+	if err != nil {
+		return nil, err
+	}
+	result := make([]devopsv1alpha1.PipelineParameter, 0)
+	err = json.Unmarshal(raw, &result)
+	return result, err
+}
+
+// mergeParameters returns the supplied parameters followed by values of the preset for parameters which are
+// not supplied. Nothing is validated or defaulted, parameters are passed through as they are.
+func mergeParameters(preset map[string]string, params []devopsv1alpha1.PipelineParameter) []devopsv1alpha1.PipelineParameter {
+	result := make([]devopsv1alpha1.PipelineParameter, 0, len(params)+len(preset))
+	supplied := make(map[string]bool, len(params))
+	for _, p := range params {
+		supplied[p.Name] = true
+		result = append(result, p)
+	}
+
+	names := make([]string, 0, len(preset))
+	for name := range preset {
+		if !supplied[name] {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		result = append(result, devopsv1alpha1.PipelineParameter{Name: name, Value: preset[name]})
+	}
+	return result
+}
+
+// getTriggerParameters resolves parameters of the trigger for the PipelineConfig. Invalid parameters are
+// rejected with the error of every parameter. Without validate the parameters are merged with the preset
+// and passed through, problems are only logged.
+func getTriggerParameters(config *devopsv1alpha1.PipelineConfig, branch, presetName string,
+	params []devopsv1alpha1.PipelineParameter, validate bool) ([]ParameterDefinition, []devopsv1alpha1.PipelineParameter, error) {
+	definitions, err := getParameterDefinitions(config, branch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var preset map[string]string
+	if presetName != "" {
+		presets, err := getParameterPresets(config)
+		if err != nil {
+			return nil, nil, err
+		}
+		var ok bool
+		if preset, ok = presets[presetName]; !ok {
+			return nil, nil, k8serrors.NewBadRequest(fmt.Sprintf("parameter preset %s not found", presetName))
+		}
+	}
+
+	if !validate {
+		if _, err := resolveParameters(definitions, preset, params); err != nil {
+			log.Printf("Triggering PipelineConfig %s/%s with unvalidated parameters: %v", config.Namespace, config.Name, err)
+		}
+		return definitions, mergeParameters(preset, params), nil
+	}
+	params, err = resolveParameters(definitions, preset, params)
+	return definitions, params, err
+}
+
+// ValidateTriggerParameters resolves parameters of the trigger without triggering the PipelineConfig
+func ValidateTriggerParameters(client devopsclient.Interface, spec *PipelineConfigTrigger) (*ParameterValidationResponse, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(spec.Namespace).Get(spec.Name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+
+	definitions, params, err := getTriggerParameters(config, strings.TrimSpace(spec.Branch), spec.Preset, spec.Params, true)
+	if err != nil {
+		return nil, err
+	}
+	return &ParameterValidationResponse{Definitions: definitions, Params: params}, nil
+}
+
+// GetParameterPresets returns parameter presets of the PipelineConfig
+func GetParameterPresets(client devopsclient.Interface, namespace, name string) (ParameterPresets, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	return getParameterPresets(config)
+}
+
+// SaveParameterPreset creates or replaces a parameter preset. Values are validated against parameters
+// declared by the PipelineConfig.
+func SaveParameterPreset(client devopsclient.Interface, namespace, name, preset string, values map[string]string) (ParameterPresets, error) {
+	return updateParameterPresets(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, presets ParameterPresets) error {
+		definitions, err := getParameterDefinitions(config, "")
+		if err != nil {
+			return err
+		}
+		if _, err := resolveParameters(definitions, values, nil); err != nil {
+			return err
+		}
+		presets[preset] = values
+		return nil
+	})
+}
+
+// DeleteParameterPreset deletes a parameter preset
+func DeleteParameterPreset(client devopsclient.Interface, namespace, name, preset string) (ParameterPresets, error) {
+	return updateParameterPresets(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, presets ParameterPresets) error {
+		if _, ok := presets[preset]; !ok {
+			return k8serrors.NewNotFound(schema.GroupResource{Resource: "parameterpresets"}, preset)
+		}
+		delete(presets, preset)
+		return nil
+	})
+}
+
+func updateParameterPresets(client devopsclient.Interface, namespace, name string,
+	update func(config *devopsv1alpha1.PipelineConfig, presets ParameterPresets) error) (ParameterPresets, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	config = config.DeepCopy()
+
+	presets, err := getParameterPresets(config)
+	if err != nil {
+		return nil, err
+	}
+	if err := update(config, presets); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(presets)
+	if err != nil {
+		return nil, err
+	}
+	if config.Annotations == nil {
+		config.Annotations = make(map[string]string, 0)
+	}
+	config.Annotations[common.AnnotationsKeyParameterPresets] = string(raw)
+	if _, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Update(config); err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
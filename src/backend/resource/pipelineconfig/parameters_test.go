@@ -0,0 +1,189 @@
+package pipelineconfig
+
+import (
+	"reflect"
+	"testing"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestParameterDefinitionValidate(t *testing.T) {
+	cases := []struct {
+		info       string
+		definition ParameterDefinition
+		value      string
+		valid      bool
+	}{
+		{"boolean true", ParameterDefinition{Name: "a", Type: "boolean"}, "true", true},
+		{"jenkins boolean false", ParameterDefinition{Name: "a", Type: "BooleanParameterDefinition"}, "false", true},
+		{"invalid boolean", ParameterDefinition{Name: "a", Type: "boolean"}, "yes", false},
+		{"declared choice", ParameterDefinition{Name: "a", Type: "choice", Choices: []string{"x", "y"}}, "y", true},
+		{"unknown choice", ParameterDefinition{Name: "a", Type: "ChoiceParameterDefinition", Choices: []string{"x"}}, "z", false},
+		{"choice without choices", ParameterDefinition{Name: "a", Type: "choice"}, "z", true},
+		{"missing required", ParameterDefinition{Name: "a", Type: "string", Required: true}, "", false},
+		{"optional string", ParameterDefinition{Name: "a", Type: "string"}, "", true},
+	}
+
+	for _, c := range cases {
+		err := c.definition.validate(c.value)
+		if (err == nil) != c.valid {
+			t.Errorf("Test Case: %s. validate(%q) returned %v, expected valid: %t", c.info, c.value, err, c.valid)
+		}
+	}
+}
+
+func TestResolveParameters(t *testing.T) {
+	definitions := []ParameterDefinition{
+		{Name: "env", Type: "choice", Choices: []string{"dev", "prod"}, Value: "dev"},
+		{Name: "debug", Type: "boolean", DefaultParameterValue: &struct {
+			Value interface{} `json:"value"`
+		}{Value: false}},
+		{Name: "tag", Type: "string"},
+	}
+
+	cases := []struct {
+		info        string
+		definitions []ParameterDefinition
+		preset      map[string]string
+		params      []devopsv1alpha1.PipelineParameter
+		expected    map[string]string
+		valid       bool
+	}{
+		{
+			info:        "defaults",
+			definitions: definitions,
+			expected:    map[string]string{"env": "dev", "debug": "false", "tag": ""},
+			valid:       true,
+		},
+		{
+			info:        "params override preset",
+			definitions: definitions,
+			preset:      map[string]string{"env": "prod", "tag": "v1"},
+			params:      []devopsv1alpha1.PipelineParameter{{Name: "tag", Value: "v2"}},
+			expected:    map[string]string{"env": "prod", "debug": "false", "tag": "v2"},
+			valid:       true,
+		},
+		{
+			info:        "unknown parameter",
+			definitions: definitions,
+			params:      []devopsv1alpha1.PipelineParameter{{Name: "other", Value: "x"}},
+			valid:       false,
+		},
+		{
+			info:        "invalid value",
+			definitions: definitions,
+			params:      []devopsv1alpha1.PipelineParameter{{Name: "debug", Value: "maybe"}},
+			valid:       false,
+		},
+		{
+			info:     "nothing declared",
+			params:   []devopsv1alpha1.PipelineParameter{{Name: "other", Value: "x"}},
+			expected: map[string]string{"other": "x"},
+			valid:    true,
+		},
+	}
+
+	for _, c := range cases {
+		result, err := resolveParameters(c.definitions, c.preset, c.params)
+		if (err == nil) != c.valid {
+			t.Errorf("Test Case: %s. resolveParameters() returned %v, expected valid: %t", c.info, err, c.valid)
+			continue
+		}
+		if !c.valid {
+			continue
+		}
+		actual := make(map[string]string, len(result))
+		for _, p := range result {
+			actual[p.Name] = p.Value
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Test Case: %s. resolveParameters() == %v, expected %v", c.info, actual, c.expected)
+		}
+	}
+}
+
+func TestMergeParameters(t *testing.T) {
+	preset := map[string]string{"b": "preset", "a": "preset"}
+	params := []devopsv1alpha1.PipelineParameter{{Name: "b", Value: "param"}, {Name: "unknown", Value: "x"}}
+
+	result := mergeParameters(preset, params)
+	expected := []devopsv1alpha1.PipelineParameter{
+		{Name: "b", Value: "param"}, {Name: "unknown", Value: "x"}, {Name: "a", Value: "preset"},
+	}
+	if !reflect.DeepEqual(result, expected) {
+		t.Errorf("mergeParameters() == %v, expected %v", result, expected)
+	}
+}
+
+func TestGetTriggerParameters(t *testing.T) {
+	config := &devopsv1alpha1.PipelineConfig{}
+	config.Namespace, config.Name = "default", "build"
+	config.Annotations = map[string]string{
+		"alauda.io/jenkins.feature-a.params":  `[{"name":"debug","type":"boolean","value":"false"}]`,
+		common.AnnotationsKeyParameterPresets: `{"verbose":{"debug":"true"}}`,
+	}
+
+	cases := []struct {
+		info     string
+		preset   string
+		params   []devopsv1alpha1.PipelineParameter
+		validate bool
+		expected map[string]string
+		valid    bool
+	}{
+		{
+			info:     "defaults filled",
+			validate: true,
+			expected: map[string]string{"debug": "false"},
+			valid:    true,
+		},
+		{
+			info:     "preset",
+			preset:   "verbose",
+			validate: true,
+			expected: map[string]string{"debug": "true"},
+			valid:    true,
+		},
+		{
+			info:     "invalid parameter rejected",
+			params:   []devopsv1alpha1.PipelineParameter{{Name: "debug", Value: "maybe"}},
+			validate: true,
+			valid:    false,
+		},
+		{
+			info:     "unknown preset",
+			preset:   "missing",
+			validate: true,
+			valid:    false,
+		},
+		{
+			info:     "validation skipped",
+			params:   []devopsv1alpha1.PipelineParameter{{Name: "debug", Value: "maybe"}},
+			expected: map[string]string{"debug": "maybe"},
+			valid:    true,
+		},
+	}
+
+	for _, c := range cases {
+		_, params, err := getTriggerParameters(config, "feature/a", c.preset, c.params, c.validate)
+		if (err == nil) != c.valid {
+			t.Errorf("Test Case: %s. getTriggerParameters() returned %v, expected valid: %t", c.info, err, c.valid)
+			continue
+		}
+		if !c.valid {
+			if !k8serrors.IsBadRequest(err) {
+				t.Errorf("Test Case: %s. getTriggerParameters() returned %v, expected bad request", c.info, err)
+			}
+			continue
+		}
+		actual := make(map[string]string, len(params))
+		for _, p := range params {
+			actual[p.Name] = p.Value
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("Test Case: %s. getTriggerParameters() == %v, expected %v", c.info, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,357 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	"github.com/ghodss/yaml"
+	v1 "k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+const (
+	// MaxPipelineConfigRevisions is the number of revisions kept for a PipelineConfig, older ones are removed
+	MaxPipelineConfigRevisions = 20
+	// revisionDiffContext is the number of unchanged lines around changes in diffs of revisions
+	revisionDiffContext = 3
+)
+
+// PipelineConfigRevision is the spec of a PipelineConfig recorded by an update
+type PipelineConfigRevision struct {
+	Revision  int       `json:"revision"`
+	Author    string    `json:"author"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Description tells why the revision was recorded, e.g. a rollback
+	Description string `json:"description"`
+	// Spec is omitted in revision lists
+	Spec *devopsv1alpha1.PipelineConfigSpec `json:"spec,omitempty"`
+}
+
+// PipelineConfigRevisionList contains revisions of a PipelineConfig, the newest first
+type PipelineConfigRevisionList struct {
+	ListMeta api.ListMeta `json:"listMeta"`
+	// Current is the revision of the current spec, 0 when no revision was recorded
+	Current int                      `json:"current"`
+	Items   []PipelineConfigRevision `json:"items"`
+}
+
+// PipelineConfigRevisionDiff contains unified diffs between two revisions of a PipelineConfig
+type PipelineConfigRevisionDiff struct {
+	From int `json:"from"`
+	To   int `json:"to"`
+	// Spec is the diff of the specs in YAML, empty when they are equal
+	Spec string `json:"spec"`
+	// Jenkinsfile is the diff of the rendered Jenkinsfiles, empty when they are equal
+	Jenkinsfile string `json:"jenkinsfile"`
+	// JenkinsfileError is set when a Jenkinsfile could not be rendered
+	JenkinsfileError string `json:"jenkinsfileError,omitempty"`
+}
+
+// revisionConfigMapName returns the name of the ConfigMap holding revisions of the PipelineConfig. The prefix
+// keeps it apart from ConfigMaps created by users for other purposes.
+func revisionConfigMapName(name string) string {
+	return "pipelineconfig-revisions." + name
+}
+
+// isRevisionConfigMap returns true when the ConfigMap holds revisions of the PipelineConfig. The owner is
+// only checked when uid is not empty.
+func isRevisionConfigMap(configMap *v1.ConfigMap, name string, uid types.UID) bool {
+	if configMap.Labels[common.LabelPipelineConfigRevisions] != name {
+		return false
+	}
+	if uid == "" {
+		return true
+	}
+	for _, owner := range configMap.OwnerReferences {
+		if owner.Kind == "PipelineConfig" && owner.UID == uid {
+			return true
+		}
+	}
+	return false
+}
+
+// getRevisionConfigMap returns the ConfigMap holding revisions of the PipelineConfig. A ConfigMap with the
+// same name which does not hold them is reported as not found.
+func getRevisionConfigMap(k8sclient kubernetes.Interface, namespace, name string) (*v1.ConfigMap, error) {
+	configMap, err := k8sclient.CoreV1().ConfigMaps(namespace).Get(revisionConfigMapName(name), api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	if !isRevisionConfigMap(configMap, name, "") {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "revisions"}, name)
+	}
+	return configMap, nil
+}
+
+// getRevisions returns revisions saved in the ConfigMap ordered by revision
+func getRevisions(configMap *v1.ConfigMap) ([]PipelineConfigRevision, error) {
+	revisions := make([]PipelineConfigRevision, 0, len(configMap.Data))
+	for key, value := range configMap.Data {
+		revision := PipelineConfigRevision{}
+		if err := json.Unmarshal([]byte(value), &revision); err != nil {
+			return nil, fmt.Errorf("invalid revision %s in configmap %s: %v", key, configMap.Name, err)
+		}
+		revisions = append(revisions, revision)
+	}
+	sort.Slice(revisions, func(i, j int) bool {
+		return revisions[i].Revision < revisions[j].Revision
+	})
+	return revisions, nil
+}
+
+// getRevision returns a revision of the PipelineConfig with its spec
+func getRevision(k8sclient kubernetes.Interface, namespace, name string, revision int) (*PipelineConfigRevision, error) {
+	configMap, err := getRevisionConfigMap(k8sclient, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := configMap.Data[strconv.Itoa(revision)]
+	if !ok {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Resource: "revisions"}, strconv.Itoa(revision))
+	}
+	result := new(PipelineConfigRevision)
+	if err := json.Unmarshal([]byte(value), result); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// GetRevisions returns revisions of the PipelineConfig without their specs
+func GetRevisions(k8sclient kubernetes.Interface, namespace, name string) (*PipelineConfigRevisionList, error) {
+	result := &PipelineConfigRevisionList{Items: make([]PipelineConfigRevision, 0)}
+	configMap, err := getRevisionConfigMap(k8sclient, namespace, name)
+	if k8serrors.IsNotFound(err) {
+		return result, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	revisions, err := getRevisions(configMap)
+	if err != nil {
+		return nil, err
+	}
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		revision.Spec = nil
+		result.Items = append(result.Items, revision)
+	}
+	if len(revisions) > 0 {
+		result.Current = revisions[len(revisions)-1].Revision
+	}
+	result.ListMeta = api.ListMeta{TotalItems: len(result.Items)}
+	return result, nil
+}
+
+// GetRevision returns a revision of the PipelineConfig with its spec
+func GetRevision(k8sclient kubernetes.Interface, namespace, name string, revision int) (*PipelineConfigRevision, error) {
+	return getRevision(k8sclient, namespace, name, revision)
+}
+
+// DiffRevisions returns diffs of the specs and the rendered Jenkinsfiles of two revisions
+func DiffRevisions(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace, name string, from, to int) (*PipelineConfigRevisionDiff, error) {
+	fromRevision, err := getRevision(k8sclient, namespace, name, from)
+	if err != nil {
+		return nil, err
+	}
+	toRevision, err := getRevision(k8sclient, namespace, name, to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromSpec, err := yaml.Marshal(fromRevision.Spec)
+	if err != nil {
+		return nil, err
+	}
+	toSpec, err := yaml.Marshal(toRevision.Spec)
+	if err != nil {
+		return nil, err
+	}
+	result := &PipelineConfigRevisionDiff{
+		From: from,
+		To:   to,
+		Spec: common.UnifiedDiff(revisionTitle(fromRevision), revisionTitle(toRevision), string(fromSpec), string(toSpec), revisionDiffContext),
+	}
+
+	fromJenkinsfile, err := renderRevision(client, namespace, name, fromRevision)
+	if err != nil {
+		result.JenkinsfileError = err.Error()
+		return result, nil
+	}
+	toJenkinsfile, err := renderRevision(client, namespace, name, toRevision)
+	if err != nil {
+		result.JenkinsfileError = err.Error()
+		return result, nil
+	}
+	result.Jenkinsfile = common.UnifiedDiff(revisionTitle(fromRevision), revisionTitle(toRevision), fromJenkinsfile, toJenkinsfile, revisionDiffContext)
+	return result, nil
+}
+
+func revisionTitle(revision *PipelineConfigRevision) string {
+	return fmt.Sprintf("revision %d", revision.Revision)
+}
+
+func renderRevision(client devopsclient.Interface, namespace, name string, revision *PipelineConfigRevision) (string, error) {
+	spec := &PipelineConfigDetail{}
+	spec.ObjectMeta.Name = name
+	spec.Spec = *revision.Spec
+	return RenderJenkinsfile(client, namespace, spec)
+}
+
+// RollbackPipelineConfig restores the spec of a revision and records it as a new revision
+func RollbackPipelineConfig(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace, name string, revision int,
+	author string) (*PipelineConfigRevisionList, error) {
+	target, err := getRevision(k8sclient, namespace, name, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	config = config.DeepCopy()
+	previous := config.Spec
+	config.Spec = *target.Spec
+	// set phase as initial
+	config.Status.Phase = devopsv1alpha1.PipelineConfigPhaseCreating
+	if config, err = client.DevopsV1alpha1().PipelineConfigs(namespace).Update(config); err != nil {
+		return nil, err
+	}
+
+	if err := recordRevision(k8sclient, config, previous, author, fmt.Sprintf("rollback to revision %d", revision)); err != nil {
+		return nil, err
+	}
+	return GetRevisions(k8sclient, namespace, name)
+}
+
+// recordRevision saves the spec of the updated PipelineConfig as a new revision. The previous spec is
+// saved first when the PipelineConfig has no revisions yet, so that the first update can be rolled back.
+func recordRevision(k8sclient kubernetes.Interface, config *devopsv1alpha1.PipelineConfig, previous devopsv1alpha1.PipelineConfigSpec,
+	author, description string) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMaps := k8sclient.CoreV1().ConfigMaps(config.Namespace)
+		configMap, err := configMaps.Get(revisionConfigMapName(config.Name), metaV1.GetOptions{})
+		create := k8serrors.IsNotFound(err)
+		if create {
+			configMap = newRevisionConfigMap(config)
+		} else if err != nil {
+			return err
+		} else if !isRevisionConfigMap(configMap, config.Name, config.UID) {
+			// never overwrite a ConfigMap which does not belong to this PipelineConfig
+			return k8serrors.NewAlreadyExists(schema.GroupResource{Resource: "configmaps"}, configMap.Name)
+		}
+
+		revisions, err := getRevisions(configMap)
+		if err != nil {
+			return err
+		}
+		if len(revisions) == 0 {
+			revisions = append(revisions, PipelineConfigRevision{
+				Revision:    1,
+				CreatedAt:   config.CreationTimestamp.Time,
+				Description: "initial revision",
+				Spec:        &previous,
+			})
+		}
+		latest := revisions[len(revisions)-1]
+		if equalSpecs(latest.Spec, &config.Spec) {
+			if create {
+				return createRevisionConfigMap(k8sclient, configMap, revisions)
+			}
+			return nil
+		}
+		spec := config.Spec
+		revisions = append(revisions, PipelineConfigRevision{
+			Revision:    latest.Revision + 1,
+			Author:      author,
+			CreatedAt:   time.Now(),
+			Description: description,
+			Spec:        &spec,
+		})
+		if len(revisions) > MaxPipelineConfigRevisions {
+			revisions = revisions[len(revisions)-MaxPipelineConfigRevisions:]
+		}
+
+		if create {
+			return createRevisionConfigMap(k8sclient, configMap, revisions)
+		}
+		if err := setRevisions(configMap, revisions); err != nil {
+			return err
+		}
+		_, err = configMaps.Update(configMap)
+		return err
+	})
+}
+
+func createRevisionConfigMap(k8sclient kubernetes.Interface, configMap *v1.ConfigMap, revisions []PipelineConfigRevision) error {
+	if err := setRevisions(configMap, revisions); err != nil {
+		return err
+	}
+	_, err := k8sclient.CoreV1().ConfigMaps(configMap.Namespace).Create(configMap)
+	if k8serrors.IsAlreadyExists(err) {
+		// created concurrently, retry with an update
+		return k8serrors.NewConflict(schema.GroupResource{Resource: "configmaps"}, configMap.Name, err)
+	}
+	return err
+}
+
+// newRevisionConfigMap returns the ConfigMap holding revisions of the PipelineConfig, it is garbage
+// collected with the PipelineConfig
+func newRevisionConfigMap(config *devopsv1alpha1.PipelineConfig) *v1.ConfigMap {
+	return &v1.ConfigMap{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      revisionConfigMapName(config.Name),
+			Namespace: config.Namespace,
+			Labels: map[string]string{
+				common.LabelPipelineConfigRevisions: config.Name,
+			},
+			OwnerReferences: []metaV1.OwnerReference{
+				{
+					APIVersion: devopsv1alpha1.SchemeGroupVersion.String(),
+					Kind:       "PipelineConfig",
+					Name:       config.Name,
+					UID:        config.UID,
+				},
+			},
+		},
+	}
+}
+
+func setRevisions(configMap *v1.ConfigMap, revisions []PipelineConfigRevision) error {
+	configMap.Data = make(map[string]string, len(revisions))
+	for _, revision := range revisions {
+		raw, err := json.Marshal(revision)
+		if err != nil {
+			return err
+		}
+		configMap.Data[strconv.Itoa(revision.Revision)] = string(raw)
+	}
+	return nil
+}
+
+func equalSpecs(left, right *devopsv1alpha1.PipelineConfigSpec) bool {
+	leftRaw, leftErr := json.Marshal(left)
+	rightRaw, rightErr := json.Marshal(right)
+	return leftErr == nil && rightErr == nil && string(leftRaw) == string(rightRaw)
+}
+
+// logRecordRevision records a revision and only logs failures, the PipelineConfig is already updated
+func logRecordRevision(k8sclient kubernetes.Interface, config *devopsv1alpha1.PipelineConfig, previous devopsv1alpha1.PipelineConfigSpec, author string) {
+	if err := recordRevision(k8sclient, config, previous, author, ""); err != nil {
+		log.Printf("Failed to record revision of pipeline config %s/%s: %v", config.Namespace, config.Name, err)
+	}
+}
@@ -0,0 +1,39 @@
+package pipelineconfig
+
+import (
+	"testing"
+
+	"alauda.io/diablo/src/backend/resource/common"
+	v1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestIsRevisionConfigMap(t *testing.T) {
+	owned := &v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{
+		Name:            revisionConfigMapName("app"),
+		Labels:          map[string]string{common.LabelPipelineConfigRevisions: "app"},
+		OwnerReferences: []metaV1.OwnerReference{{Kind: "PipelineConfig", Name: "app", UID: "uid-1"}},
+	}}
+	foreign := &v1.ConfigMap{ObjectMeta: metaV1.ObjectMeta{Name: revisionConfigMapName("app")}}
+
+	cases := []struct {
+		info      string
+		configMap *v1.ConfigMap
+		name      string
+		uid       string
+		expected  bool
+	}{
+		{"owned by the pipeline config", owned, "app", "uid-1", true},
+		{"owner not checked", owned, "app", "", true},
+		{"owned by a deleted pipeline config", owned, "app", "uid-2", false},
+		{"labeled for another pipeline config", owned, "other", "", false},
+		{"created by a user", foreign, "app", "", false},
+	}
+
+	for _, c := range cases {
+		if actual := isRevisionConfigMap(c.configMap, c.name, types.UID(c.uid)); actual != c.expected {
+			t.Errorf("Test Case: %s. isRevisionConfigMap() == %t, expected %t", c.info, actual, c.expected)
+		}
+	}
+}
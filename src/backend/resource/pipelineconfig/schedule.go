@@ -0,0 +1,307 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// DefaultScheduleFireTimes is the number of next fire times returned for a schedule when not specified
+	DefaultScheduleFireTimes = 5
+	// MaxScheduleFireTimes is the maximum number of next fire times returned for a schedule
+	MaxScheduleFireTimes = 100
+)
+
+// PipelineSchedule triggers a PipelineConfig on a cron schedule managed by the dashboard backend
+type PipelineSchedule struct {
+	Name string `json:"name"`
+	// Cron is a cron expression with five fields or one of @hourly, @daily, @weekly, @monthly, @yearly
+	Cron string `json:"cron"`
+	// Timezone of the cron expression, e.g. Asia/Shanghai. Defaults to UTC
+	Timezone string `json:"timezone"`
+	// Branch is required for multi-branch PipelineConfigs
+	Branch string                             `json:"branch"`
+	Params []devopsv1alpha1.PipelineParameter `json:"params"`
+	Preset string                             `json:"preset"`
+	Paused bool                               `json:"paused"`
+
+	// ActiveSince is the time the schedule was saved or resumed, fire times before it are skipped
+	ActiveSince *time.Time `json:"activeSince,omitempty"`
+	// LastScheduledAt is the time the schedule was last triggered by the scheduler
+	LastScheduledAt *time.Time `json:"lastScheduledAt,omitempty"`
+	// LastPipeline is the name of the pipeline created by the last trigger
+	LastPipeline string `json:"lastPipeline,omitempty"`
+	// LastError is the error of the last trigger, empty when it succeeded
+	LastError string `json:"lastError,omitempty"`
+
+	// Owner is the user who last saved, paused or resumed the schedule, the scheduler triggers the
+	// PipelineConfig on behalf of this user
+	Owner *ScheduleOwner `json:"owner,omitempty"`
+	// Signature proves that the schedule was saved through the API. The scheduler does not trigger
+	// schedules without a valid signature, e.g. ones written to the annotations directly.
+	Signature string `json:"signature,omitempty"`
+}
+
+// ScheduleOwner is a user on whose behalf a schedule triggers the PipelineConfig
+type ScheduleOwner struct {
+	User   string   `json:"user"`
+	Groups []string `json:"groups,omitempty"`
+}
+
+// PipelineScheduleDetail is a schedule with its next fire times
+type PipelineScheduleDetail struct {
+	PipelineSchedule
+	// NextFireTimes is empty when the schedule is paused
+	NextFireTimes []time.Time `json:"nextFireTimes"`
+}
+
+// nextFireTime returns the first fire time after the schedule was last triggered or activated
+func (s PipelineSchedule) nextFireTime() (time.Time, error) {
+	cron, err := common.ParseCron(s.Cron, s.Timezone)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var since time.Time
+	if s.ActiveSince != nil {
+		since = *s.ActiveSince
+	}
+	if s.LastScheduledAt != nil && s.LastScheduledAt.After(since) {
+		since = *s.LastScheduledAt
+	}
+	// schedules written without the API are active from now on
+	if since.IsZero() {
+		since = time.Now()
+	}
+	return cron.Next(since), nil
+}
+
+// getSchedules returns schedules saved in the PipelineConfig annotations
+func getSchedules(config *devopsv1alpha1.PipelineConfig) ([]PipelineSchedule, error) {
+	schedules := make([]PipelineSchedule, 0)
+	if value, ok := config.Annotations[common.AnnotationsKeySchedules]; ok {
+		if err := json.Unmarshal([]byte(value), &schedules); err != nil {
+			return nil, err
+		}
+	}
+	return schedules, nil
+}
+
+func findSchedule(schedules []PipelineSchedule, name string) (int, error) {
+	for i, s := range schedules {
+		if s.Name == name {
+			return i, nil
+		}
+	}
+	return -1, k8serrors.NewNotFound(schema.GroupResource{Resource: "schedules"}, name)
+}
+
+// GetSchedules returns schedules of the PipelineConfig with count next fire times of every schedule, at most
+// MaxScheduleFireTimes
+func GetSchedules(client devopsclient.Interface, namespace, name string, count int) ([]PipelineScheduleDetail, error) {
+	if count < 0 || count > MaxScheduleFireTimes {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("count of next fire times must be between 0 and %d, got %d",
+			MaxScheduleFireTimes, count))
+	}
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := getSchedules(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return scheduleDetails(schedules, count), nil
+}
+
+// scheduleDetails returns schedules with count next fire times of every schedule
+func scheduleDetails(schedules []PipelineSchedule, count int) []PipelineScheduleDetail {
+	now := time.Now()
+	result := make([]PipelineScheduleDetail, 0, len(schedules))
+	for _, s := range schedules {
+		detail := PipelineScheduleDetail{PipelineSchedule: s, NextFireTimes: make([]time.Time, 0)}
+		if cron, err := common.ParseCron(s.Cron, s.Timezone); err == nil && !s.Paused {
+			detail.NextFireTimes = cron.NextN(now, count)
+		}
+		result = append(result, detail)
+	}
+	return result
+}
+
+// SaveSchedule creates or replaces a schedule of the PipelineConfig owned by the user. The cron expression,
+// timezone and parameters are validated before saving.
+func SaveSchedule(client devopsclient.Interface, namespace, name string, schedule *PipelineSchedule,
+	owner *ScheduleOwner) (*PipelineSchedule, error) {
+	schedule.Name = strings.TrimSpace(schedule.Name)
+	if schedule.Name == "" {
+		return nil, k8serrors.NewBadRequest("schedule name is required")
+	}
+	if _, err := common.ParseCron(schedule.Cron, schedule.Timezone); err != nil {
+		return nil, k8serrors.NewBadRequest(err.Error())
+	}
+
+	now := time.Now()
+	schedule.ActiveSince = &now
+	schedule.LastScheduledAt = nil
+	schedule.LastPipeline = ""
+	schedule.LastError = ""
+	schedule.Owner = owner
+	schedule.Signature = signSchedule(namespace, name, *schedule)
+	_, err := updateSchedules(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error) {
+		if isMultiBranch(config) && strings.TrimSpace(schedule.Branch) == "" {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("schedule of multi-branch pipeline config %s needs branch name", name))
+		}
+		if _, _, err := getTriggerParameters(config, strings.TrimSpace(schedule.Branch), schedule.Preset, schedule.Params, true); err != nil {
+			return nil, err
+		}
+
+		if i, err := findSchedule(schedules, schedule.Name); err == nil {
+			schedules[i] = *schedule
+			return schedules, nil
+		}
+		return append(schedules, *schedule), nil
+	})
+	return schedule, err
+}
+
+// DeleteSchedule deletes a schedule of the PipelineConfig and returns the remaining schedules
+func DeleteSchedule(client devopsclient.Interface, namespace, name, schedule string) ([]PipelineScheduleDetail, error) {
+	schedules, err := updateSchedules(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error) {
+		i, err := findSchedule(schedules, schedule)
+		if err != nil {
+			return nil, err
+		}
+		return append(schedules[:i], schedules[i+1:]...), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scheduleDetails(schedules, DefaultScheduleFireTimes), nil
+}
+
+// SetSchedulePaused pauses or resumes a schedule, which is then owned by the user, and returns the
+// schedules. Fire times missed while paused are skipped.
+func SetSchedulePaused(client devopsclient.Interface, namespace, name, schedule string, paused bool,
+	owner *ScheduleOwner) ([]PipelineScheduleDetail, error) {
+	schedules, err := updateSchedules(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error) {
+		i, err := findSchedule(schedules, schedule)
+		if err != nil {
+			return nil, err
+		}
+		if schedules[i].Paused && !paused {
+			now := time.Now()
+			schedules[i].ActiveSince = &now
+		}
+		schedules[i].Paused = paused
+		schedules[i].Owner = owner
+		schedules[i].Signature = signSchedule(namespace, name, schedules[i])
+		return schedules, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return scheduleDetails(schedules, DefaultScheduleFireTimes), nil
+}
+
+// RunSchedule triggers the PipelineConfig with branch and parameters of the schedule immediately. It
+// does not change the next fire times of the schedule.
+func RunSchedule(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace, name, schedule string) (*PipelineTriggerResponse, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	schedules, err := getSchedules(config)
+	if err != nil {
+		return nil, err
+	}
+	i, err := findSchedule(schedules, schedule)
+	if err != nil {
+		return nil, err
+	}
+
+	return triggerSchedule(client, k8sclient, namespace, name, schedules[i])
+}
+
+// triggerSchedule triggers the PipelineConfig and records the result in the schedule
+func triggerSchedule(client devopsclient.Interface, k8sclient kubernetes.Interface, namespace, name string,
+	schedule PipelineSchedule) (*PipelineTriggerResponse, error) {
+	response, err := TriggerPipelineConfig(client, k8sclient, &PipelineConfigTrigger{
+		Namespace: namespace,
+		Name:      name,
+		Branch:    schedule.Branch,
+		Params:    schedule.Params,
+		Preset:    schedule.Preset,
+	})
+	recordScheduleTrigger(client, namespace, name, schedule.Name, response, err)
+	return response, err
+}
+
+// recordScheduleTrigger records the result of a trigger in the schedule
+func recordScheduleTrigger(client devopsclient.Interface, namespace, name, schedule string,
+	response *PipelineTriggerResponse, err error) {
+	_, updateErr := updateSchedules(client, namespace, name, func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error) {
+		i, findErr := findSchedule(schedules, schedule)
+		if findErr != nil {
+			return nil, findErr
+		}
+		schedules[i].LastError = ""
+		if err != nil {
+			schedules[i].LastError = err.Error()
+		} else if response.Pipeline != nil {
+			schedules[i].LastPipeline = response.Pipeline.GetName()
+		}
+		return schedules, nil
+	})
+	if updateErr != nil {
+		log.Printf("Failed to record trigger of schedule %s of pipeline config %s/%s: %v", schedule, namespace, name, updateErr)
+	}
+}
+
+// updateSchedules saves schedules changed by update in the PipelineConfig annotations and returns them.
+// The update fails with a conflict when the PipelineConfig was changed in the meantime.
+func updateSchedules(client devopsclient.Interface, namespace, name string,
+	update func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error)) ([]PipelineSchedule, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, metaV1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	config = config.DeepCopy()
+
+	schedules, err := getSchedules(config)
+	if err != nil {
+		return nil, err
+	}
+	if schedules, err = update(config, schedules); err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(schedules)
+	if err != nil {
+		return nil, err
+	}
+	if config.Annotations == nil {
+		config.Annotations = make(map[string]string, 0)
+	}
+	config.Annotations[common.AnnotationsKeySchedules] = string(raw)
+	if _, err = client.DevopsV1alpha1().PipelineConfigs(namespace).Update(config); err != nil {
+		return nil, err
+	}
+	return schedules, nil
+}
+
+func isMultiBranch(config *devopsv1alpha1.PipelineConfig) bool {
+	return config.Labels != nil && config.Labels[devopsv1alpha1.LabelPipelineKind] == devopsv1alpha1.LabelPipelineKindMultiBranch
+}
@@ -0,0 +1,16 @@
+package pipelineconfig
+
+import (
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestGetSchedulesCountLimit(t *testing.T) {
+	// the count is checked before the pipeline config is read
+	for _, count := range []int{-1, MaxScheduleFireTimes + 1} {
+		if _, err := GetSchedules(nil, "default", "build", count); !k8serrors.IsBadRequest(err) {
+			t.Errorf("GetSchedules() with count %d returned %v, expected bad request", count, err)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package pipelineconfig
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"sync"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	"k8s.io/api/core/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Secret holding the key which signs schedules saved through the API. It is shared by all replicas.
+const (
+	ScheduleKeyHolderName      = "diablo-pipeline-schedule-key"
+	ScheduleKeyHolderNamespace = "kube-system"
+
+	scheduleKeyEntry = "key"
+	scheduleKeySize  = 32
+)
+
+var scheduleKey struct {
+	sync.RWMutex
+	key []byte
+}
+
+// LoadScheduleKey reads the key signing schedules from its secret and creates the secret with a random key
+// when it does not exist yet. Schedules are not signed, and so never triggered by the scheduler, until the
+// key is loaded.
+func LoadScheduleKey(client kubernetes.Interface) error {
+	secrets := client.CoreV1().Secrets(ScheduleKeyHolderNamespace)
+	secret, err := secrets.Get(ScheduleKeyHolderName, metaV1.GetOptions{})
+	if k8serrors.IsNotFound(err) {
+		key := make([]byte, scheduleKeySize)
+		if _, err := rand.Read(key); err != nil {
+			return err
+		}
+		secret, err = secrets.Create(&v1.Secret{
+			ObjectMeta: metaV1.ObjectMeta{Namespace: ScheduleKeyHolderNamespace, Name: ScheduleKeyHolderName},
+			Data:       map[string][]byte{scheduleKeyEntry: key},
+		})
+		// another replica created the key in the meantime
+		if k8serrors.IsAlreadyExists(err) {
+			secret, err = secrets.Get(ScheduleKeyHolderName, metaV1.GetOptions{})
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	scheduleKey.Lock()
+	defer scheduleKey.Unlock()
+	scheduleKey.key = secret.Data[scheduleKeyEntry]
+	return nil
+}
+
+// signedSchedule contains fields of a schedule which can only be changed through the API. Fields updated by
+// the scheduler are not signed.
+type signedSchedule struct {
+	Namespace      string                             `json:"namespace"`
+	PipelineConfig string                             `json:"pipelineConfig"`
+	Name           string                             `json:"name"`
+	Cron           string                             `json:"cron"`
+	Timezone       string                             `json:"timezone"`
+	Branch         string                             `json:"branch"`
+	Params         []devopsv1alpha1.PipelineParameter `json:"params"`
+	Preset         string                             `json:"preset"`
+	Paused         bool                               `json:"paused"`
+	ActiveSince    *time.Time                         `json:"activeSince"`
+	Owner          *ScheduleOwner                     `json:"owner"`
+}
+
+// signSchedule returns the signature of the schedule of the PipelineConfig, empty when the key is not loaded
+func signSchedule(namespace, name string, schedule PipelineSchedule) string {
+	scheduleKey.RLock()
+	defer scheduleKey.RUnlock()
+	if len(scheduleKey.key) == 0 {
+		return ""
+	}
+
+	raw, err := json.Marshal(signedSchedule{
+		Namespace:      namespace,
+		PipelineConfig: name,
+		Name:           schedule.Name,
+		Cron:           schedule.Cron,
+		Timezone:       schedule.Timezone,
+		Branch:         schedule.Branch,
+		Params:         schedule.Params,
+		Preset:         schedule.Preset,
+		Paused:         schedule.Paused,
+		ActiveSince:    schedule.ActiveSince,
+		Owner:          schedule.Owner,
+	})
+	if err != nil {
+		return ""
+	}
+	mac := hmac.New(sha256.New, scheduleKey.key)
+	mac.Write(raw)
+	return base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// isScheduleSigned returns true when the schedule was saved through the API and not changed since
+func isScheduleSigned(namespace, name string, schedule PipelineSchedule) bool {
+	signature := signSchedule(namespace, name, schedule)
+	return signature != "" && hmac.Equal([]byte(signature), []byte(schedule.Signature))
+}
@@ -0,0 +1,43 @@
+package pipelineconfig
+
+import "testing"
+
+func TestIsScheduleSigned(t *testing.T) {
+	schedule := PipelineSchedule{Name: "nightly", Cron: "@daily", Owner: &ScheduleOwner{User: "alice@example.com"}}
+
+	scheduleKey.key = nil
+	if signature := signSchedule("ns", "config", schedule); signature != "" {
+		t.Errorf("signSchedule() without key == %q, expected empty signature", signature)
+	}
+
+	scheduleKey.key = []byte("0123456789abcdef0123456789abcdef")
+	defer func() { scheduleKey.key = nil }()
+	schedule.Signature = signSchedule("ns", "config", schedule)
+
+	changedCron := schedule
+	changedCron.Cron = "* * * * *"
+	changedOwner := schedule
+	changedOwner.Owner = &ScheduleOwner{User: "admin"}
+	triggered := schedule
+	triggered.LastPipeline = "config-1"
+
+	cases := []struct {
+		info      string
+		namespace string
+		schedule  PipelineSchedule
+		signed    bool
+	}{
+		{"saved through the API", "ns", schedule, true},
+		{"changed by the scheduler", "ns", triggered, true},
+		{"changed cron", "ns", changedCron, false},
+		{"changed owner", "ns", changedOwner, false},
+		{"copied to another namespace", "other", schedule, false},
+		{"not signed", "ns", PipelineSchedule{Name: "nightly", Cron: "@daily"}, false},
+	}
+
+	for _, c := range cases {
+		if signed := isScheduleSigned(c.namespace, "config", c.schedule); signed != c.signed {
+			t.Errorf("Test Case: %s. isScheduleSigned() == %t, expected %t", c.info, signed, c.signed)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package pipelineconfig
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	authv1 "k8s.io/api/authorization/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/kubernetes"
+)
+
+// DefaultScheduleSyncPeriod is how often the scheduler checks schedules of all PipelineConfigs
+const DefaultScheduleSyncPeriod = 30 * time.Second
+
+// errScheduleNotDue is returned when the schedule was triggered by another replica in the meantime
+var errScheduleNotDue = errors.New("schedule is not due")
+
+// errScheduleNotSigned is returned for schedules which were not saved through the API
+var errScheduleNotSigned = errors.New("schedule was not saved through the API, save it again to activate it")
+
+// Scheduler triggers PipelineConfigs when their schedules are due. Several replicas of the dashboard
+// may run a scheduler, every fire time is claimed by updating the PipelineConfig before triggering so
+// that only one of them triggers it. Only schedules signed by the API are triggered, and only while
+// their owners are allowed to create pipelines.
+type Scheduler struct {
+	client    devopsclient.Interface
+	k8sclient kubernetes.Interface
+	period    time.Duration
+}
+
+// NewScheduler creates a scheduler using clients which can list and update PipelineConfigs, create
+// pipelines in all namespaces and review access of other users. The schedule key must be loaded by
+// LoadScheduleKey.
+func NewScheduler(client devopsclient.Interface, k8sclient kubernetes.Interface, period time.Duration) *Scheduler {
+	return &Scheduler{client: client, k8sclient: k8sclient, period: period}
+}
+
+// Start checks schedules periodically until stopCh is closed
+func (self *Scheduler) Start(stopCh <-chan struct{}) {
+	go wait.Until(self.sync, self.period, stopCh)
+}
+
+func (self *Scheduler) sync() {
+	list, err := self.client.DevopsV1alpha1().PipelineConfigs("").List(api.ListEverything)
+	if err != nil {
+		log.Printf("Failed to list pipeline configs for schedules: %v", err)
+		return
+	}
+
+	now := time.Now()
+	for _, config := range list.Items {
+		if _, ok := config.Annotations[common.AnnotationsKeySchedules]; !ok {
+			continue
+		}
+		schedules, err := getSchedules(&config)
+		if err != nil {
+			log.Printf("Invalid schedules of pipeline config %s/%s: %v", config.Namespace, config.Name, err)
+			continue
+		}
+		for _, schedule := range schedules {
+			if isScheduleDue(schedule, now) {
+				self.fire(&config, schedule.Name, now)
+			}
+		}
+	}
+}
+
+// fire claims the due schedule and triggers the PipelineConfig
+func (self *Scheduler) fire(config *devopsv1alpha1.PipelineConfig, name string, now time.Time) {
+	var claimed PipelineSchedule
+	_, err := updateSchedules(self.client, config.Namespace, config.Name, func(config *devopsv1alpha1.PipelineConfig, schedules []PipelineSchedule) ([]PipelineSchedule, error) {
+		i, err := findSchedule(schedules, name)
+		if err != nil {
+			return nil, err
+		}
+		if !isScheduleDue(schedules[i], now) {
+			return nil, errScheduleNotDue
+		}
+		// fire times missed while the scheduler was not running are triggered only once
+		schedules[i].LastScheduledAt = &now
+		claimed = schedules[i]
+		return schedules, nil
+	})
+	if err != nil {
+		if err != errScheduleNotDue && !k8serrors.IsConflict(err) && !k8serrors.IsNotFound(err) {
+			log.Printf("Failed to claim schedule %s of pipeline config %s/%s: %v", name, config.Namespace, config.Name, err)
+		}
+		return
+	}
+
+	if err := self.authorize(config.Namespace, config.Name, claimed); err != nil {
+		log.Printf("Skipping schedule %s of pipeline config %s/%s: %v", name, config.Namespace, config.Name, err)
+		recordScheduleTrigger(self.client, config.Namespace, config.Name, name, nil, err)
+		return
+	}
+
+	log.Printf("Triggering schedule %s of pipeline config %s/%s", name, config.Namespace, config.Name)
+	if _, err := triggerSchedule(self.client, self.k8sclient, config.Namespace, config.Name, claimed); err != nil {
+		log.Printf("Failed to trigger schedule %s of pipeline config %s/%s: %v", name, config.Namespace, config.Name, err)
+	}
+}
+
+// authorize checks that the schedule was saved through the API and that its owner is still allowed to create
+// pipelines in the namespace, as the scheduler creates them with its own permissions
+func (self *Scheduler) authorize(namespace, name string, schedule PipelineSchedule) error {
+	if schedule.Owner == nil || !isScheduleSigned(namespace, name, schedule) {
+		return errScheduleNotSigned
+	}
+
+	review, err := self.k8sclient.AuthorizationV1().SubjectAccessReviews().Create(&authv1.SubjectAccessReview{
+		Spec: authv1.SubjectAccessReviewSpec{
+			User:   schedule.Owner.User,
+			Groups: schedule.Owner.Groups,
+			ResourceAttributes: &authv1.ResourceAttributes{
+				Namespace: namespace,
+				Verb:      "create",
+				Group:     devopsv1alpha1.SchemeGroupVersion.Group,
+				Version:   devopsv1alpha1.SchemeGroupVersion.Version,
+				Resource:  "pipelines",
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return fmt.Errorf("user %s is not allowed to create pipelines in namespace %s", schedule.Owner.User, namespace)
+	}
+	return nil
+}
+
+func isScheduleDue(schedule PipelineSchedule, now time.Time) bool {
+	if schedule.Paused {
+		return false
+	}
+	next, err := schedule.nextFireTime()
+	return err == nil && !next.IsZero() && !next.After(now)
+}
@@ -0,0 +1,254 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/pipeline"
+	"github.com/golang/glog"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// DefaultTestHistoryRuns is the number of runs used by test history when not specified
+	DefaultTestHistoryRuns = 10
+	// MaxTestHistoryRuns is the maximum number of runs used by test history, every run reads a full test report
+	MaxTestHistoryRuns = 50
+	// DefaultTestHistorySlowest is the number of slowest tests returned when not specified
+	DefaultTestHistorySlowest = 10
+
+	testStatusPassed = "PASSED"
+	testStatusFailed = "FAILED"
+)
+
+// TestHistory contains results of every test case in the last runs of a PipelineConfig
+type TestHistory struct {
+	// Runs in order of execution, results of test cases are in the same order
+	Runs []TestHistoryRun `json:"runs"`
+	// Tests ordered by flip rate and number of failures, the flakiest first
+	Tests []TestCaseHistory `json:"tests"`
+	// Slowest tests by mean duration
+	Slowest []TestCaseHistory `json:"slowest"`
+}
+
+// TestHistoryRun is a pipeline whose test report is a part of the history
+type TestHistoryRun struct {
+	Name   string `json:"name"`
+	Build  string `json:"build"`
+	Phase  string `json:"phase"`
+	Total  int    `json:"total"`
+	Failed int    `json:"failed"`
+}
+
+// TestCaseHistory contains results of a test case in every run
+type TestCaseHistory struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Results contains status of the test in every run, empty when the test was not reported
+	Results  []string `json:"results"`
+	Passed   int      `json:"passed"`
+	Failed   int      `json:"failed"`
+	FlipRate float64  `json:"flipRate"`
+	// FirstFailedIn is the run where the last series of failures started, empty when the test
+	// did not fail
+	FirstFailedIn string `json:"firstFailedIn"`
+	// Durations are in seconds
+	MeanDuration float64 `json:"meanDuration"`
+	MaxDuration  float64 `json:"maxDuration"`
+}
+
+// testCase is the JSON representation of PipelineTestReportItem
+type testCase struct {
+	ID       string  `json:"id"`
+	Name     string  `json:"name"`
+	Status   string  `json:"status"`
+	Duration float64 `json:"duration"`
+}
+
+// GetTestHistory walks test reports of the last finished runs of the PipelineConfig and returns
+// history of every test case. Test reports are fetched by a bounded number of workers.
+func GetTestHistory(client devopsclient.Interface, namespace, name string, runs, slowest int) (*TestHistory, error) {
+	if runs > MaxTestHistoryRuns {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("test history walks at most %d runs, got %d", MaxTestHistoryRuns, runs))
+	}
+
+	pipelineList, err := client.DevopsV1alpha1().Pipelines(namespace).List(metaV1.ListOptions{
+		LabelSelector:   getPipelineConfigSelector(name).String(),
+		ResourceVersion: "0",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	pipelines := make([]devopsv1alpha1.Pipeline, 0)
+	for _, p := range pipelineList.Items {
+		if p.Status.Phase.IsFinalPhase() {
+			pipelines = append(pipelines, p)
+		}
+	}
+	// the latest runs first
+	sort.SliceStable(pipelines, func(i, j int) bool {
+		return comparePipeline(pipelines[i], pipelines[j])
+	})
+	if len(pipelines) > runs {
+		pipelines = pipelines[:runs]
+	}
+	// history is in order of execution
+	for i, j := 0, len(pipelines)-1; i < j; i, j = i+1, j-1 {
+		pipelines[i], pipelines[j] = pipelines[j], pipelines[i]
+	}
+
+	targets := make([]common.BatchTarget, 0, len(pipelines))
+	for _, p := range pipelines {
+		targets = append(targets, common.BatchTarget{Namespace: namespace, Name: p.GetName()})
+	}
+	batch := common.RunBatch(targets, common.BatchOptions{}, func(target common.BatchTarget) (interface{}, error) {
+		return getTestCases(client, target.Namespace, target.Name)
+	})
+
+	reports := make([][]testCase, len(pipelines))
+	historyRuns := make([]TestHistoryRun, len(pipelines))
+	for i, p := range pipelines {
+		if item := batch.Items[i]; item.Succeeded {
+			reports[i], _ = item.Result.([]testCase)
+		} else {
+			// runs without test report are kept in the history with no results
+			glog.Errorf("error happen when get test report of pipeline %s/%s, err: %s", namespace, p.GetName(), item.Error)
+		}
+		historyRuns[i] = TestHistoryRun{
+			Name:  p.GetName(),
+			Phase: string(p.Status.Phase),
+		}
+		if p.Status.Jenkins != nil {
+			historyRuns[i].Build = p.Status.Jenkins.Build
+		}
+	}
+
+	return buildTestHistory(historyRuns, reports, slowest), nil
+}
+
+// getTestCases returns all test cases of the pipeline test report
+func getTestCases(client devopsclient.Interface, namespace, name string) ([]testCase, error) {
+	items, err := pipeline.GetTestReportItems(client, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.Marshal(items)
+	if err != nil {
+		return nil, err
+	}
+	cases := make([]testCase, 0)
+	err = json.Unmarshal(raw, &cases)
+	return cases, err
+}
+
+// buildTestHistory merges test reports of the runs into history of every test case
+func buildTestHistory(runs []TestHistoryRun, reports [][]testCase, slowest int) *TestHistory {
+	history := &TestHistory{
+		Runs:    runs,
+		Tests:   make([]TestCaseHistory, 0),
+		Slowest: make([]TestCaseHistory, 0),
+	}
+
+	tests := make(map[string]*TestCaseHistory, 0)
+	durations := make(map[string][]float64, 0)
+	order := make([]string, 0)
+	for i, report := range reports {
+		for _, c := range report {
+			key := c.ID
+			if len(key) == 0 {
+				key = c.Name
+			}
+
+			test, ok := tests[key]
+			if !ok {
+				test = &TestCaseHistory{ID: c.ID, Name: c.Name, Results: make([]string, len(runs))}
+				tests[key] = test
+				order = append(order, key)
+			}
+			test.Results[i] = c.Status
+			durations[key] = append(durations[key], c.Duration)
+
+			history.Runs[i].Total++
+			if c.Status == testStatusFailed {
+				history.Runs[i].Failed++
+			}
+		}
+	}
+
+	for _, key := range order {
+		test := tests[key]
+		test.Passed, test.Failed, test.FlipRate, test.FirstFailedIn = analyzeResults(test.Results, runs)
+		test.MeanDuration, test.MaxDuration = analyzeDurations(durations[key])
+		history.Tests = append(history.Tests, *test)
+	}
+
+	sort.SliceStable(history.Tests, func(i, j int) bool {
+		left, right := history.Tests[i], history.Tests[j]
+		if left.FlipRate != right.FlipRate {
+			return left.FlipRate > right.FlipRate
+		}
+		return left.Failed > right.Failed
+	})
+
+	history.Slowest = append(history.Slowest, history.Tests...)
+	sort.SliceStable(history.Slowest, func(i, j int) bool {
+		return history.Slowest[i].MeanDuration > history.Slowest[j].MeanDuration
+	})
+	if len(history.Slowest) > slowest {
+		history.Slowest = history.Slowest[:slowest]
+	}
+	return history
+}
+
+// analyzeResults counts passes and failures of the test. Flip rate is the number of changes between
+// pass and failure divided by the number of possible changes, skipped and missing results are ignored.
+func analyzeResults(results []string, runs []TestHistoryRun) (passed, failed int, flipRate float64, firstFailedIn string) {
+	flips, last := 0, ""
+	for i, result := range results {
+		if result != testStatusPassed && result != testStatusFailed {
+			continue
+		}
+
+		if result == testStatusPassed {
+			passed++
+		} else {
+			failed++
+			if last != testStatusFailed {
+				firstFailedIn = runs[i].Name
+			}
+		}
+		if last != "" && last != result {
+			flips++
+		}
+		last = result
+	}
+
+	if passed+failed > 1 {
+		flipRate = float64(flips) / float64(passed+failed-1)
+	}
+	return
+}
+
+// analyzeDurations returns mean and max duration
+func analyzeDurations(durations []float64) (mean, max float64) {
+	if len(durations) == 0 {
+		return
+	}
+
+	sum := 0.0
+	for _, d := range durations {
+		sum += d
+		if d > max {
+			max = d
+		}
+	}
+	mean = sum / float64(len(durations))
+	return
+}
@@ -0,0 +1,14 @@
+package pipelineconfig
+
+import (
+	"testing"
+
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+func TestGetTestHistoryRunsLimit(t *testing.T) {
+	// the limit is checked before any pipeline is read
+	if _, err := GetTestHistory(nil, "default", "build", MaxTestHistoryRuns+1, DefaultTestHistorySlowest); !k8serrors.IsBadRequest(err) {
+		t.Errorf("GetTestHistory() with %d runs returned %v, expected bad request", MaxTestHistoryRuns+1, err)
+	}
+}
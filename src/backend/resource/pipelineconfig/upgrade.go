@@ -0,0 +1,343 @@
+package pipelineconfig
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/common"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	// ArgumentAdded is an argument of the latest template version which the PipelineConfig does not have
+	ArgumentAdded = "added"
+	// ArgumentRemoved is an argument of the PipelineConfig which the latest template version does not have
+	ArgumentRemoved = "removed"
+	// ArgumentChanged is an argument whose definition differs in the latest template version
+	ArgumentChanged = "changed"
+
+	kindClusterPipelineTemplate = "ClusterPipelineTemplate"
+)
+
+// TemplateUpgrade is a PipelineConfig instantiated from an older version of its template
+type TemplateUpgrade struct {
+	Namespace    string `json:"namespace"`
+	Name         string `json:"name"`
+	TemplateKind string `json:"templateKind"`
+	TemplateName string `json:"templateName"`
+	// CurrentVersion is the template version the PipelineConfig was instantiated from
+	CurrentVersion string                   `json:"currentVersion"`
+	LatestVersion  string                   `json:"latestVersion"`
+	Arguments      []TemplateArgumentChange `json:"arguments"`
+	// Warning is set when the PipelineConfig was upgraded but the upgrade could not be recorded as a revision
+	Warning string `json:"warning,omitempty"`
+}
+
+// TemplateArgumentChange is a template argument which differs between the versions
+type TemplateArgumentChange struct {
+	Name string `json:"name"`
+	// Change is added, removed or changed
+	Change string `json:"change"`
+}
+
+// TemplateUpgradeList contains PipelineConfigs using outdated template versions
+type TemplateUpgradeList struct {
+	ListMeta api.ListMeta      `json:"listMeta"`
+	Items    []TemplateUpgrade `json:"items"`
+	// List of non-critical errors, that occurred during resource retrieval.
+	Errors []error `json:"errors"`
+}
+
+// TemplateUpgradePreview is the upgrade of a PipelineConfig with the Jenkinsfile rendered from the
+// latest template version
+type TemplateUpgradePreview struct {
+	TemplateUpgrade
+	Jenkinsfile string `json:"jenkinsfile"`
+	// Diff is the unified diff between the current and the upgraded Jenkinsfile
+	Diff string `json:"diff"`
+}
+
+// GetTemplateUpgrades returns PipelineConfigs of the namespaces instantiated from an older version of
+// the template
+func GetTemplateUpgrades(client devopsclient.Interface, namespace *common.NamespaceQuery, kind, name string) (*TemplateUpgradeList, error) {
+	list, err := client.DevopsV1alpha1().PipelineConfigs(namespace.ToRequestParam()).List(api.ListEverything)
+	nonCriticalErrors, criticalError := errors.HandleError(err)
+	if criticalError != nil {
+		return nil, criticalError
+	}
+
+	result := &TemplateUpgradeList{Items: make([]TemplateUpgrade, 0), Errors: nonCriticalErrors}
+	for i := range list.Items {
+		config := &list.Items[i]
+		template := config.Spec.Strategy.Template
+		if !namespace.Matches(config.Namespace) || template == nil || template.Kind != kind || template.Name != name {
+			continue
+		}
+		latest, err := getTemplateSpec(client, config)
+		if err != nil {
+			result.Errors = append(result.Errors, err)
+			continue
+		}
+		upgrade, err := toTemplateUpgrade(config, latest)
+		if err != nil {
+			return nil, err
+		}
+		if upgrade.CurrentVersion != upgrade.LatestVersion {
+			result.Items = append(result.Items, *upgrade)
+		}
+	}
+	result.ListMeta = api.ListMeta{TotalItems: len(result.Items)}
+	return result, nil
+}
+
+// PreviewTemplateUpgrade returns the argument changes and the Jenkinsfile of the PipelineConfig upgraded
+// to the latest version of its template
+func PreviewTemplateUpgrade(client devopsclient.Interface, namespace, name string) (*TemplateUpgradePreview, error) {
+	config, err := client.DevopsV1alpha1().PipelineConfigs(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	upgraded, upgrade, err := upgradePipelineConfig(client, config)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := RenderJenkinsfile(client, namespace, toPipelineConfigDetail(config))
+	if err != nil {
+		return nil, err
+	}
+	jenkinsfile, err := RenderJenkinsfile(client, namespace, toPipelineConfigDetail(upgraded))
+	if err != nil {
+		return nil, err
+	}
+	return &TemplateUpgradePreview{
+		TemplateUpgrade: *upgrade,
+		Jenkinsfile:     jenkinsfile,
+		Diff: common.UnifiedDiff("version "+upgrade.CurrentVersion, "version "+upgrade.LatestVersion,
+			current, jenkinsfile, revisionDiffContext),
+	}, nil
+}
+
+// UpgradePipelineConfigs upgrades every target to the latest version of its template. Values of
+// arguments kept by the latest version are preserved and the upgrade is recorded as a revision by author.
+func UpgradePipelineConfigs(client devopsclient.Interface, k8sclient kubernetes.Interface, targets []common.BatchTarget,
+	options common.BatchOptions, author string) *common.BatchResult {
+	return common.RunBatch(targets, options, func(target common.BatchTarget) (interface{}, error) {
+		config, err := client.DevopsV1alpha1().PipelineConfigs(target.Namespace).Get(target.Name, metaV1.GetOptions{})
+		if err != nil {
+			return nil, err
+		}
+		upgraded, upgrade, err := upgradePipelineConfig(client, config)
+		if err != nil {
+			return nil, err
+		}
+		if upgrade.CurrentVersion == upgrade.LatestVersion {
+			return upgrade, nil
+		}
+
+		// set phase as initial
+		upgraded.Status.Phase = devopsv1alpha1.PipelineConfigPhaseCreating
+		updated, err := client.DevopsV1alpha1().PipelineConfigs(target.Namespace).Update(upgraded)
+		if err != nil {
+			return nil, err
+		}
+		// the PipelineConfig is already upgraded, so the upgrade succeeded even without a revision
+		if err := recordRevision(k8sclient, updated, config.Spec, author,
+			fmt.Sprintf("upgrade template %s from version %s to %s", upgrade.TemplateName, upgrade.CurrentVersion, upgrade.LatestVersion)); err != nil {
+			log.Printf("Failed to record revision of pipeline config %s/%s: %v", updated.Namespace, updated.Name, err)
+			upgrade.Warning = fmt.Sprintf("upgrade was not recorded as a revision: %v", err)
+		}
+		return upgrade, nil
+	})
+}
+
+// SelectTemplateUpgradeTargets returns the targets to upgrade out of the outdated PipelineConfigs of the
+// list, all of them when targets is empty
+func SelectTemplateUpgradeTargets(list *TemplateUpgradeList, targets []common.BatchTarget) ([]common.BatchTarget, error) {
+	outdated := make([]common.BatchTarget, 0, len(list.Items))
+	for _, item := range list.Items {
+		outdated = append(outdated, common.BatchTarget{Namespace: item.Namespace, Name: item.Name})
+	}
+	if len(targets) == 0 {
+		return outdated, nil
+	}
+
+	for _, target := range targets {
+		found := false
+		for _, item := range outdated {
+			found = found || item == target
+		}
+		if !found {
+			return nil, k8serrors.NewBadRequest(fmt.Sprintf("pipeline config %s/%s does not use an outdated version of the template", target.Namespace, target.Name))
+		}
+	}
+	return targets, nil
+}
+
+// upgradePipelineConfig returns a copy of the PipelineConfig instantiated from the latest version of its
+// template with the values of the arguments and parameters kept by the latest version
+func upgradePipelineConfig(client devopsclient.Interface, config *devopsv1alpha1.PipelineConfig) (*devopsv1alpha1.PipelineConfig, *TemplateUpgrade, error) {
+	if config.Spec.Strategy.Template == nil {
+		return nil, nil, k8serrors.NewBadRequest(fmt.Sprintf("pipeline config %s/%s is not created from a template", config.Namespace, config.Name))
+	}
+	latest, err := getTemplateSpec(client, config)
+	if err != nil {
+		return nil, nil, err
+	}
+	upgrade, err := toTemplateUpgrade(config, latest)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	template := make(map[string]interface{}, 0)
+	if err := convertJSON(config.Spec.Strategy.Template, &template); err != nil {
+		return nil, nil, err
+	}
+	spec := make(map[string]interface{}, 0)
+	if err := convertJSON(latest.Spec, &spec); err != nil {
+		return nil, nil, err
+	}
+	values := make(map[string]interface{}, 0)
+	for _, item := range templateArgumentItems(template) {
+		if itemName, ok := item["name"].(string); ok {
+			values[itemName] = item["value"]
+		}
+	}
+	for _, item := range templateArgumentItems(map[string]interface{}{"spec": spec}) {
+		itemName, _ := item["name"].(string)
+		if value, ok := values[itemName]; ok {
+			item["value"] = value
+		}
+	}
+	template["spec"] = spec
+
+	upgraded := config.DeepCopy()
+	upgraded.Spec.Strategy.Template = new(devopsv1alpha1.PipelineConfigTemplate)
+	if err := convertJSON(template, upgraded.Spec.Strategy.Template); err != nil {
+		return nil, nil, err
+	}
+	// parameters are kept by name, their definitions come from the latest version
+	for i, parameter := range upgraded.Spec.Strategy.Template.Spec.Parameters {
+		for _, old := range config.Spec.Strategy.Template.Spec.Parameters {
+			if old.Name == parameter.Name {
+				upgraded.Spec.Strategy.Template.Spec.Parameters[i].Value = old.Value
+			}
+		}
+	}
+	annotations := upgraded.Spec.Strategy.Template.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string, 0)
+	}
+	annotations[common.AnnotationsKeyTemplateVersion] = upgrade.LatestVersion
+	upgraded.Spec.Strategy.Template.SetAnnotations(annotations)
+
+	if err := handleSpec(client, upgraded, upgraded.Spec.Strategy.Template); err != nil {
+		return nil, nil, err
+	}
+	return upgraded, upgrade, nil
+}
+
+// templateSpec is the latest version of a template
+type templateSpec struct {
+	Version string
+	Spec    devopsv1alpha1.PipelineTemplateSpec
+}
+
+// getTemplateSpec returns the latest version of the template of the PipelineConfig
+func getTemplateSpec(client devopsclient.Interface, config *devopsv1alpha1.PipelineConfig) (*templateSpec, error) {
+	template := config.Spec.Strategy.Template
+	if template.Kind == kindClusterPipelineTemplate {
+		latest, err := client.DevopsV1alpha1().ClusterPipelineTemplates().Get(template.Name, api.GetOptionsInCache)
+		if err != nil {
+			return nil, err
+		}
+		return &templateSpec{Version: latest.Annotations[common.AnnotationsKeyTemplateVersion], Spec: latest.Spec}, nil
+	}
+
+	namespace := template.Namespace
+	if namespace == "" {
+		namespace = config.Namespace
+	}
+	latest, err := client.DevopsV1alpha1().PipelineTemplates(namespace).Get(template.Name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	return &templateSpec{Version: latest.Annotations[common.AnnotationsKeyTemplateVersion], Spec: latest.Spec}, nil
+}
+
+// toTemplateUpgrade compares arguments of the PipelineConfig with the latest version of its template
+func toTemplateUpgrade(config *devopsv1alpha1.PipelineConfig, latest *templateSpec) (*TemplateUpgrade, error) {
+	template := config.Spec.Strategy.Template
+	current := make(map[string]interface{}, 0)
+	if err := convertJSON(template, &current); err != nil {
+		return nil, err
+	}
+	spec := make(map[string]interface{}, 0)
+	if err := convertJSON(latest.Spec, &spec); err != nil {
+		return nil, err
+	}
+
+	upgrade := &TemplateUpgrade{
+		Namespace:      config.Namespace,
+		Name:           config.Name,
+		TemplateKind:   template.Kind,
+		TemplateName:   template.Name,
+		CurrentVersion: template.GetAnnotations()[common.AnnotationsKeyTemplateVersion],
+		LatestVersion:  latest.Version,
+		Arguments:      diffTemplateArguments(templateArgumentItems(current), templateArgumentItems(map[string]interface{}{"spec": spec})),
+	}
+	return upgrade, nil
+}
+
+// diffTemplateArguments compares definitions of argument items by name, ignoring their values
+func diffTemplateArguments(current, latest []map[string]interface{}) []TemplateArgumentChange {
+	definitions := func(items []map[string]interface{}) map[string]string {
+		result := make(map[string]string, len(items))
+		for _, item := range items {
+			itemName, _ := item["name"].(string)
+			definition := make(map[string]interface{}, len(item))
+			for key, value := range item {
+				if key != "value" {
+					definition[key] = value
+				}
+			}
+			raw, _ := json.Marshal(definition)
+			result[itemName] = string(raw)
+		}
+		return result
+	}
+	currentDefinitions, latestDefinitions := definitions(current), definitions(latest)
+
+	changes := make([]TemplateArgumentChange, 0)
+	for itemName, definition := range latestDefinitions {
+		if old, ok := currentDefinitions[itemName]; !ok {
+			changes = append(changes, TemplateArgumentChange{Name: itemName, Change: ArgumentAdded})
+		} else if old != definition {
+			changes = append(changes, TemplateArgumentChange{Name: itemName, Change: ArgumentChanged})
+		}
+	}
+	for itemName := range currentDefinitions {
+		if _, ok := latestDefinitions[itemName]; !ok {
+			changes = append(changes, TemplateArgumentChange{Name: itemName, Change: ArgumentRemoved})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Name < changes[j].Name
+	})
+	return changes
+}
+
+func toPipelineConfigDetail(config *devopsv1alpha1.PipelineConfig) *PipelineConfigDetail {
+	detail := &PipelineConfigDetail{}
+	detail.ObjectMeta = api.NewObjectMeta(config.ObjectMeta)
+	detail.Spec = config.Spec
+	return detail
+}
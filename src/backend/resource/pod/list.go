@@ -25,6 +25,7 @@ import (
 	"alauda.io/diablo/src/backend/resource/event"
 	"k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
 	k8sClient "k8s.io/client-go/kubernetes"
 )
 
@@ -148,6 +149,13 @@ func ToPodList(pods []v1.Pod, events []v1.Event, nonCriticalErrors []error, dsQu
 	return podList
 }
 
+// ToPod returns presentation view of a single pod without metrics. Warnings are taken from given
+// events.
+func ToPod(pod *v1.Pod, events []v1.Event) Pod {
+	warnings := event.GetPodsEventWarnings(events, []v1.Pod{*pod})
+	return toPod(pod, &MetricsByPod{MetricsMap: make(map[types.UID]PodMetrics)}, warnings)
+}
+
 func toPod(pod *v1.Pod, metrics *MetricsByPod, warnings []common.Event) Pod {
 	podDetail := Pod{
 		ObjectMeta:   api.NewObjectMeta(pod.ObjectMeta),
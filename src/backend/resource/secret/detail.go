@@ -159,6 +159,10 @@ func GetSecretRelatedResources(k8sclient kubernetes.Interface, devopsClient devo
 func CreateSecret(client kubernetes.Interface, appCoreClient *appCore.ApplicationClient, namespace *common.NamespaceQuery, spec *SecretDetail) (*Secret, error) {
 	curNamespace := common.GetCurNamespace(namespace)
 
+	if err := validateExpiry(spec.ObjectMeta.Annotations); err != nil {
+		return nil, err
+	}
+
 	secret := &v1.Secret{
 		ObjectMeta: metaV1.ObjectMeta{
 			Name:        spec.ObjectMeta.Name,
@@ -242,6 +246,10 @@ func UpdateSecret(client kubernetes.Interface, appCoreClient *appCore.Applicatio
 		}
 	}
 
+	if err := validateExpiry(spec.ObjectMeta.Annotations); err != nil {
+		return nil, err
+	}
+
 	anno := common.DevOpsAnnotator{}
 	newMeta := api.NewRawObjectMeta(spec.ObjectMeta)
 	originSecret.ObjectMeta = anno.GetProductAnnotations(api.CompleteMeta(newMeta, originSecret.ObjectMeta))
@@ -295,6 +303,9 @@ func DeleteSecret(client kubernetes.Interface, appCoreClient *appCore.Applicatio
 	curNamespace := common.GetCurNamespace(namespace)
 
 	secretDetail, err, originSecret := GetSecretDetail(client, appCoreClient, curNamespace, name)
+	if err != nil {
+		return err
+	}
 	setTypeMeta(originSecret)
 
 	if secretDetail.AppName != "" {
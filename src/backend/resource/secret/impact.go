@@ -0,0 +1,74 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"log"
+
+	appCore "alauda.io/app-core/pkg/app"
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/common"
+	"k8s.io/client-go/kubernetes"
+)
+
+// SecretImpact lists the resources which break when the secret is deleted
+type SecretImpact struct {
+	Namespace string `json:"namespace"`
+	Name      string `json:"name"`
+	// AppName is the application the secret belongs to, such secrets cannot be deleted
+	AppName string `json:"appName"`
+	// Resources are the toolchain bindings, pipeline configs, workloads and service accounts referencing
+	// the secret
+	Resources []ResourceItem `json:"resources"`
+	// Deleted is true when the secret was deleted
+	Deleted bool `json:"deleted"`
+
+	// List of non-critical errors, that occurred during resource retrieval.
+	Errors []error `json:"errors"`
+}
+
+// GetSecretImpact returns the resources referencing the secret. References to secrets of the global
+// credentials namespace are looked up in all namespaces.
+func GetSecretImpact(client kubernetes.Interface, appCoreClient *appCore.ApplicationClient, devopsClient devopsclient.Interface,
+	namespace, name string) (*SecretImpact, error) {
+	log.Printf("Getting impact of deleting secret %s/%s", namespace, name)
+
+	detail, err, _ := GetSecretDetail(client, appCoreClient, namespace, name)
+	if err != nil {
+		return nil, err
+	}
+
+	query := common.NewSameNamespaceQuery(namespace)
+	if namespace == devopsv1alpha1.NamespaceGlobalCredentials {
+		query = common.NewNamespaceQuery(nil)
+	}
+	references, nonCriticalErrors, err := getSecretReferences(client, devopsClient, query)
+	if err != nil {
+		return nil, err
+	}
+
+	impact := &SecretImpact{
+		Namespace: namespace,
+		Name:      name,
+		AppName:   detail.AppName,
+		Resources: references[secretKey(namespace, name)],
+		Errors:    nonCriticalErrors,
+	}
+	if impact.Resources == nil {
+		impact.Resources = make([]ResourceItem, 0)
+	}
+	return impact, nil
+}
@@ -24,6 +24,7 @@ import (
 	"alauda.io/diablo/src/backend/resource/common"
 	"alauda.io/diablo/src/backend/resource/dataselect"
 	"k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
 )
 
@@ -101,6 +102,8 @@ type Secret struct {
 	Type       v1.SecretType  `json:"type"`
 	Keys       []string       `json:"keys"`
 	AppName    string         `json:"appName"`
+	// ExpiresAt is the optional expiry of the credentials of the secret
+	ExpiresAt *metaV1.Time `json:"expiresAt,omitempty"`
 }
 
 func (ing Secret) GetObjectMeta() api.ObjectMeta {
@@ -159,24 +162,24 @@ func GetSecretList(client kubernetes.Interface, appCoreClient *appCore.Applicati
 		}
 	}
 
-	// secret type list
-	items := func(secrets []v1.Secret) []v1.Secret {
-		filtered := make([]v1.Secret, 0, len(secrets))
-		for _, s := range secrets {
-			if len(s.ObjectMeta.Labels) > 0 && s.ObjectMeta.Labels[generatedSecretLabelKey] != "" {
-				continue
-			}
-			for _, t := range supportedSecretTypes {
-				if s.Type == t && !isHiddenNamespace(s.Namespace) {
-					filtered = append(filtered, s)
-					break
-				}
+	return toSecretList(filterSecrets(secretList.Items), nonCriticalErrors, dsQuery, appCoreClient), nil
+}
+
+// filterSecrets returns the secrets of supported types which are neither generated nor in hidden namespaces
+func filterSecrets(secrets []v1.Secret) []v1.Secret {
+	filtered := make([]v1.Secret, 0, len(secrets))
+	for _, s := range secrets {
+		if len(s.ObjectMeta.Labels) > 0 && s.ObjectMeta.Labels[generatedSecretLabelKey] != "" {
+			continue
+		}
+		for _, t := range supportedSecretTypes {
+			if s.Type == t && !isHiddenNamespace(s.Namespace) {
+				filtered = append(filtered, s)
+				break
 			}
 		}
-		return filtered
-	}(secretList.Items)
-
-	return toSecretList(items, nonCriticalErrors, dsQuery, appCoreClient), nil
+	}
+	return filtered
 }
 
 func isHiddenNamespace(namespace string) bool {
@@ -213,6 +216,9 @@ func toSecret(secret *v1.Secret, appName string) *Secret {
 		Keys:       keys,
 		AppName:    appName,
 	}
+	if expiresAt, err := getExpiry(secret.ObjectMeta); err == nil {
+		result.ExpiresAt = expiresAt
+	}
 	if result.ObjectMeta.Annotations == nil {
 		result.ObjectMeta.Annotations = make(map[string]string, 0)
 	}
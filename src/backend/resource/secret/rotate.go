@@ -0,0 +1,285 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"fmt"
+	"log"
+	"sync"
+
+	appCore "alauda.io/app-core/pkg/app"
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/codereposervice"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/imageregistry"
+	"alauda.io/diablo/src/backend/resource/jenkins"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
+)
+
+// SecretRotation contains the new credentials of a secret. Keys which are not given keep their values.
+type SecretRotation struct {
+	Data map[string][]byte `json:"data"`
+
+	// StringData is without base64 Data
+	StringData map[string]string `json:"stringData,omitempty"`
+}
+
+// SecretRotationItem is the verification of the credentials against the tool of a resource referencing
+// the secret
+type SecretRotationItem struct {
+	ResourceItem
+	Tool     string `json:"tool"`
+	Verified bool   `json:"verified"`
+	Message  string `json:"message,omitempty"`
+}
+
+// SecretRotationResult is the result of the rotation of a secret
+type SecretRotationResult struct {
+	// Rotated is true when the secret was updated with the new credentials
+	Rotated bool `json:"rotated"`
+	// Reverted is true when the secret was restored because the verification of the updated secret failed
+	Reverted bool   `json:"reverted"`
+	Message  string `json:"message,omitempty"`
+	// Validated contains the validations of the new credentials before the secret is updated
+	Validated []SecretRotationItem `json:"validated"`
+	// Verified contains the verifications of the updated secret
+	Verified []SecretRotationItem `json:"verified"`
+}
+
+// rotationSecretGenerator labels the temporary secrets validating new credentials, it hides them from secret
+// lists like other generated secrets
+const rotationSecretGenerator = "secret-rotation"
+
+// RotateSecret replaces the credentials of the secret. The new credentials are validated against the tools of
+// every resource referencing the secret before the secret is updated and verified again afterwards, the
+// secret is reverted when the verification fails. The secret is updated like by UpdateSecret, so that secrets
+// of applications are updated through their application.
+func RotateSecret(k8sclient kubernetes.Interface, appCoreClient *appCore.ApplicationClient, devopsClient devopsclient.Interface,
+	namespace, name string, rotation *SecretRotation) (*SecretRotationResult, error) {
+	log.Printf("Rotating secret %s/%s", namespace, name)
+
+	origin, err := k8sclient.CoreV1().Secrets(namespace).Get(name, api.GetOptionsInCache)
+	if err != nil {
+		return nil, err
+	}
+	if origin.Type == devopsv1alpha1.SecretTypeOAuth2 {
+		return nil, errors.NewBadRequest(fmt.Sprintf("secret '%s' of type %s cannot be rotated, authorize it again instead", name, origin.Type))
+	}
+	if len(rotation.Data) == 0 && len(rotation.StringData) == 0 {
+		return nil, errors.NewBadRequest("new credentials are required")
+	}
+
+	resources, err := GetSecretRelatedResources(k8sclient, devopsClient, namespace, name, dataselect.NoDataSelect)
+	if err != nil {
+		return nil, err
+	}
+
+	rotator := &clientSecretRotator{
+		k8sclient:     k8sclient,
+		appCoreClient: appCoreClient,
+		devopsClient:  devopsClient,
+		origin:        origin,
+		resources:     resources.Items,
+	}
+	return rotateSecret(rotator, origin.Data, rotation)
+}
+
+// secretRotator validates, updates and verifies the credentials of a secret during its rotation
+type secretRotator interface {
+	// validate verifies the new credentials without changing the secret
+	validate(data map[string][]byte) ([]SecretRotationItem, error)
+	// update replaces the data of the secret
+	update(data map[string][]byte) error
+	// verify verifies the secret as it is stored
+	verify() []SecretRotationItem
+}
+
+func rotateSecret(rotator secretRotator, originData map[string][]byte, rotation *SecretRotation) (*SecretRotationResult, error) {
+	data := make(map[string][]byte, len(originData))
+	for key, value := range originData {
+		data[key] = value
+	}
+	for key, value := range rotation.Data {
+		data[key] = value
+	}
+	for key, value := range rotation.StringData {
+		data[key] = []byte(value)
+	}
+
+	var err error
+	result := &SecretRotationResult{}
+	result.Validated, err = rotator.validate(data)
+	if err != nil {
+		return nil, err
+	}
+	if failed := countUnverified(result.Validated); failed > 0 {
+		result.Message = fmt.Sprintf("new credentials were rejected by %d of %d resources, secret was not updated", failed, len(result.Validated))
+		return result, nil
+	}
+
+	if err = rotator.update(data); err != nil {
+		return nil, err
+	}
+	result.Rotated = true
+
+	result.Verified = rotator.verify()
+	failed := countUnverified(result.Verified)
+	if failed == 0 {
+		return result, nil
+	}
+
+	log.Printf("Reverting rotated secret, verification failed for %d resources", failed)
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		return rotator.update(originData)
+	})
+	if err != nil {
+		result.Message = fmt.Sprintf("updated secret was rejected by %d of %d resources and could not be reverted: %v",
+			failed, len(result.Verified), err)
+		return result, nil
+	}
+	result.Reverted = true
+	result.Message = fmt.Sprintf("updated secret was rejected by %d of %d resources, secret was reverted", failed, len(result.Verified))
+	return result, nil
+}
+
+// clientSecretRotator rotates the secret through the API server
+type clientSecretRotator struct {
+	k8sclient     kubernetes.Interface
+	appCoreClient *appCore.ApplicationClient
+	devopsClient  devopsclient.Interface
+	origin        *v1.Secret
+	resources     []ResourceItem
+}
+
+// validate verifies the resources against a temporary copy of the secret with data, so that the secret is not
+// changed when the new credentials are rejected. The copy does not keep the labels of the secret, so that it
+// never becomes part of its application.
+func (rotator *clientSecretRotator) validate(data map[string][]byte) ([]SecretRotationItem, error) {
+	if len(rotator.resources) == 0 {
+		return make([]SecretRotationItem, 0), nil
+	}
+
+	origin := rotator.origin
+	temporary, err := rotator.k8sclient.CoreV1().Secrets(origin.Namespace).Create(&v1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			GenerateName: origin.Name + "-rotation-",
+			Namespace:    origin.Namespace,
+			Labels:       map[string]string{generatedSecretLabelKey: rotationSecretGenerator},
+		},
+		Type: origin.Type,
+		Data: data,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := rotator.k8sclient.CoreV1().Secrets(temporary.Namespace).Delete(temporary.Name, &metaV1.DeleteOptions{}); err != nil {
+			log.Printf("error while deleting temporary secret %s/%s: %v", temporary.Namespace, temporary.Name, err)
+		}
+	}()
+	return verifyResources(rotator.devopsClient, rotator.resources, temporary.Namespace, temporary.Name), nil
+}
+
+// update replaces the data of the secret through UpdateSecret, keeping its application
+func (rotator *clientSecretRotator) update(data map[string][]byte) error {
+	namespace, name := rotator.origin.Namespace, rotator.origin.Name
+	log.Printf("Updating data of secret %s/%s", namespace, name)
+
+	detail, err, _ := GetSecretDetail(rotator.k8sclient, rotator.appCoreClient, namespace, name)
+	if err != nil {
+		return err
+	}
+	detail.Data = data
+	detail.StringData = nil
+	_, err = UpdateSecret(rotator.k8sclient, rotator.appCoreClient, common.NewSameNamespaceQuery(namespace), detail)
+	return err
+}
+
+func (rotator *clientSecretRotator) verify() []SecretRotationItem {
+	return verifyResources(rotator.devopsClient, rotator.resources, rotator.origin.Namespace, rotator.origin.Name)
+}
+
+// verifyResources authorizes the secret against the tools of the resources concurrently
+func verifyResources(devopsClient devopsclient.Interface, resources []ResourceItem, secretNamespace, secretName string) []SecretRotationItem {
+	items := make([]SecretRotationItem, len(resources))
+	wg := sync.WaitGroup{}
+	for i, resource := range resources {
+		wg.Add(1)
+		go func(i int, resource ResourceItem) {
+			defer wg.Done()
+			items[i] = verifyResource(devopsClient, resource, secretNamespace, secretName)
+		}(i, resource)
+	}
+	wg.Wait()
+	return items
+}
+
+func verifyResource(devopsClient devopsclient.Interface, resource ResourceItem, secretNamespace, secretName string) SecretRotationItem {
+	item := SecretRotationItem{ResourceItem: resource}
+
+	var (
+		response *devopsv1alpha1.CodeRepoServiceAuthorizeResponse
+		err      error
+	)
+	bindings := devopsClient.DevopsV1alpha1()
+	switch resource.Kind {
+	case api.ResourceKindCodeRepoBinding:
+		binding, getErr := bindings.CodeRepoBindings(resource.Namespace).Get(resource.Name, api.GetOptionsInCache)
+		if err = getErr; err == nil {
+			item.Tool = binding.Spec.CodeRepoService.Name
+			response, err = codereposervice.AuthorizeService(devopsClient, item.Tool, secretName, secretNamespace)
+		}
+	case api.ResourceKindImageRegistry, api.ResourceKindImageRegistryBinding:
+		binding, getErr := bindings.ImageRegistryBindings(resource.Namespace).Get(resource.Name, api.GetOptionsInCache)
+		if err = getErr; err == nil {
+			item.Tool = binding.Spec.ImageRegistry.Name
+			response, err = imageregistry.AuthorizeService(devopsClient, item.Tool, secretName, secretNamespace)
+		}
+	case api.ResourceKindJenkinsBinding:
+		binding, getErr := bindings.JenkinsBindings(resource.Namespace).Get(resource.Name, api.GetOptionsInCache)
+		if err = getErr; err == nil {
+			item.Tool = binding.Spec.Jenkins.Name
+			response, err = jenkins.AuthorizeService(devopsClient, item.Tool, secretName, secretNamespace)
+		}
+	default:
+		err = fmt.Errorf("resource kind %s cannot be verified", resource.Kind)
+	}
+
+	switch {
+	case err != nil:
+		item.Message = err.Error()
+	case response != nil && response.AuthorizeUrl != "":
+		item.Message = "credentials need to be authorized"
+	default:
+		item.Verified = true
+	}
+	return item
+}
+
+func countUnverified(items []SecretRotationItem) (count int) {
+	for _, item := range items {
+		if !item.Verified {
+			count++
+		}
+	}
+	return
+}
@@ -0,0 +1,110 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+// fakeSecretRotator accepts a single password before and after the update and records the updated passwords
+type fakeSecretRotator struct {
+	data      map[string][]byte
+	accepted  string
+	verified  string
+	revertErr error
+	updates   []string
+}
+
+func (rotator *fakeSecretRotator) validate(data map[string][]byte) ([]SecretRotationItem, error) {
+	return []SecretRotationItem{{Verified: string(data["password"]) == rotator.accepted}}, nil
+}
+
+func (rotator *fakeSecretRotator) update(data map[string][]byte) error {
+	// the second update reverts the secret
+	if len(rotator.updates) > 0 && rotator.revertErr != nil {
+		return rotator.revertErr
+	}
+	rotator.updates = append(rotator.updates, string(data["password"]))
+	rotator.data = data
+	return nil
+}
+
+func (rotator *fakeSecretRotator) verify() []SecretRotationItem {
+	return []SecretRotationItem{{Verified: string(rotator.data["password"]) == rotator.verified}}
+}
+
+func TestRotateSecret(t *testing.T) {
+	cases := []struct {
+		info      string
+		rotator   *fakeSecretRotator
+		rotated   bool
+		reverted  bool
+		message   string
+		updates   []string
+		finalData string
+	}{
+		{
+			"new credentials are rejected",
+			&fakeSecretRotator{accepted: "old", verified: "new"},
+			false, false,
+			"new credentials were rejected by 1 of 1 resources, secret was not updated",
+			nil, "",
+		},
+		{
+			"updated secret is verified",
+			&fakeSecretRotator{accepted: "new", verified: "new"},
+			true, false, "",
+			[]string{"new"}, "new",
+		},
+		{
+			"updated secret is rejected and reverted",
+			&fakeSecretRotator{accepted: "new", verified: "old"},
+			true, true,
+			"updated secret was rejected by 1 of 1 resources, secret was reverted",
+			[]string{"new", "old"}, "old",
+		},
+		{
+			"updated secret is rejected and cannot be reverted",
+			&fakeSecretRotator{accepted: "new", verified: "old", revertErr: errors.New("forbidden")},
+			true, false,
+			"updated secret was rejected by 1 of 1 resources and could not be reverted: forbidden",
+			[]string{"new"}, "new",
+		},
+	}
+
+	for _, c := range cases {
+		origin := map[string][]byte{"username": []byte("user"), "password": []byte("old")}
+		result, err := rotateSecret(c.rotator, origin, &SecretRotation{StringData: map[string]string{"password": "new"}})
+		if err != nil {
+			t.Errorf("Test Case: %s. rotateSecret() returned error %v", c.info, err)
+			continue
+		}
+		if result.Rotated != c.rotated || result.Reverted != c.reverted || result.Message != c.message {
+			t.Errorf("Test Case: %s. rotateSecret() == %+v, expected rotated %t, reverted %t and message %q",
+				c.info, result, c.rotated, c.reverted, c.message)
+		}
+		if !reflect.DeepEqual(c.rotator.updates, c.updates) {
+			t.Errorf("Test Case: %s. rotateSecret() updated passwords %v, expected %v", c.info, c.rotator.updates, c.updates)
+		}
+		if password := string(c.rotator.data["password"]); password != c.finalData {
+			t.Errorf("Test Case: %s. secret has password %q, expected %q", c.info, password, c.finalData)
+		}
+		if c.rotator.data != nil && string(c.rotator.data["username"]) != "user" {
+			t.Errorf("Test Case: %s. rotateSecret() did not keep the username", c.info)
+		}
+	}
+}
@@ -0,0 +1,462 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/errors"
+	"alauda.io/diablo/src/backend/resource/coderepobinding"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/imageregistrybinding"
+	"alauda.io/diablo/src/backend/resource/jenkinsbinding"
+	"k8s.io/api/core/v1"
+	extensions "k8s.io/api/extensions/v1beta1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const resourceKindServiceAccount = "ServiceAccount"
+
+// SecretUsage is a secret with the resources referencing it
+type SecretUsage struct {
+	Secret
+	// ExpiresIn is the number of days until the secret expires, negative when it already expired
+	ExpiresIn *int           `json:"expiresIn,omitempty"`
+	Resources []ResourceItem `json:"resources"`
+}
+
+// SecretUsageList contains a list of secrets with their usage
+type SecretUsageList struct {
+	ListMeta api.ListMeta  `json:"listMeta"`
+	Items    []SecretUsage `json:"items"`
+
+	// List of non-critical errors, that occurred during resource retrieval.
+	Errors []error `json:"errors"`
+}
+
+// GetExpiringSecrets returns the secrets in namespaces which expire within days or already expired, with
+// the resources using them, sorted by expiry
+func GetExpiringSecrets(client kubernetes.Interface, devopsClient devopsclient.Interface, namespace *common.NamespaceQuery,
+	days int) (*SecretUsageList, error) {
+	log.Printf("Getting secrets expiring within %d days", days)
+
+	secrets, nonCriticalErrors, err := listSecrets(client, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	deadline := now.Add(time.Duration(days) * 24 * time.Hour)
+	expiring := make([]v1.Secret, 0)
+	for _, secret := range secrets {
+		if expiresAt, err := getExpiry(secret.ObjectMeta); err == nil && expiresAt != nil && !expiresAt.Time.After(deadline) {
+			expiring = append(expiring, secret)
+		}
+	}
+
+	list := &SecretUsageList{Items: make([]SecretUsage, 0, len(expiring)), Errors: nonCriticalErrors}
+	if len(expiring) > 0 {
+		references, errs, err := getSecretReferences(client, devopsClient, namespace)
+		if err != nil {
+			return nil, err
+		}
+		list.Errors = append(list.Errors, errs...)
+
+		for i := range expiring {
+			usage := toSecretUsage(&expiring[i], references)
+			expiresIn := int(usage.ExpiresAt.Time.Sub(now).Hours() / 24)
+			usage.ExpiresIn = &expiresIn
+			list.Items = append(list.Items, usage)
+		}
+	}
+
+	sort.SliceStable(list.Items, func(i, j int) bool {
+		return list.Items[i].ExpiresAt.Before(list.Items[j].ExpiresAt)
+	})
+	list.ListMeta = api.ListMeta{TotalItems: len(list.Items)}
+	return list, nil
+}
+
+// GetUnusedSecrets returns the secrets in namespaces which no toolchain binding, pipeline config, workload,
+// ingress or service account references and no resource owns. Secrets of the global credentials namespace can be
+// referenced from any namespace, so they are only returned when all namespaces are queried.
+func GetUnusedSecrets(client kubernetes.Interface, devopsClient devopsclient.Interface, namespace *common.NamespaceQuery) (*SecretUsageList, error) {
+	log.Println("Getting unused secrets")
+
+	secrets, nonCriticalErrors, err := listSecrets(client, namespace)
+	if err != nil {
+		return nil, err
+	}
+	references, errs, err := getSecretReferences(client, devopsClient, namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	list := &SecretUsageList{Items: make([]SecretUsage, 0), Errors: append(nonCriticalErrors, errs...)}
+	allNamespaces := len(namespace.Namespaces()) == 0
+	for i := range secrets {
+		secret := &secrets[i]
+		if len(secret.OwnerReferences) > 0 || len(references[secretKey(secret.Namespace, secret.Name)]) > 0 {
+			continue
+		}
+		if secret.Namespace == devopsv1alpha1.NamespaceGlobalCredentials && !allNamespaces {
+			continue
+		}
+		list.Items = append(list.Items, toSecretUsage(secret, references))
+	}
+	list.ListMeta = api.ListMeta{TotalItems: len(list.Items)}
+	return list, nil
+}
+
+// validateExpiry returns an error when the expiry annotation is not a RFC3339 time
+func validateExpiry(annotations map[string]string) error {
+	_, err := getExpiry(metaV1.ObjectMeta{Annotations: annotations})
+	return err
+}
+
+// getExpiry returns the expiry of the secret, nil when it has none
+func getExpiry(meta metaV1.ObjectMeta) (*metaV1.Time, error) {
+	value := meta.Annotations[common.AnnotationsKeyExpiresAt]
+	if value == "" {
+		return nil, nil
+	}
+	expiresAt, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("annotation %s must be a RFC3339 time: %v", common.AnnotationsKeyExpiresAt, err))
+	}
+	return &metaV1.Time{Time: expiresAt}, nil
+}
+
+func listSecrets(client kubernetes.Interface, namespace *common.NamespaceQuery) ([]v1.Secret, []error, error) {
+	secretList, err := client.CoreV1().Secrets(namespace.ToRequestParam()).List(api.ListEverything)
+	nonCriticalErrors, criticalError := errors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	if secretList == nil {
+		return make([]v1.Secret, 0), nonCriticalErrors, nil
+	}
+
+	secrets := make([]v1.Secret, 0, len(secretList.Items))
+	for _, secret := range filterSecrets(secretList.Items) {
+		if namespace.Matches(secret.Namespace) {
+			secrets = append(secrets, secret)
+		}
+	}
+	return secrets, nonCriticalErrors, nil
+}
+
+func toSecretUsage(secret *v1.Secret, references map[string][]ResourceItem) SecretUsage {
+	setTypeMeta(secret)
+	usage := SecretUsage{
+		Secret:    *toSecret(secret, ""),
+		Resources: references[secretKey(secret.Namespace, secret.Name)],
+	}
+	if usage.Resources == nil {
+		usage.Resources = make([]ResourceItem, 0)
+	}
+	return usage
+}
+
+func secretKey(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+// getSecretReferences returns the resources in namespaces referencing secrets by namespace/name of the
+// secrets. Bindings are matched as in GetSecretRelatedResources, references without namespace are to
+// secrets of the namespace of the resource.
+func getSecretReferences(client kubernetes.Interface, devopsClient devopsclient.Interface,
+	namespace *common.NamespaceQuery) (map[string][]ResourceItem, []error, error) {
+	references := make(map[string][]ResourceItem)
+	nonCriticalErrors := make([]error, 0)
+	var add addReference = func(secretNamespace, secretName string, item ResourceItem) {
+		if secretName == "" {
+			return
+		}
+		if secretNamespace == "" {
+			secretNamespace = item.Namespace
+		}
+		key := secretKey(secretNamespace, secretName)
+		for _, existing := range references[key] {
+			if existing == item {
+				return
+			}
+		}
+		references[key] = append(references[key], item)
+	}
+
+	codeRepoBindings, err := coderepobinding.GetCodeRepoBindingList(devopsClient, namespace, dataselect.NoDataSelect)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonCriticalErrors = append(nonCriticalErrors, codeRepoBindings.Errors...)
+	for _, item := range codeRepoBindings.Items {
+		add(item.Spec.Account.Secret.Namespace, item.Spec.Account.Secret.Name, ResourceItem{
+			Name: item.ObjectMeta.Name, Namespace: item.ObjectMeta.Namespace, Kind: api.ResourceKindCodeRepoBinding})
+	}
+
+	imageRegistryBindings, err := imageregistrybinding.GetImageRegistryBindingList(devopsClient, namespace, dataselect.NoDataSelect)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonCriticalErrors = append(nonCriticalErrors, imageRegistryBindings.Errors...)
+	for _, item := range imageRegistryBindings.Items {
+		add(item.Spec.Secret.Namespace, item.Spec.Secret.Name, ResourceItem{
+			Name: item.ObjectMeta.Name, Namespace: item.ObjectMeta.Namespace, Kind: api.ResourceKindImageRegistryBinding})
+	}
+
+	jenkinsBindings, err := jenkinsbinding.GetJenkinsBindingList(devopsClient, client, namespace, dataselect.NoDataSelect)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonCriticalErrors = append(nonCriticalErrors, jenkinsBindings.Errors...)
+	for _, item := range jenkinsBindings.Items {
+		add(item.Spec.Account.Secret.Namespace, item.Spec.Account.Secret.Name, ResourceItem{
+			Name: item.ObjectMeta.Name, Namespace: item.ObjectMeta.Namespace, Kind: api.ResourceKindJenkinsBinding})
+	}
+
+	codeQualityBindings, err := devopsClient.DevopsV1alpha1().CodeQualityBindings(namespace.ToRequestParam()).List(api.ListEverything)
+	errs, criticalError := errors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	nonCriticalErrors = append(nonCriticalErrors, errs...)
+	if codeQualityBindings != nil {
+		addCodeQualityBindingReferences(add, namespace, codeQualityBindings.Items)
+	}
+
+	pipelineConfigs, err := devopsClient.DevopsV1alpha1().PipelineConfigs(namespace.ToRequestParam()).List(api.ListEverything)
+	errs, criticalError = errors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	nonCriticalErrors = append(nonCriticalErrors, errs...)
+	if pipelineConfigs != nil {
+		for _, item := range pipelineConfigs.Items {
+			if !namespace.Matches(item.Namespace) || item.Spec.Source.Secret == nil {
+				continue
+			}
+			add(item.Spec.Source.Secret.Namespace, item.Spec.Source.Secret.Name, ResourceItem{
+				Name: item.Name, Namespace: item.Namespace, Kind: api.ResourceKindPipelineConfig})
+		}
+	}
+
+	podSpecs, errs, err := getPodSpecs(client, namespace)
+	if err != nil {
+		return nil, nil, err
+	}
+	nonCriticalErrors = append(nonCriticalErrors, errs...)
+	for item, spec := range podSpecs {
+		for _, name := range getPodSecretNames(spec) {
+			add(item.Namespace, name, item)
+		}
+	}
+
+	ingresses, err := client.ExtensionsV1beta1().Ingresses(namespace.ToRequestParam()).List(api.ListEverything)
+	errs, criticalError = errors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	nonCriticalErrors = append(nonCriticalErrors, errs...)
+	if ingresses != nil {
+		addIngressReferences(add, namespace, ingresses.Items)
+	}
+
+	serviceAccounts, err := client.CoreV1().ServiceAccounts(namespace.ToRequestParam()).List(api.ListEverything)
+	errs, criticalError = errors.HandleError(err)
+	if criticalError != nil {
+		return nil, nil, criticalError
+	}
+	nonCriticalErrors = append(nonCriticalErrors, errs...)
+	if serviceAccounts != nil {
+		for _, serviceAccount := range serviceAccounts.Items {
+			if !namespace.Matches(serviceAccount.Namespace) {
+				continue
+			}
+			item := ResourceItem{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace, Kind: resourceKindServiceAccount}
+			for _, secret := range serviceAccount.Secrets {
+				add(secret.Namespace, secret.Name, item)
+			}
+			for _, secret := range serviceAccount.ImagePullSecrets {
+				add("", secret.Name, item)
+			}
+		}
+	}
+
+	for _, items := range references {
+		sort.Slice(items, func(i, j int) bool {
+			if items[i].Kind != items[j].Kind {
+				return items[i].Kind < items[j].Kind
+			}
+			if items[i].Namespace != items[j].Namespace {
+				return items[i].Namespace < items[j].Namespace
+			}
+			return items[i].Name < items[j].Name
+		})
+	}
+	return references, nonCriticalErrors, nil
+}
+
+// addReference records that the resource item references the secret
+type addReference func(secretNamespace, secretName string, item ResourceItem)
+
+// addCodeQualityBindingReferences adds the secrets of the code quality bindings in namespaces
+func addCodeQualityBindingReferences(add addReference, namespace *common.NamespaceQuery, bindings []devopsv1alpha1.CodeQualityBinding) {
+	for i := range bindings {
+		binding := &bindings[i]
+		if !namespace.Matches(binding.Namespace) {
+			continue
+		}
+		add(binding.GetSecretNamespace(), binding.GetSecretName(), ResourceItem{
+			Name: binding.Name, Namespace: binding.Namespace, Kind: api.ResourceKindCodeQualityBinding})
+	}
+}
+
+// addIngressReferences adds the TLS secrets of the ingresses in namespaces, which are always in the namespace
+// of the ingress
+func addIngressReferences(add addReference, namespace *common.NamespaceQuery, ingresses []extensions.Ingress) {
+	for _, ingress := range ingresses {
+		if !namespace.Matches(ingress.Namespace) {
+			continue
+		}
+		item := ResourceItem{Name: ingress.Name, Namespace: ingress.Namespace, Kind: api.ResourceKindIngress}
+		for _, tls := range ingress.Spec.TLS {
+			add(ingress.Namespace, tls.SecretName, item)
+		}
+	}
+}
+
+// getPodSpecs returns the pod specs of the pods and workloads in namespaces
+func getPodSpecs(client kubernetes.Interface, namespace *common.NamespaceQuery) (map[ResourceItem]*v1.PodSpec, []error, error) {
+	var (
+		specs             = make(map[ResourceItem]*v1.PodSpec)
+		nonCriticalErrors = make([]error, 0)
+		requestNamespace  = namespace.ToRequestParam()
+	)
+	handleError := func(err error) error {
+		errs, criticalError := errors.HandleError(err)
+		nonCriticalErrors = append(nonCriticalErrors, errs...)
+		return criticalError
+	}
+	add := func(kind string, meta metaV1.ObjectMeta, spec *v1.PodSpec) {
+		if namespace.Matches(meta.Namespace) {
+			specs[ResourceItem{Name: meta.Name, Namespace: meta.Namespace, Kind: kind}] = spec
+		}
+	}
+
+	pods, err := client.CoreV1().Pods(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if pods != nil {
+		for i := range pods.Items {
+			add(api.ResourceKindPod, pods.Items[i].ObjectMeta, &pods.Items[i].Spec)
+		}
+	}
+
+	deployments, err := client.AppsV1().Deployments(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if deployments != nil {
+		for i := range deployments.Items {
+			add(api.ResourceKindDeployment, deployments.Items[i].ObjectMeta, &deployments.Items[i].Spec.Template.Spec)
+		}
+	}
+
+	statefulSets, err := client.AppsV1().StatefulSets(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if statefulSets != nil {
+		for i := range statefulSets.Items {
+			add(api.ResourceKindStatefulSet, statefulSets.Items[i].ObjectMeta, &statefulSets.Items[i].Spec.Template.Spec)
+		}
+	}
+
+	daemonSets, err := client.AppsV1().DaemonSets(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if daemonSets != nil {
+		for i := range daemonSets.Items {
+			add(api.ResourceKindDaemonSet, daemonSets.Items[i].ObjectMeta, &daemonSets.Items[i].Spec.Template.Spec)
+		}
+	}
+
+	jobs, err := client.BatchV1().Jobs(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if jobs != nil {
+		for i := range jobs.Items {
+			add(api.ResourceKindJob, jobs.Items[i].ObjectMeta, &jobs.Items[i].Spec.Template.Spec)
+		}
+	}
+
+	cronJobs, err := client.BatchV1beta1().CronJobs(requestNamespace).List(api.ListEverything)
+	if err := handleError(err); err != nil {
+		return nil, nil, err
+	}
+	if cronJobs != nil {
+		for i := range cronJobs.Items {
+			add(api.ResourceKindCronJob, cronJobs.Items[i].ObjectMeta, &cronJobs.Items[i].Spec.JobTemplate.Spec.Template.Spec)
+		}
+	}
+	return specs, nonCriticalErrors, nil
+}
+
+// getPodSecretNames returns the names of the secrets mounted, injected or pulled with by the pod
+func getPodSecretNames(spec *v1.PodSpec) []string {
+	names := make([]string, 0)
+	for _, secret := range spec.ImagePullSecrets {
+		names = append(names, secret.Name)
+	}
+	for _, volume := range spec.Volumes {
+		if volume.Secret != nil {
+			names = append(names, volume.Secret.SecretName)
+		}
+		if volume.Projected != nil {
+			for _, source := range volume.Projected.Sources {
+				if source.Secret != nil {
+					names = append(names, source.Secret.Name)
+				}
+			}
+		}
+	}
+	containers := append(append([]v1.Container{}, spec.InitContainers...), spec.Containers...)
+	for _, container := range containers {
+		for _, env := range container.Env {
+			if env.ValueFrom != nil && env.ValueFrom.SecretKeyRef != nil {
+				names = append(names, env.ValueFrom.SecretKeyRef.Name)
+			}
+		}
+		for _, envFrom := range container.EnvFrom {
+			if envFrom.SecretRef != nil {
+				names = append(names, envFrom.SecretRef.Name)
+			}
+		}
+	}
+	return names
+}
@@ -0,0 +1,130 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package secret
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	extensions "k8s.io/api/extensions/v1beta1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+type recordedReference struct {
+	secretNamespace string
+	secretName      string
+	item            ResourceItem
+}
+
+// recordReferences returns an addReference recording its calls in references
+func recordReferences(references *[]recordedReference) addReference {
+	return func(secretNamespace, secretName string, item ResourceItem) {
+		*references = append(*references, recordedReference{secretNamespace, secretName, item})
+	}
+}
+
+func TestAddCodeQualityBindingReferences(t *testing.T) {
+	bindings := make([]devopsv1alpha1.CodeQualityBinding, 0)
+	raw := `[
+		{"metadata":{"name":"sonar","namespace":"ns1"},"spec":{"secret":{"name":"sonar-token","namespace":"global-credentials"}}},
+		{"metadata":{"name":"sonar","namespace":"ns2"},"spec":{"secret":{"name":"sonar-token","namespace":"ns2"}}}
+	]`
+	if err := json.Unmarshal([]byte(raw), &bindings); err != nil {
+		t.Fatalf("unmarshal code quality bindings: %v", err)
+	}
+
+	cases := []struct {
+		info      string
+		namespace *common.NamespaceQuery
+		expected  []recordedReference
+	}{
+		{
+			"all namespaces",
+			common.NewNamespaceQuery(nil),
+			[]recordedReference{
+				{"global-credentials", "sonar-token", ResourceItem{Name: "sonar", Namespace: "ns1", Kind: api.ResourceKindCodeQualityBinding}},
+				{"ns2", "sonar-token", ResourceItem{Name: "sonar", Namespace: "ns2", Kind: api.ResourceKindCodeQualityBinding}},
+			},
+		},
+		{
+			"bindings of other namespaces are skipped",
+			common.NewSameNamespaceQuery("ns2"),
+			[]recordedReference{
+				{"ns2", "sonar-token", ResourceItem{Name: "sonar", Namespace: "ns2", Kind: api.ResourceKindCodeQualityBinding}},
+			},
+		},
+	}
+
+	for _, c := range cases {
+		references := make([]recordedReference, 0)
+		addCodeQualityBindingReferences(recordReferences(&references), c.namespace, bindings)
+		if !reflect.DeepEqual(references, c.expected) {
+			t.Errorf("Test Case: %s. addCodeQualityBindingReferences() added %v, expected %v", c.info, references, c.expected)
+		}
+	}
+}
+
+func TestAddIngressReferences(t *testing.T) {
+	ingresses := []extensions.Ingress{
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "ns1"},
+			Spec: extensions.IngressSpec{TLS: []extensions.IngressTLS{
+				{Hosts: []string{"a.example.com"}, SecretName: "tls-a"},
+				{Hosts: []string{"b.example.com"}, SecretName: "tls-b"},
+			}},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "plain", Namespace: "ns1"},
+		},
+		{
+			ObjectMeta: metaV1.ObjectMeta{Name: "web", Namespace: "ns2"},
+			Spec:       extensions.IngressSpec{TLS: []extensions.IngressTLS{{SecretName: "tls-a"}}},
+		},
+	}
+	web := ResourceItem{Name: "web", Namespace: "ns1", Kind: api.ResourceKindIngress}
+
+	cases := []struct {
+		info      string
+		namespace *common.NamespaceQuery
+		expected  []recordedReference
+	}{
+		{
+			"all namespaces",
+			common.NewNamespaceQuery(nil),
+			[]recordedReference{
+				{"ns1", "tls-a", web},
+				{"ns1", "tls-b", web},
+				{"ns2", "tls-a", ResourceItem{Name: "web", Namespace: "ns2", Kind: api.ResourceKindIngress}},
+			},
+		},
+		{
+			"ingresses of other namespaces are skipped",
+			common.NewSameNamespaceQuery("ns1"),
+			[]recordedReference{{"ns1", "tls-a", web}, {"ns1", "tls-b", web}},
+		},
+	}
+
+	for _, c := range cases {
+		references := make([]recordedReference, 0)
+		addIngressReferences(recordReferences(&references), c.namespace, ingresses)
+		if !reflect.DeepEqual(references, c.expected) {
+			t.Errorf("Test Case: %s. addIngressReferences() added %v, expected %v", c.info, references, c.expected)
+		}
+	}
+}
@@ -35,7 +35,7 @@ func GetStatefulSetPods(client kubernetes.Interface, metricClient metricapi.Metr
 
 	log.Printf("Getting replication controller %s pods in namespace %s", name, namespace)
 
-	pods, err := getRawStatefulSetPods(client, name, namespace)
+	pods, err := GetRawStatefulSetPods(client, name, namespace)
 	if err != nil {
 		return pod.EmptyPodList, err
 	}
@@ -50,8 +50,8 @@ func GetStatefulSetPods(client kubernetes.Interface, metricClient metricapi.Metr
 	return &podList, nil
 }
 
-// getRawStatefulSetPods return array of api pods targeting pet set with given name.
-func getRawStatefulSetPods(client kubernetes.Interface, name, namespace string) ([]v1.Pod, error) {
+// GetRawStatefulSetPods return array of api pods targeting pet set with given name.
+func GetRawStatefulSetPods(client kubernetes.Interface, name, namespace string) ([]v1.Pod, error) {
 	statefulSet, err := client.AppsV1beta1().StatefulSets(namespace).Get(name, api.GetOptionsInCache)
 	if err != nil {
 		return nil, err
@@ -71,7 +71,7 @@ func getRawStatefulSetPods(client kubernetes.Interface, name, namespace string)
 
 // Returns simple info about pods(running, desired, failing, etc.) related to given pet set.
 func getStatefulSetPodInfo(client kubernetes.Interface, statefulSet *apps.StatefulSet) (*common.PodInfo, error) {
-	pods, err := getRawStatefulSetPods(client, statefulSet.Name, statefulSet.Namespace)
+	pods, err := GetRawStatefulSetPods(client, statefulSet.Name, statefulSet.Namespace)
 	if err != nil {
 		return nil, err
 	}
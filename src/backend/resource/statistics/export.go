@@ -0,0 +1,83 @@
+package statistics
+
+import (
+	"sort"
+
+	"alauda.io/diablo/src/backend/resource/export"
+)
+
+// PipelineStatisticsColumns contains the columns of exported pipeline statistics, durations are in milliseconds
+var PipelineStatisticsColumns = []string{
+	"time", "succ", "failed", "total",
+	"durationMean", "durationP50", "durationP90",
+	"queueTimeMean", "queueTimeP50", "queueTimeP90",
+	"mttrCount", "mttrMean",
+}
+
+// StageStatisticsColumns contains the columns of exported stage statistics
+var StageStatisticsColumns = []string{"name", "succ", "failed", "total"}
+
+// CodeQualityStatisticsColumns contains the columns of exported code quality statistics
+var CodeQualityStatisticsColumns = []string{"metric", "level", "count"}
+
+// codeQualityStatusMetric is the metric of the rows with the quality gate statuses of the projects
+const codeQualityStatusMetric = "status"
+
+// ExportPipelineStatistics writes a row for every time bucket of the statistics
+func ExportPipelineStatistics(writer export.Writer, result *PipelineStatistics) error {
+	for _, data := range result.Data {
+		err := writer.WriteRow([]interface{}{
+			data.Time, data.Succ, data.Failed, data.Total,
+			data.Duration.Mean, data.Duration.P50, data.Duration.P90,
+			data.QueueTime.Mean, data.QueueTime.P50, data.QueueTime.P90,
+			data.MTTR.Count, data.MTTR.Mean,
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ExportStageStatistics writes a row for every stage of the statistics
+func ExportStageStatistics(writer export.Writer, result *StageStatistics) error {
+	for _, data := range result.Data {
+		if err := writer.WriteRow([]interface{}{data.Name, data.Succ, data.Failed, data.Total}); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
+
+// ExportCodeQualityStatistics writes a row for every quality gate status of the projects followed by a row
+// for every level of every metric, metrics and levels are sorted by name
+func ExportCodeQualityStatistics(writer export.Writer, result CodeQualityStatistics) error {
+	rows := [][]interface{}{
+		{codeQualityStatusMetric, "ok", result.OK},
+		{codeQualityStatusMetric, "warn", result.Warn},
+		{codeQualityStatusMetric, "error", result.Error},
+	}
+
+	metrics := make([]string, 0, len(result.MetricSummary))
+	for metric := range result.MetricSummary {
+		metrics = append(metrics, metric)
+	}
+	sort.Strings(metrics)
+	for _, metric := range metrics {
+		levels := make([]string, 0, len(result.MetricSummary[metric].Levels))
+		for level := range result.MetricSummary[metric].Levels {
+			levels = append(levels, level)
+		}
+		sort.Strings(levels)
+		for _, level := range levels {
+			rows = append(rows, []interface{}{metric, level, result.MetricSummary[metric].Levels[level]})
+		}
+	}
+
+	for _, row := range rows {
+		if err := writer.WriteRow(row); err != nil {
+			return err
+		}
+	}
+	return writer.Flush()
+}
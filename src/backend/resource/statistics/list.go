@@ -15,7 +15,6 @@ import (
 	"sort"
 
 	"github.com/golang/glog"
-	"github.com/jinzhu/now"
 )
 
 // Statistics defines the number of successes/failures/total
@@ -28,15 +27,48 @@ type Statistics struct {
 // PipelineStatistics defines the statistics of pipeline
 type PipelineStatistics struct {
 	Data []PipelineStatisticsData `json:"data"`
+	PipelineMetrics
+
+	// ByPipelineConfig and ByTrigger are only filled when the breakdown is requested
+	ByPipelineConfig []PipelineStatisticsBreakdown `json:"byPipelineConfig,omitempty"`
+	ByTrigger        []PipelineStatisticsBreakdown `json:"byTrigger,omitempty"`
+}
+
+// PipelineMetrics defines the number of successes/failures/total together with
+// the distribution of durations, queue time and time to recovery
+type PipelineMetrics struct {
 	Statistics
+	Duration  DurationStatistics `json:"duration"`
+	QueueTime DurationStatistics `json:"queueTime"`
+	// MTTR is the time from a failure to the next success of the same pipeline config,
+	// a recovery is counted where the successful pipeline belongs
+	MTTR DurationStatistics `json:"mttr"`
 }
 
 // PipelineStatisticsData defines data in PipelineStatistics
 type PipelineStatisticsData struct {
 	Time time.Time `json:"time"`
-	Statistics
+	PipelineMetrics
+}
+
+// PipelineStatisticsBreakdown defines metrics of pipelines grouped by name
+type PipelineStatisticsBreakdown struct {
+	Name string `json:"name"`
+	PipelineMetrics
+}
+
+// PipelineStatisticsOptions defines how pipeline statistics are grouped
+type PipelineStatisticsOptions struct {
+	Granularity Granularity
+	// Breakdowns contains the breakdowns to calculate: pipelineconfig, trigger
+	Breakdowns []string
 }
 
+const (
+	BreakdownPipelineConfig = "pipelineconfig"
+	BreakdownTrigger        = "trigger"
+)
+
 // StageStatistics defines the statistics of stage
 type StageStatistics struct {
 	Data []StageStatisticsData `json:"data"`
@@ -61,7 +93,7 @@ type CodeQualityMetricStatistics struct {
 }
 
 // GetPipelineStatistics get pipeline statistics
-func GetPipelineStatistics(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery, startTime, endTime time.Time) (result *PipelineStatistics, err error) {
+func GetPipelineStatistics(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery, startTime, endTime time.Time, options PipelineStatisticsOptions) (result *PipelineStatistics, err error) {
 	glog.V(7).Infof("get pipeline statistics between %s and %s", startTime, endTime)
 	result = &PipelineStatistics{
 		Data: make([]PipelineStatisticsData, 0),
@@ -81,20 +113,109 @@ func GetPipelineStatistics(client devopsclient.Interface, namespace *common.Name
 		return
 	}
 
-	timePool := make(map[time.Time]*Statistics, 0)
-	parsePipelinesToTimePool(pipelineList.Items, startTime, endTime, result, timePool)
-	parseTimePoolToResult(timePool, result, startTime, endTime)
+	result = getPipelineStatistics(pipelineList.Items, startTime, endTime, options)
+	return
+}
+
+// getPipelineStatistics calculates pipeline statistics of the pipelines
+func getPipelineStatistics(pipelines []pipeline.Pipeline, startTime, endTime time.Time, options PipelineStatisticsOptions) (result *PipelineStatistics) {
+	result = &PipelineStatistics{
+		Data: make([]PipelineStatisticsData, 0),
+	}
+	if !startTime.Before(endTime) {
+		return
+	}
+
+	finished := filterFinishedPipelines(pipelines, startTime, endTime)
+	recoveries := getRecoveries(finished)
+
+	all := &pipelineGroup{pipelines: finished, recoveries: recoveries}
+	result.PipelineMetrics = all.metrics()
+
+	timePool := make(map[time.Time]*pipelineGroup, 0)
+	parsePipelinesToTimePool(all, options.Granularity, timePool)
+	parseTimePoolToResult(timePool, result, options.Granularity, startTime, endTime)
+
+	for _, breakdown := range options.Breakdowns {
+		switch breakdown {
+		case BreakdownPipelineConfig:
+			result.ByPipelineConfig = getBreakdown(all, func(pipe *pipeline.Pipeline) string {
+				return pipe.Spec.PipelineConfig.Name
+			})
+		case BreakdownTrigger:
+			result.ByTrigger = getBreakdown(all, func(pipe *pipeline.Pipeline) string {
+				return string(pipe.Spec.Cause.Type)
+			})
+		}
+	}
 
 	return
 }
 
-// parsePipelinesToTimePool parse pipelines to time pool
-func parsePipelinesToTimePool(pipelines []pipeline.Pipeline, startTime time.Time, endTime time.Time, result *PipelineStatistics, timePool map[time.Time]*Statistics) {
-	var (
-		succTotal, failedTotal, total = 0, 0, 0
-	)
+// pipelineGroup defines finished pipelines and recoveries which are counted together
+type pipelineGroup struct {
+	pipelines  []*pipeline.Pipeline
+	recoveries []recovery
+}
+
+// recovery defines a successful pipeline which follows failed ones of the same pipeline config
+type recovery struct {
+	pipeline *pipeline.Pipeline
+	// duration from the first failure to the success
+	duration time.Duration
+}
+
+// metrics calculates metrics of the pipelines in the group
+func (g *pipelineGroup) metrics() (result PipelineMetrics) {
+	durations := make([]time.Duration, 0, len(g.pipelines))
+	queueTimes := make([]time.Duration, 0, len(g.pipelines))
+	for _, pipe := range g.pipelines {
+		result.Total++
+		if pipe.Status.Phase == devopsv1alpha1.PipelinePhaseComplete {
+			result.Succ++
+		} else {
+			result.Failed++
+		}
+
+		if pipe.Status.StartedAt == nil {
+			continue
+		}
+		startedAt := pipe.Status.StartedAt.Time
+		durations = append(durations, pipe.Status.FinishedAt.Time.Sub(startedAt))
+		if !pipe.ObjectMeta.CreationTimestamp.IsZero() && !startedAt.Before(pipe.ObjectMeta.CreationTimestamp.Time) {
+			queueTimes = append(queueTimes, startedAt.Sub(pipe.ObjectMeta.CreationTimestamp.Time))
+		}
+	}
+
+	recoveryTimes := make([]time.Duration, 0, len(g.recoveries))
+	for _, r := range g.recoveries {
+		recoveryTimes = append(recoveryTimes, r.duration)
+	}
+
+	result.Duration = getDurationStatistics(durations)
+	result.QueueTime = getDurationStatistics(queueTimes)
+	result.MTTR = getDurationStatistics(recoveryTimes)
+	return
+}
+
+// split adds every pipeline and recovery of the group to the group returned by getGroup
+func (g *pipelineGroup) split(getGroup func(pipe *pipeline.Pipeline) *pipelineGroup) {
+	for _, pipe := range g.pipelines {
+		group := getGroup(pipe)
+		group.pipelines = append(group.pipelines, pipe)
+	}
+	for _, r := range g.recoveries {
+		group := getGroup(r.pipeline)
+		group.recoveries = append(group.recoveries, r)
+	}
+}
+
+// filterFinishedPipelines returns the pipelines which finished in the time range ordered by finish time
+func filterFinishedPipelines(pipelines []pipeline.Pipeline, startTime time.Time, endTime time.Time) []*pipeline.Pipeline {
 	glog.V(7).Infof("pipeline count: %d", len(pipelines))
-	for _, pipe := range pipelines {
+	result := make([]*pipeline.Pipeline, 0, len(pipelines))
+	for i := range pipelines {
+		pipe := &pipelines[i]
 		// skip the pipeline which is not finished
 		if !pipe.Status.Phase.IsFinalPhase() || pipe.Status.FinishedAt == nil {
 			glog.V(7).Infof("pipeline %s is not finished", pipe.GetObjectMeta().Name)
@@ -107,45 +228,97 @@ func parsePipelinesToTimePool(pipelines []pipeline.Pipeline, startTime time.Time
 			glog.V(7).Infof("pipeline %s is not in the time range", pipe.GetObjectMeta().Name)
 			continue
 		}
+		result = append(result, pipe)
+	}
 
-		total++
-		beginningOfHour := now.New(finishedAt).BeginningOfHour()
-		if val, ok := timePool[beginningOfHour]; !ok || val == nil {
-			timePool[beginningOfHour] = &Statistics{}
-		}
+	sort.SliceStable(result, func(i, j int) bool {
+		return result[i].Status.FinishedAt.Time.Before(result[j].Status.FinishedAt.Time)
+	})
+	return result
+}
 
-		if pipe.Status.Phase == devopsv1alpha1.PipelinePhaseComplete {
-			succTotal++
-			timePool[beginningOfHour].Succ++
-			timePool[beginningOfHour].Total++
+// getRecoveries finds the first successful pipeline after failed ones of every pipeline config.
+// Pipelines have to be ordered by finish time. Failures before the time range are not known,
+// so only the recoveries which started in the time range are found.
+func getRecoveries(pipelines []*pipeline.Pipeline) []recovery {
+	result := make([]recovery, 0)
+	failedSince := make(map[string]time.Time, 0)
+	for _, pipe := range pipelines {
+		key := pipe.ObjectMeta.Namespace + "/" + pipe.Spec.PipelineConfig.Name
+		finishedAt := pipe.Status.FinishedAt.Time
+
+		if pipe.Status.Phase != devopsv1alpha1.PipelinePhaseComplete {
+			if _, ok := failedSince[key]; !ok {
+				failedSince[key] = finishedAt
+			}
 			continue
 		}
 
-		failedTotal++
-		timePool[beginningOfHour].Failed++
-		timePool[beginningOfHour].Total++
+		if since, ok := failedSince[key]; ok {
+			result = append(result, recovery{pipeline: pipe, duration: finishedAt.Sub(since)})
+			delete(failedSince, key)
+		}
 	}
-	result.Succ = succTotal
-	result.Failed = failedTotal
-	result.Total = total
-	return
+	return result
+}
+
+// parsePipelinesToTimePool parse pipelines to time pool
+func parsePipelinesToTimePool(all *pipelineGroup, granularity Granularity, timePool map[time.Time]*pipelineGroup) {
+	getGroup := func(pipe *pipeline.Pipeline) *pipelineGroup {
+		beginning := granularity.beginningOf(pipe.Status.FinishedAt.Time)
+		if val, ok := timePool[beginning]; !ok || val == nil {
+			timePool[beginning] = &pipelineGroup{}
+		}
+		return timePool[beginning]
+	}
+
+	all.split(getGroup)
 }
 
 // parseTimePoolToResult parse time pool to result
-func parseTimePoolToResult(timePool map[time.Time]*Statistics, result *PipelineStatistics, startTime, endTime time.Time) {
-	step := 1 * time.Hour
-	for i := startTime; i.Before(endTime); i = i.Add(step) {
+func parseTimePoolToResult(timePool map[time.Time]*pipelineGroup, result *PipelineStatistics, granularity Granularity, startTime, endTime time.Time) {
+	for i := granularity.beginningOf(startTime); !i.After(endTime); i = granularity.next(i) {
 		data := PipelineStatisticsData{
-			Time: now.New(i).BeginningOfHour(),
+			Time: i,
 		}
 
 		if val, ok := timePool[data.Time]; ok {
-			data.Statistics = *val
+			data.PipelineMetrics = val.metrics()
 		}
 		result.Data = append(result.Data, data)
 	}
 }
 
+// getBreakdown groups pipelines by the key and calculates metrics of every group
+func getBreakdown(all *pipelineGroup, key func(pipe *pipeline.Pipeline) string) []PipelineStatisticsBreakdown {
+	groups := make(map[string]*pipelineGroup, 0)
+	getGroup := func(pipe *pipeline.Pipeline) *pipelineGroup {
+		name := key(pipe)
+		if val, ok := groups[name]; !ok || val == nil {
+			groups[name] = &pipelineGroup{}
+		}
+		return groups[name]
+	}
+
+	all.split(getGroup)
+
+	result := make([]PipelineStatisticsBreakdown, 0, len(groups))
+	for name, group := range groups {
+		result = append(result, PipelineStatisticsBreakdown{
+			Name:            name,
+			PipelineMetrics: group.metrics(),
+		})
+	}
+
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Total != result[j].Total {
+			return result[i].Total > result[j].Total
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result
+}
+
 // GetStageStatistics get stage statistics
 func GetStageStatistics(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery, startTime, endTime time.Time) (result *StageStatistics, err error) {
 	glog.V(5).Infof("get stage statistics between %s and %s", startTime, endTime)
@@ -167,13 +340,26 @@ func GetStageStatistics(client devopsclient.Interface, namespace *common.Namespa
 		glog.V(7).Infof("no pipelines in this period")
 		return
 	}
-	glog.V(7).Infof("pipeline's count: %d", len(pipelineList.Items))
+
+	result = getStageStatistics(pipelineList.Items, startTime, endTime)
+	return
+}
+
+// getStageStatistics calculates stage statistics of the pipelines
+func getStageStatistics(pipelines []pipeline.Pipeline, startTime, endTime time.Time) (result *StageStatistics) {
+	result = &StageStatistics{
+		Data: make([]StageStatisticsData, 0),
+	}
+	if !startTime.Before(endTime) {
+		return
+	}
+	glog.V(7).Infof("pipeline's count: %d", len(pipelines))
 
 	var (
 		stagePool = &sync.Map{}
 		wg        sync.WaitGroup
 	)
-	for _, pipe := range pipelineList.Items {
+	for _, pipe := range pipelines {
 		// skip the pipeline which is not finished
 		if !pipe.Status.Phase.IsFinalPhase() || pipe.Status.FinishedAt == nil {
 			glog.V(7).Infof("pipeline %s is not finished", pipe.GetObjectMeta().Name)
@@ -285,7 +471,11 @@ func GetCodeQualityStatistics(devopsClient devopsclient.Interface, namespace *co
 		return
 	}
 
-	projects := codeQualityProjectList.Items
+	return getCodeQualityStatistics(codeQualityProjectList.Items)
+}
+
+// getCodeQualityStatistics calculates code quality statistics of the projects
+func getCodeQualityStatistics(projects []codequalityproject.CodeQualityProject) (result CodeQualityStatistics, err error) {
 	projectNumber := len(projects)
 	if projectNumber <= 10 {
 		result, err = parseProjectsToCodeQualityStatistics(projects)
@@ -0,0 +1,96 @@
+package statistics
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"github.com/jinzhu/now"
+)
+
+// Granularity defines the size of time buckets of pipeline statistics
+type Granularity string
+
+const (
+	GranularityHour Granularity = "hour"
+	GranularityDay  Granularity = "day"
+	GranularityWeek Granularity = "week"
+)
+
+// ParseGranularity parses granularity, empty value means hour
+func ParseGranularity(value string) (Granularity, error) {
+	switch Granularity(value) {
+	case "", GranularityHour:
+		return GranularityHour, nil
+	case GranularityDay, GranularityWeek:
+		return Granularity(value), nil
+	}
+	return "", fmt.Errorf("unsupported granularity '%s', should be one of: hour, day, week", value)
+}
+
+// beginningOf returns the beginning of the bucket which t belongs to
+func (g Granularity) beginningOf(t time.Time) time.Time {
+	switch g {
+	case GranularityDay:
+		return now.New(t).BeginningOfDay()
+	case GranularityWeek:
+		return now.New(t).BeginningOfWeek()
+	}
+	return now.New(t).BeginningOfHour()
+}
+
+// next returns the beginning of the bucket after the one starting at t
+func (g Granularity) next(t time.Time) time.Time {
+	switch g {
+	case GranularityDay:
+		return t.AddDate(0, 0, 1)
+	case GranularityWeek:
+		return t.AddDate(0, 0, 7)
+	}
+	return t.Add(time.Hour)
+}
+
+// DurationStatistics defines the distribution of durations, all values are in milliseconds
+type DurationStatistics struct {
+	Count int   `json:"count"`
+	Mean  int64 `json:"mean"`
+	P50   int64 `json:"p50"`
+	P90   int64 `json:"p90"`
+}
+
+// getDurationStatistics calculates the distribution of durations
+func getDurationStatistics(durations []time.Duration) (result DurationStatistics) {
+	result.Count = len(durations)
+	if result.Count == 0 {
+		return
+	}
+
+	sorted := make([]time.Duration, len(durations))
+	copy(sorted, durations)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	var sum time.Duration
+	for _, d := range sorted {
+		sum += d
+	}
+	result.Mean = toMillis(sum / time.Duration(len(sorted)))
+	result.P50 = toMillis(percentile(sorted, 50))
+	result.P90 = toMillis(percentile(sorted, 90))
+	return
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func toMillis(d time.Duration) int64 {
+	return int64(d / time.Millisecond)
+}
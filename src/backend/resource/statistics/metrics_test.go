@@ -0,0 +1,76 @@
+package statistics
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestParseGranularity(t *testing.T) {
+	cases := []struct {
+		value    string
+		expected Granularity
+		err      bool
+	}{
+		{"", GranularityHour, false},
+		{"hour", GranularityHour, false},
+		{"day", GranularityDay, false},
+		{"week", GranularityWeek, false},
+		{"month", "", true},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseGranularity(c.value)
+		if (err != nil) != c.err {
+			t.Errorf("ParseGranularity(%q) returned error %v", c.value, err)
+		}
+		if actual != c.expected {
+			t.Errorf("ParseGranularity(%q) == %q, expected %q", c.value, actual, c.expected)
+		}
+	}
+}
+
+func TestGranularityBuckets(t *testing.T) {
+	// 2018-01-03 is a Wednesday
+	at := time.Date(2018, 1, 3, 10, 30, 0, 0, time.UTC)
+	cases := []struct {
+		granularity Granularity
+		beginning   time.Time
+		next        time.Time
+	}{
+		{GranularityHour, time.Date(2018, 1, 3, 10, 0, 0, 0, time.UTC), time.Date(2018, 1, 3, 11, 0, 0, 0, time.UTC)},
+		{GranularityDay, time.Date(2018, 1, 3, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 4, 0, 0, 0, 0, time.UTC)},
+		{GranularityWeek, time.Date(2017, 12, 31, 0, 0, 0, 0, time.UTC), time.Date(2018, 1, 7, 0, 0, 0, 0, time.UTC)},
+	}
+
+	for _, c := range cases {
+		beginning := c.granularity.beginningOf(at)
+		if !beginning.Equal(c.beginning) {
+			t.Errorf("%s beginningOf(%s) == %s, expected %s", c.granularity, at, beginning, c.beginning)
+		}
+		if next := c.granularity.next(beginning); !next.Equal(c.next) {
+			t.Errorf("%s next(%s) == %s, expected %s", c.granularity, beginning, next, c.next)
+		}
+	}
+}
+
+func TestGetDurationStatistics(t *testing.T) {
+	cases := []struct {
+		durations []time.Duration
+		expected  DurationStatistics
+	}{
+		{nil, DurationStatistics{}},
+		{[]time.Duration{time.Second}, DurationStatistics{Count: 1, Mean: 1000, P50: 1000, P90: 1000}},
+		{
+			[]time.Duration{10 * time.Second, time.Second, 4 * time.Second, 2 * time.Second, 3 * time.Second},
+			DurationStatistics{Count: 5, Mean: 4000, P50: 3000, P90: 10000},
+		},
+	}
+
+	for _, c := range cases {
+		actual := getDurationStatistics(c.durations)
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("getDurationStatistics(%v) == %+v, expected %+v", c.durations, actual, c.expected)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package statistics
+
+import (
+	"sync"
+	"time"
+
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/resource/codequalityproject"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/pipeline"
+
+	"github.com/golang/glog"
+)
+
+// ProjectStatistics defines the statistics of every namespace of a project and the combined statistics
+type ProjectStatistics struct {
+	Namespaces []NamespaceStatistics `json:"namespaces"`
+	// Combined is calculated from the resources of all namespaces, not by merging the namespace results
+	Combined RollupStatistics `json:"combined"`
+
+	// List of non-critical errors, that occurred during retrieval of the namespaces of the project.
+	Errors []error `json:"errors"`
+}
+
+// NamespaceStatistics defines the statistics of a single namespace
+type NamespaceStatistics struct {
+	Namespace string `json:"namespace"`
+	RollupStatistics
+
+	// List of errors, that occurred during resource retrieval of the namespace.
+	Errors []error `json:"errors"`
+}
+
+// RollupStatistics defines the pipeline, stage and code quality statistics together
+type RollupStatistics struct {
+	Pipeline    *PipelineStatistics   `json:"pipeline"`
+	Stage       *StageStatistics      `json:"stage"`
+	CodeQuality CodeQualityStatistics `json:"codeQuality"`
+}
+
+// namespaceResources defines the resources of a namespace which statistics are calculated from
+type namespaceResources struct {
+	pipelines []pipeline.Pipeline
+	projects  []codequalityproject.CodeQualityProject
+	errors    []error
+}
+
+// GetProjectStatistics get statistics of every namespace in the query and the combined statistics of all of them.
+// Namespaces are fetched concurrently, errors of a namespace are reported in its result and do not fail the others.
+func GetProjectStatistics(client devopsclient.Interface, namespace *common.NamespaceQuery, dsQuery *dataselect.DataSelectQuery, startTime, endTime time.Time, options PipelineStatisticsOptions) (result *ProjectStatistics, err error) {
+	namespaces := namespace.Namespaces()
+	glog.V(5).Infof("get project statistics of namespaces %v between %s and %s", namespaces, startTime, endTime)
+
+	resources := make([]namespaceResources, len(namespaces))
+	var wg sync.WaitGroup
+	for i, ns := range namespaces {
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+			resources[i] = getNamespaceResources(client, ns, dsQuery)
+		}(i, ns)
+	}
+	wg.Wait()
+
+	result = &ProjectStatistics{
+		Namespaces: make([]NamespaceStatistics, len(namespaces)),
+		Errors:     make([]error, 0),
+	}
+	var (
+		allPipelines = make([]pipeline.Pipeline, 0)
+		allProjects  = make([]codequalityproject.CodeQualityProject, 0)
+	)
+	for i, ns := range namespaces {
+		wg.Add(1)
+		go func(i int, ns string) {
+			defer wg.Done()
+			result.Namespaces[i] = NamespaceStatistics{
+				Namespace:        ns,
+				RollupStatistics: getRollupStatistics(resources[i].pipelines, resources[i].projects, startTime, endTime, options),
+				Errors:           resources[i].errors,
+			}
+		}(i, ns)
+
+		allPipelines = append(allPipelines, resources[i].pipelines...)
+		allProjects = append(allProjects, resources[i].projects...)
+	}
+	result.Combined = getRollupStatistics(allPipelines, allProjects, startTime, endTime, options)
+	wg.Wait()
+
+	return
+}
+
+// getNamespaceResources lists pipelines and code quality projects of the namespace
+func getNamespaceResources(client devopsclient.Interface, namespace string, dsQuery *dataselect.DataSelectQuery) (result namespaceResources) {
+	result.errors = make([]error, 0)
+	nsQuery := common.NewSameNamespaceQuery(namespace)
+
+	pipelineList, err := pipeline.GetPipelineList(client, nsQuery, dsQuery)
+	if err != nil {
+		glog.Errorf("error happen when get pipelines in namespace %s, err: %v", namespace, err)
+		result.errors = append(result.errors, err)
+	} else {
+		result.pipelines = pipelineList.Items
+		result.errors = append(result.errors, pipelineList.Errors...)
+	}
+
+	projectList, err := codequalityproject.GetCodeQualityProjectList(client, nsQuery, dsQuery)
+	if err != nil {
+		glog.Errorf("error happen when get code quality projects in namespace %s, err: %v", namespace, err)
+		result.errors = append(result.errors, err)
+	} else {
+		result.projects = projectList.Items
+		result.errors = append(result.errors, projectList.Errors...)
+	}
+	return
+}
+
+// getRollupStatistics calculates all statistics of the resources
+func getRollupStatistics(pipelines []pipeline.Pipeline, projects []codequalityproject.CodeQualityProject, startTime, endTime time.Time, options PipelineStatisticsOptions) (result RollupStatistics) {
+	result.Pipeline = getPipelineStatistics(pipelines, startTime, endTime, options)
+	result.Stage = getStageStatistics(pipelines, startTime, endTime)
+
+	codeQuality, err := getCodeQualityStatistics(projects)
+	if err != nil {
+		glog.Errorf("error happen when convert code quality projects to statistics, err: %v", err)
+	}
+	result.CodeQuality = codeQuality
+	return
+}
@@ -0,0 +1,387 @@
+package toolchain
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/codequalitybinding"
+	"alauda.io/diablo/src/backend/resource/codequalitytool"
+	"alauda.io/diablo/src/backend/resource/coderepobinding"
+	"alauda.io/diablo/src/backend/resource/codereposervice"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/imageregistry"
+	"alauda.io/diablo/src/backend/resource/imageregistrybinding"
+	"alauda.io/diablo/src/backend/resource/jenkins"
+	"alauda.io/diablo/src/backend/resource/jenkinsbinding"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	statusPhaseReady = devopsv1alpha1.ServiceStatusPhase("Ready")
+	statusPhaseError = devopsv1alpha1.ServiceStatusPhase("Error")
+	conditionError   = "Error"
+
+	// BindingCheckTimeout is how long a check of a binding waits for its tool
+	BindingCheckTimeout = 30 * time.Second
+)
+
+// HealthStatus is the last-known connectivity and authorization status of a tool or a binding, as reported
+// by the conditions of the resource
+type HealthStatus struct {
+	Phase   string `json:"phase"`
+	Healthy bool   `json:"healthy"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+	// LastChecked is the time the status was last updated
+	LastChecked *metaV1.Time `json:"lastChecked,omitempty"`
+	// Age is the number of seconds since LastChecked
+	Age int64 `json:"age"`
+	// Conditions contains the failing conditions of the resource
+	Conditions []HealthCondition `json:"conditions,omitempty"`
+}
+
+// HealthCondition is a failing condition of a tool or a binding
+type HealthCondition struct {
+	Type        string       `json:"type"`
+	Name        string       `json:"name,omitempty"`
+	Status      string       `json:"status"`
+	Reason      string       `json:"reason,omitempty"`
+	Message     string       `json:"message,omitempty"`
+	LastAttempt *metaV1.Time `json:"lastAttempt,omitempty"`
+}
+
+// ToolHealth is the health of a tool of the toolchain with the namespaces of its broken bindings
+type ToolHealth struct {
+	Kind   string       `json:"kind"`
+	Name   string       `json:"name"`
+	Type   string       `json:"type,omitempty"`
+	Status HealthStatus `json:"status"`
+	// Bindings is the number of bindings of the tool
+	Bindings       int `json:"bindings"`
+	BrokenBindings int `json:"brokenBindings"`
+	// BrokenNamespaces are the namespaces with broken bindings of the tool
+	BrokenNamespaces []string `json:"brokenNamespaces"`
+}
+
+// BindingHealth is the health of a binding of a tool in a namespace
+type BindingHealth struct {
+	Kind      string       `json:"kind"`
+	Namespace string       `json:"namespace"`
+	Name      string       `json:"name"`
+	ToolKind  string       `json:"toolKind"`
+	ToolName  string       `json:"toolName"`
+	Status    HealthStatus `json:"status"`
+}
+
+// ToolChainHealth contains the health of the tools of the toolchain and of their bindings
+type ToolChainHealth struct {
+	Tools    []ToolHealth    `json:"tools"`
+	Bindings []BindingHealth `json:"bindings"`
+
+	// List of non-critical errors, that occurred during resource retrieval.
+	Errors []error `json:"errors"`
+}
+
+// HealthCheck is the result of an on-demand check of the secret of a binding against its tool
+type HealthCheck struct {
+	Kind      string      `json:"kind"`
+	Namespace string      `json:"namespace"`
+	Name      string      `json:"name"`
+	Healthy   bool        `json:"healthy"`
+	Message   string      `json:"message,omitempty"`
+	CheckedAt metaV1.Time `json:"checkedAt"`
+	// AuthorizeURL is set when the secret of the binding needs to be authorized again
+	AuthorizeURL string `json:"authorizeUrl,omitempty"`
+}
+
+// GetToolChainHealth returns the health of the tools of the toolchain and of their bindings in namespaces.
+// Only broken bindings are returned when brokenOnly is true.
+func GetToolChainHealth(client devopsclient.Interface, k8sclient kubernetes.Interface, namespaces *common.NamespaceQuery,
+	toolType string, brokenOnly bool) (*ToolChainHealth, error) {
+	log.Println("Getting health of toolchain")
+
+	toolChainList, err := GetToolChainList(client, k8sclient, dataselect.NoDataSelect, toolType)
+	if err != nil {
+		return nil, err
+	}
+	bindingList, err := GetToolChainBindingList(client, k8sclient, dataselect.NoDataSelect, namespaces, toolType)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	health := &ToolChainHealth{
+		Tools:    make([]ToolHealth, 0),
+		Bindings: make([]BindingHealth, 0),
+	}
+	if toolChainList != nil {
+		health.Errors = append(health.Errors, toolChainList.Errors...)
+		for _, item := range toolChainList.Items {
+			if tool, ok := toToolHealth(item, now); ok {
+				health.Tools = append(health.Tools, tool)
+			}
+		}
+	}
+
+	bindings := make([]BindingHealth, 0)
+	if bindingList != nil {
+		health.Errors = append(health.Errors, bindingList.Errors...)
+		for _, item := range bindingList.Items {
+			if binding, ok := toBindingHealth(item, now); ok {
+				bindings = append(bindings, binding)
+			}
+		}
+	}
+
+	for i := range health.Tools {
+		tool := &health.Tools[i]
+		brokenNamespaces := make(map[string]bool)
+		for _, binding := range bindings {
+			if binding.ToolKind != tool.Kind || binding.ToolName != tool.Name {
+				continue
+			}
+			tool.Bindings++
+			if !binding.Status.Healthy {
+				tool.BrokenBindings++
+				brokenNamespaces[binding.Namespace] = true
+			}
+		}
+		tool.BrokenNamespaces = make([]string, 0, len(brokenNamespaces))
+		for namespace := range brokenNamespaces {
+			tool.BrokenNamespaces = append(tool.BrokenNamespaces, namespace)
+		}
+		sort.Strings(tool.BrokenNamespaces)
+	}
+
+	for _, binding := range bindings {
+		if !brokenOnly || !binding.Status.Healthy {
+			health.Bindings = append(health.Bindings, binding)
+		}
+	}
+	return health, nil
+}
+
+// CheckBinding checks again the secret of the binding against its tool. Failing checks and checks which do not
+// finish within BindingCheckTimeout are reported in the result, errors are only returned when the binding
+// cannot be found.
+func CheckBinding(client devopsclient.Interface, namespace, kind, name string) (*HealthCheck, error) {
+	log.Printf("Checking %s %s/%s", kind, namespace, name)
+
+	var authorize func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error)
+	switch kind {
+	case api.ResourceKindJenkinsBinding:
+		binding, err := jenkinsbinding.GetJenkinsBinding(client, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		authorize = func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error) {
+			return jenkins.AuthorizeService(client, binding.Spec.Jenkins.Name, binding.GetSecretName(), binding.GetSecretNamespace())
+		}
+	case api.ResourceKindCodeRepoBinding:
+		binding, err := coderepobinding.GetCodeRepoBinding(client, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		authorize = func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error) {
+			return codereposervice.AuthorizeService(client, binding.Spec.CodeRepoService.Name, binding.GetSecretName(), binding.GetSecretNamespace())
+		}
+	case api.ResourceKindImageRegistryBinding:
+		binding, err := imageregistrybinding.GetImageRegistryBinding(client, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		authorize = func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error) {
+			return imageregistry.AuthorizeService(client, binding.Spec.ImageRegistry.Name, binding.GetSecretName(), binding.GetSecretNamespace())
+		}
+	case api.ResourceKindCodeQualityBinding:
+		binding, err := codequalitybinding.GetCodeQualityBinding(client, namespace, name)
+		if err != nil {
+			return nil, err
+		}
+		authorize = func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error) {
+			return codequalitytool.AuthorizeService(client, binding.Spec.CodeQualityTool.Name, binding.GetSecretName(), binding.GetSecretNamespace())
+		}
+	default:
+		return nil, k8serrors.NewBadRequest(fmt.Sprintf("binding kind %s cannot be checked", kind))
+	}
+
+	check := &HealthCheck{Kind: kind, Namespace: namespace, Name: name, Healthy: true}
+	response, err := authorizeWithTimeout(authorize, BindingCheckTimeout)
+	check.CheckedAt = metaV1.Now()
+	if err != nil {
+		check.Healthy = false
+		check.Message = err.Error()
+	} else if response != nil && response.AuthorizeUrl != "" {
+		check.Healthy = false
+		check.Message = "secret needs to be authorized again"
+		check.AuthorizeURL = response.AuthorizeUrl
+	}
+	return check, nil
+}
+
+// authorizeWithTimeout returns the result of authorize or an error when it does not finish within the timeout.
+// The tool is called through the apiserver, whose client cannot be cancelled, so a late result is dropped.
+func authorizeWithTimeout(authorize func() (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error),
+	timeout time.Duration) (*devopsv1alpha1.CodeRepoServiceAuthorizeResponse, error) {
+	type result struct {
+		response *devopsv1alpha1.CodeRepoServiceAuthorizeResponse
+		err      error
+	}
+	done := make(chan result, 1)
+	go func() {
+		response, err := authorize()
+		done <- result{response: response, err: err}
+	}()
+
+	select {
+	case r := <-done:
+		return r.response, r.err
+	case <-time.After(timeout):
+		return nil, fmt.Errorf("check did not finish within %s", timeout)
+	}
+}
+
+// CheckToolBindings checks again the secrets of all bindings of the tool in namespaces. Bindings are checked
+// by a bounded number of workers.
+func CheckToolBindings(client devopsclient.Interface, k8sclient kubernetes.Interface, namespaces *common.NamespaceQuery,
+	kind, name string) ([]HealthCheck, error) {
+	health, err := GetToolChainHealth(client, k8sclient, namespaces, "", false)
+	if err != nil {
+		return nil, err
+	}
+
+	found := false
+	for _, tool := range health.Tools {
+		found = found || (tool.Kind == kind && tool.Name == name)
+	}
+	if !found {
+		return nil, k8serrors.NewNotFound(schema.GroupResource{Resource: kind}, name)
+	}
+
+	bindings := make([]BindingHealth, 0)
+	targets := make([]common.BatchTarget, 0)
+	for _, binding := range health.Bindings {
+		if binding.ToolKind == kind && binding.ToolName == name {
+			bindings = append(bindings, binding)
+			targets = append(targets, common.BatchTarget{Namespace: binding.Namespace, Name: binding.Name})
+		}
+	}
+	// every tool has a single kind of bindings, so the target identifies the binding
+	bindingKinds := make(map[common.BatchTarget]string, len(bindings))
+	for i, binding := range bindings {
+		bindingKinds[targets[i]] = binding.Kind
+	}
+	batch := common.RunBatch(targets, common.BatchOptions{}, func(target common.BatchTarget) (interface{}, error) {
+		return CheckBinding(client, target.Namespace, bindingKinds[target], target.Name)
+	})
+
+	checks := make([]HealthCheck, 0, len(bindings))
+	for i, item := range batch.Items {
+		if check, ok := item.Result.(*HealthCheck); ok && item.Succeeded {
+			checks = append(checks, *check)
+			continue
+		}
+		checks = append(checks, HealthCheck{Kind: bindings[i].Kind, Namespace: item.Namespace, Name: item.Name,
+			Message: item.Error, CheckedAt: metaV1.Now()})
+	}
+
+	sort.Slice(checks, func(i, j int) bool {
+		if checks[i].Namespace != checks[j].Namespace {
+			return checks[i].Namespace < checks[j].Namespace
+		}
+		return checks[i].Name < checks[j].Name
+	})
+	return checks, nil
+}
+
+func toToolHealth(item interface{}, now time.Time) (ToolHealth, bool) {
+	switch value := item.(type) {
+	case jenkins.Jenkins:
+		return ToolHealth{Kind: api.ResourceKindJenkins, Name: value.ObjectMeta.Name,
+			Status: toHealthStatus(value.Status.ServiceStatus, now)}, true
+	case codereposervice.CodeRepoService:
+		return ToolHealth{Kind: api.ResourceKindCodeRepoService, Name: value.ObjectMeta.Name,
+			Type: value.Spec.Type.String(), Status: toHealthStatus(value.Status, now)}, true
+	case imageregistry.ImageRegistry:
+		return ToolHealth{Kind: api.ResourceKindImageRegistry, Name: value.ObjectMeta.Name,
+			Type: string(value.Spec.Type), Status: toHealthStatus(value.Status, now)}, true
+	case codequalitytool.CodeQualityTool:
+		return ToolHealth{Kind: api.ResourceKindCodeQualityTool, Name: value.ObjectMeta.Name,
+			Type: string(value.Spec.Type), Status: toHealthStatus(value.Status, now)}, true
+	}
+	// project management and test tools do not report their status
+	return ToolHealth{}, false
+}
+
+func toBindingHealth(item interface{}, now time.Time) (BindingHealth, bool) {
+	switch value := item.(type) {
+	case jenkinsbinding.JenkinsBinding:
+		return BindingHealth{Kind: api.ResourceKindJenkinsBinding, Namespace: value.ObjectMeta.Namespace,
+			Name: value.ObjectMeta.Name, ToolKind: api.ResourceKindJenkins, ToolName: value.Spec.Jenkins.Name,
+			Status: toHealthStatus(value.Status.ServiceStatus, now)}, true
+	case coderepobinding.CodeRepoBinding:
+		return BindingHealth{Kind: api.ResourceKindCodeRepoBinding, Namespace: value.ObjectMeta.Namespace,
+			Name: value.ObjectMeta.Name, ToolKind: api.ResourceKindCodeRepoService, ToolName: value.Spec.CodeRepoService.Name,
+			Status: toHealthStatus(value.Status, now)}, true
+	case imageregistrybinding.ImageRegistryBinding:
+		return BindingHealth{Kind: api.ResourceKindImageRegistryBinding, Namespace: value.ObjectMeta.Namespace,
+			Name: value.ObjectMeta.Name, ToolKind: api.ResourceKindImageRegistry, ToolName: value.Spec.ImageRegistry.Name,
+			Status: toHealthStatus(value.Status, now)}, true
+	case codequalitybinding.CodeQualityBinding:
+		return BindingHealth{Kind: api.ResourceKindCodeQualityBinding, Namespace: value.ObjectMeta.Namespace,
+			Name: value.ObjectMeta.Name, ToolKind: api.ResourceKindCodeQualityTool, ToolName: value.Spec.CodeQualityTool.Name,
+			Status: toHealthStatus(value.Status, now)}, true
+	}
+	return BindingHealth{}, false
+}
+
+// toHealthStatus returns the health of a status. A resource is healthy when it is ready and none of its
+// conditions failed.
+func toHealthStatus(status devopsv1alpha1.ServiceStatus, now time.Time) HealthStatus {
+	health := HealthStatus{
+		Phase:       string(status.Phase),
+		Healthy:     status.Phase == statusPhaseReady,
+		Reason:      status.Reason,
+		Message:     status.Message,
+		LastChecked: status.LastUpdate,
+		Conditions:  make([]HealthCondition, 0),
+	}
+	if health.LastChecked == nil && status.HTTPStatus != nil {
+		health.LastChecked = status.HTTPStatus.LastAttempt
+	}
+
+	for _, condition := range status.Conditions {
+		if condition.Status != conditionError {
+			continue
+		}
+		health.Healthy = false
+		health.Conditions = append(health.Conditions, HealthCondition{
+			Type:        condition.Type,
+			Name:        condition.Name,
+			Status:      condition.Status,
+			Reason:      condition.Reason,
+			Message:     condition.Message,
+			LastAttempt: condition.LastAttempt,
+		})
+		if condition.LastAttempt != nil && (health.LastChecked == nil || health.LastChecked.Before(condition.LastAttempt)) {
+			health.LastChecked = condition.LastAttempt
+		}
+	}
+	if status.Phase == statusPhaseError && health.Message == "" && status.HTTPStatus != nil {
+		health.Message = status.HTTPStatus.ErrorMessage
+	}
+
+	if health.LastChecked != nil {
+		health.Age = int64(now.Sub(health.LastChecked.Time) / time.Second)
+	}
+	return health
+}
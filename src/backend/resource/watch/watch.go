@@ -0,0 +1,391 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	devopsclient "alauda.io/devops-apiserver/pkg/client/clientset/versioned"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	"alauda.io/diablo/src/backend/resource/deployment"
+	"alauda.io/diablo/src/backend/resource/event"
+	"alauda.io/diablo/src/backend/resource/pipeline"
+	"alauda.io/diablo/src/backend/resource/pipelineconfig"
+	"alauda.io/diablo/src/backend/resource/pod"
+	apps "k8s.io/api/apps/v1"
+	"k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/kubernetes"
+)
+
+// Event is a single change of a watched resource. Object holds the same presentation view that
+// is returned by the list endpoint of given kind, so it can be merged into the list directly.
+type Event struct {
+	Type   watch.EventType  `json:"type"`
+	Kind   api.ResourceKind `json:"kind"`
+	Object interface{}      `json:"object"`
+}
+
+// Kinds contains all resource kinds that can be watched.
+var Kinds = []api.ResourceKind{
+	api.ResourceKindPipeline,
+	api.ResourceKindPipelineConfig,
+	api.ResourceKindPod,
+	api.ResourceKindDeployment,
+}
+
+// ParseKinds returns kinds from given comma separated list. Empty list means all kinds.
+func ParseKinds(raw string) ([]api.ResourceKind, error) {
+	if len(raw) == 0 {
+		return Kinds, nil
+	}
+
+	result := make([]api.ResourceKind, 0)
+	for _, name := range strings.Split(raw, ",") {
+		kind, ok := findKind(strings.TrimSpace(name))
+		if !ok {
+			return nil, fmt.Errorf("resource kind %s can not be watched", name)
+		}
+		result = append(result, kind)
+	}
+	return result, nil
+}
+
+func findKind(name string) (api.ResourceKind, bool) {
+	for _, kind := range Kinds {
+		if strings.EqualFold(string(kind), name) {
+			return kind, true
+		}
+	}
+	return "", false
+}
+
+// relatedResourcesTTL is how long resources related to watched objects are reused. Events of a rollout or
+// of a pipeline run come in bursts, so related resources are read once per burst instead of once per event.
+const relatedResourcesTTL = 5 * time.Second
+
+// converter turns watched object into a data cell used for filtering and a presentation view
+// sent to the client.
+type converter func(obj runtime.Object) (dataselect.DataCell, types.UID, func() interface{}, bool)
+
+// Watch opens watches of given kinds in the namespace and returns channel of events that match
+// the filter query. With more than one namespace selected all namespaces are watched and events of
+// other namespaces are dropped. The channel is closed when stopCh is closed or when any of the underlying
+// watches ends, e.g. because of apiserver timeout. Clients are expected to reconnect then.
+func Watch(k8sClient kubernetes.Interface, devopsClient devopsclient.Interface, nsQuery *common.NamespaceQuery,
+	kinds []api.ResourceKind, filterQuery *dataselect.FilterQuery, stopCh <-chan struct{}) (<-chan Event, error) {
+	namespace := nsQuery.ToRequestParam()
+	options := metaV1.ListOptions{}
+	watchers := make([]watch.Interface, 0, len(kinds))
+	converters := make([]converter, 0, len(kinds))
+	related := newRelatedCache(relatedResourcesTTL)
+
+	stopAll := func() {
+		for _, w := range watchers {
+			w.Stop()
+		}
+	}
+
+	for _, kind := range kinds {
+		var w watch.Interface
+		var err error
+		var convert converter
+
+		switch kind {
+		case api.ResourceKindPipeline:
+			w, err = devopsClient.DevopsV1alpha1().Pipelines(namespace).Watch(options)
+			convert = pipelineConverter
+		case api.ResourceKindPipelineConfig:
+			w, err = devopsClient.DevopsV1alpha1().PipelineConfigs(namespace).Watch(options)
+			convert = pipelineConfigConverter(devopsClient, related)
+		case api.ResourceKindPod:
+			w, err = k8sClient.CoreV1().Pods(namespace).Watch(options)
+			convert = podConverter(k8sClient, related)
+		case api.ResourceKindDeployment:
+			w, err = k8sClient.AppsV1().Deployments(namespace).Watch(options)
+			convert = deploymentConverter(k8sClient, related)
+		default:
+			err = fmt.Errorf("resource kind %s can not be watched", kind)
+		}
+
+		if err != nil {
+			stopAll()
+			return nil, err
+		}
+		watchers = append(watchers, w)
+		converters = append(converters, convert)
+	}
+
+	events := make(chan Event)
+	done := make(chan struct{})
+	var once sync.Once
+	finish := func() { once.Do(func() { close(done) }) }
+
+	var wg sync.WaitGroup
+	for i := range watchers {
+		wg.Add(1)
+		go func(w watch.Interface, kind api.ResourceKind, convert converter) {
+			defer wg.Done()
+			defer finish()
+			f := newFilter(kind, nsQuery, filterQuery, convert)
+			for {
+				select {
+				case <-done:
+					return
+				case e, ok := <-w.ResultChan():
+					if !ok {
+						return
+					}
+					result, ok := f.handle(e)
+					if !ok {
+						continue
+					}
+					select {
+					case events <- result:
+					case <-done:
+						return
+					}
+				}
+			}
+		}(watchers[i], kinds[i], converters[i])
+	}
+
+	go func() {
+		select {
+		case <-stopCh:
+			finish()
+		case <-done:
+		}
+		stopAll()
+		wg.Wait()
+		close(events)
+	}()
+
+	return events, nil
+}
+
+// filter keeps track of objects that matched the filter query, so the client is told to remove an
+// object once it stops matching.
+type filter struct {
+	kind        api.ResourceKind
+	nsQuery     *common.NamespaceQuery
+	filterQuery *dataselect.FilterQuery
+	convert     converter
+	matched     map[types.UID]bool
+}
+
+func newFilter(kind api.ResourceKind, nsQuery *common.NamespaceQuery, filterQuery *dataselect.FilterQuery,
+	convert converter) *filter {
+	if nsQuery == nil {
+		nsQuery = common.NewNamespaceQuery(nil)
+	}
+	if filterQuery == nil {
+		filterQuery = dataselect.NoFilter
+	}
+	return &filter{kind: kind, nsQuery: nsQuery, filterQuery: filterQuery, convert: convert,
+		matched: make(map[types.UID]bool)}
+}
+
+// handle returns event that should be sent to the client for given watch event, if any.
+func (self *filter) handle(e watch.Event) (Event, bool) {
+	if e.Type == watch.Error {
+		return Event{Type: e.Type, Kind: self.kind, Object: e.Object}, true
+	}
+
+	// namespaces which were not selected are watched when more than one namespace is selected
+	accessor, err := meta.Accessor(e.Object)
+	if err != nil || !self.nsQuery.Matches(accessor.GetNamespace()) {
+		return Event{}, false
+	}
+
+	cell, uid, toObject, ok := self.convert(e.Object)
+	if !ok {
+		log.Printf("Unexpected object %T in %s watch", e.Object, self.kind)
+		return Event{}, false
+	}
+
+	wasMatched := self.matched[uid]
+	matches := self.filterQuery.Matches(cell)
+
+	switch {
+	case e.Type == watch.Deleted:
+		delete(self.matched, uid)
+		if !wasMatched && !matches {
+			return Event{}, false
+		}
+	case matches:
+		self.matched[uid] = true
+		if !wasMatched && e.Type == watch.Modified {
+			e.Type = watch.Added
+		}
+	case wasMatched:
+		delete(self.matched, uid)
+		e.Type = watch.Deleted
+	default:
+		return Event{}, false
+	}
+
+	return Event{Type: e.Type, Kind: self.kind, Object: toObject()}, true
+}
+
+func pipelineConverter(obj runtime.Object) (dataselect.DataCell, types.UID, func() interface{}, bool) {
+	p, ok := obj.(*devopsv1alpha1.Pipeline)
+	if !ok {
+		return nil, "", nil, false
+	}
+	return pipeline.PipelineCell(*p), p.UID, func() interface{} { return pipeline.ToPipeline(*p) }, true
+}
+
+func pipelineConfigConverter(client devopsclient.Interface, related *relatedCache) converter {
+	return func(obj runtime.Object) (dataselect.DataCell, types.UID, func() interface{}, bool) {
+		config, ok := obj.(*devopsv1alpha1.PipelineConfig)
+		if !ok {
+			return nil, "", nil, false
+		}
+		return pipelineconfig.PipelineConfigCell(*config), config.UID, func() interface{} {
+			pipelines := related.get("pipelines/"+config.Namespace, func() interface{} {
+				list, err := client.DevopsV1alpha1().Pipelines(config.Namespace).List(api.ListEverything)
+				if err != nil {
+					log.Printf("Skipping pipelines of namespace %s: %v", config.Namespace, err)
+					return []devopsv1alpha1.Pipeline{}
+				}
+				return list.Items
+			}).([]devopsv1alpha1.Pipeline)
+			return pipelineconfig.ToPipelineConfig(*config, pipelineconfig.FilterPipelineConfigPipelines(config.Name, pipelines))
+		}, true
+	}
+}
+
+func podConverter(client kubernetes.Interface, related *relatedCache) converter {
+	return func(obj runtime.Object) (dataselect.DataCell, types.UID, func() interface{}, bool) {
+		p, ok := obj.(*v1.Pod)
+		if !ok {
+			return nil, "", nil, false
+		}
+		return pod.PodCell(*p), p.UID, func() interface{} {
+			events := related.get("events/"+p.Namespace, func() interface{} {
+				return getEvents(client, common.NewSameNamespaceQuery(p.Namespace))
+			}).([]v1.Event)
+			return pod.ToPod(p, event.FilterPodEvents(events, *p))
+		}, true
+	}
+}
+
+func deploymentConverter(client kubernetes.Interface, related *relatedCache) converter {
+	return func(obj runtime.Object) (dataselect.DataCell, types.UID, func() interface{}, bool) {
+		d, ok := obj.(*apps.Deployment)
+		if !ok {
+			return nil, "", nil, false
+		}
+		return deployment.DeploymentCell(*d), d.UID, func() interface{} {
+			resources := related.get("deployment/"+d.Namespace, func() interface{} {
+				return getDeploymentResources(client, common.NewSameNamespaceQuery(d.Namespace))
+			}).(*common.ResourceCollection)
+			return deployment.ToDeployment(*d, resources)
+		}, true
+	}
+}
+
+// getEvents reads events of the namespace. Errors are not critical here, pods are sent without
+// events then.
+func getEvents(client kubernetes.Interface, nsQuery *common.NamespaceQuery) []v1.Event {
+	channel := common.GetEventListChannel(client, nsQuery, 1)
+	events, err := <-channel.List, <-channel.Error
+	if err != nil {
+		log.Printf("Skipping events of namespace %s: %v", nsQuery.ToRequestParam(), err)
+		return []v1.Event{}
+	}
+	return events.Items
+}
+
+// getDeploymentResources reads resources needed to show pod info and visit addresses of a
+// deployment. Errors are not critical here, deployment is sent without related data then.
+func getDeploymentResources(client kubernetes.Interface, nsQuery *common.NamespaceQuery) *common.ResourceCollection {
+	channels := &common.ResourceChannels{
+		PodList:        common.GetPodListChannel(client, nsQuery, 1),
+		EventList:      common.GetEventListChannel(client, nsQuery, 1),
+		ReplicaSetList: common.GetReplicaSetListChannel(client, nsQuery, 1),
+		ServiceList:    common.GetServiceListChannel(client, nsQuery, 1),
+		IngressList:    common.GetIngressListChannel(client, nsQuery, 1),
+	}
+
+	rc := &common.ResourceCollection{}
+	if pods, err := <-channels.PodList.List, <-channels.PodList.Error; err == nil {
+		rc.Pods = pods.Items
+	}
+	if events, err := <-channels.EventList.List, <-channels.EventList.Error; err == nil {
+		rc.Events = events.Items
+	}
+	if rs, err := <-channels.ReplicaSetList.List, <-channels.ReplicaSetList.Error; err == nil {
+		rc.ReplicaSets = rs.Items
+	}
+	if ss, err := <-channels.ServiceList.List, <-channels.ServiceList.Error; err == nil {
+		rc.Services = ss.Items
+	}
+	if is, err := <-channels.IngressList.List, <-channels.IngressList.Error; err == nil {
+		rc.Ingresses = is.Items
+	}
+	return rc
+}
+
+// relatedCache keeps resources related to watched objects for a short time, so that the presentation
+// views of a burst of events in a namespace share a single read of the related resources.
+type relatedCache struct {
+	ttl time.Duration
+
+	mux     sync.Mutex
+	entries map[string]*relatedEntry
+}
+
+type relatedEntry struct {
+	mux     sync.Mutex
+	expires time.Time
+	value   interface{}
+}
+
+func newRelatedCache(ttl time.Duration) *relatedCache {
+	return &relatedCache{ttl: ttl, entries: make(map[string]*relatedEntry)}
+}
+
+// get returns the value of the key, loading it when it is missing or expired. Concurrent calls for the
+// same key load it only once.
+func (self *relatedCache) get(key string, load func() interface{}) interface{} {
+	self.mux.Lock()
+	entry, ok := self.entries[key]
+	if !ok {
+		entry = &relatedEntry{}
+		self.entries[key] = entry
+	}
+	self.mux.Unlock()
+
+	entry.mux.Lock()
+	defer entry.mux.Unlock()
+	if entry.value == nil || !time.Now().Before(entry.expires) {
+		entry.value = load()
+		entry.expires = time.Now().Add(self.ttl)
+	}
+	return entry.value
+}
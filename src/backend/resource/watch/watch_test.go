@@ -0,0 +1,129 @@
+// Copyright 2017 The Kubernetes Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package watch
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	devopsv1alpha1 "alauda.io/devops-apiserver/pkg/apis/devops/v1alpha1"
+	"alauda.io/diablo/src/backend/api"
+	"alauda.io/diablo/src/backend/resource/common"
+	"alauda.io/diablo/src/backend/resource/dataselect"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func newPipeline(name string) *devopsv1alpha1.Pipeline {
+	return newNamespacedPipeline("ns", name)
+}
+
+func newNamespacedPipeline(namespace, name string) *devopsv1alpha1.Pipeline {
+	return &devopsv1alpha1.Pipeline{
+		ObjectMeta: metaV1.ObjectMeta{Name: name, Namespace: namespace, UID: types.UID("uid")},
+	}
+}
+
+func TestFilterHandle(t *testing.T) {
+	f := newFilter(api.ResourceKindPipeline, common.NewNamespaceQuery([]string{"ns", "other"}),
+		dataselect.NewFilterQuery([]string{dataselect.NameProperty, "build"}), pipelineConverter)
+
+	cases := []struct {
+		info     string
+		event    watch.Event
+		expected watch.EventType
+		sent     bool
+	}{
+		{"Object that does not match is skipped",
+			watch.Event{Type: watch.Added, Object: newPipeline("deploy-1")}, "", false},
+		{"Object that starts to match is added",
+			watch.Event{Type: watch.Modified, Object: newPipeline("build-1")}, watch.Added, true},
+		{"Matching object is modified",
+			watch.Event{Type: watch.Modified, Object: newPipeline("build-1")}, watch.Modified, true},
+		{"Object that stops to match is deleted",
+			watch.Event{Type: watch.Modified, Object: newPipeline("deploy-1")}, watch.Deleted, true},
+		{"Deleted object that did not match is skipped",
+			watch.Event{Type: watch.Deleted, Object: newPipeline("deploy-1")}, "", false},
+		{"Object of another selected namespace is added",
+			watch.Event{Type: watch.Added, Object: newNamespacedPipeline("other", "build-2")}, watch.Added, true},
+		{"Object of a namespace that is not selected is skipped",
+			watch.Event{Type: watch.Added, Object: newNamespacedPipeline("foreign", "build-3")}, "", false},
+	}
+
+	for _, c := range cases {
+		event, sent := f.handle(c.event)
+		if sent != c.sent || event.Type != c.expected {
+			t.Errorf("Test Case: %s. Expected (%s, %v), but got (%s, %v).",
+				c.info, c.expected, c.sent, event.Type, sent)
+		}
+		if sent && event.Kind != api.ResourceKindPipeline {
+			t.Errorf("Test Case: %s. Expected kind %s, but got %s.", c.info, api.ResourceKindPipeline, event.Kind)
+		}
+	}
+}
+
+func TestParseKinds(t *testing.T) {
+	cases := []struct {
+		raw      string
+		expected []api.ResourceKind
+		err      bool
+	}{
+		{"", Kinds, false},
+		{"pod,Deployment", []api.ResourceKind{api.ResourceKindPod, api.ResourceKindDeployment}, false},
+		{"pipeline, pipelineconfig", []api.ResourceKind{api.ResourceKindPipeline, api.ResourceKindPipelineConfig}, false},
+		{"secret", nil, true},
+	}
+
+	for _, c := range cases {
+		actual, err := ParseKinds(c.raw)
+		if (err != nil) != c.err {
+			t.Errorf("ParseKinds(%q) returned unexpected error: %v", c.raw, err)
+		}
+		if !reflect.DeepEqual(actual, c.expected) {
+			t.Errorf("ParseKinds(%q) == %v, expected %v", c.raw, actual, c.expected)
+		}
+	}
+}
+
+func TestRelatedCacheGet(t *testing.T) {
+	cases := []struct {
+		info     string
+		ttl      time.Duration
+		expected int
+	}{
+		{"Value is reused until it expires", time.Hour, 1},
+		{"Expired value is loaded again", -time.Second, 2},
+	}
+
+	for _, c := range cases {
+		related := newRelatedCache(c.ttl)
+		loads := 0
+		load := func() interface{} {
+			loads++
+			return loads
+		}
+		related.get("pods/ns", load)
+		actual := related.get("pods/ns", load)
+		if loads != c.expected || actual != c.expected {
+			t.Errorf("Test Case: %s. Expected %d loads, but got %d returning %v.", c.info, c.expected, loads, actual)
+		}
+		related.get("pods/other", load)
+		if loads != c.expected+1 {
+			t.Errorf("Test Case: %s. Expected other key to be loaded separately.", c.info)
+		}
+	}
+}
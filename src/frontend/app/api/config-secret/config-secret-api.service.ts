@@ -2,6 +2,7 @@ import { TranslateService } from '@alauda/common-snippet';
 import { HttpClient } from '@angular/common/http';
 import { Injectable, Inject } from '@angular/core';
 import { ConfigSecretsFindParams } from '@app/api/config-secret/config-secret-api.types.ts';
+import { SecretImpact } from '@app/api/secret/secret-api.types';
 import { Pagination } from '@app/types';
 import { Observable } from 'rxjs';
 import { map } from 'rxjs/operators';
@@ -76,17 +77,17 @@ export class ConfigSecretApiService {
     );
   }
 
+  // responds 409 with the impact while the secret is in use, unless forced
   deleteSecret(
     cluster: string,
     namespace: string,
     name: string,
-  ): Observable<any> {
-    return this.http.delete(
+    force = false,
+  ): Observable<SecretImpact> {
+    return this.http.delete<SecretImpact>(
       `{{API_GATEWAY}}/devops/api/v1/secret/${namespace}/${name}`,
       {
-        params: {
-          cluster,
-        },
+        params: force ? { cluster, force: 'true' } : { cluster },
       },
     );
   }
@@ -4,7 +4,12 @@ import { groupBy } from 'lodash-es';
 import { Observable } from 'rxjs';
 import { map } from 'rxjs/operators';
 
-import { Secret, SecretIdentity, SecretResource } from './secret-api.types';
+import {
+  Secret,
+  SecretIdentity,
+  SecretImpact,
+  SecretResource,
+} from './secret-api.types';
 import { defaultFields, toModel, toResource } from './utils';
 import { Constants, TOKEN_CONSTANTS } from '@app/constants';
 
@@ -97,9 +102,11 @@ export class SecretApiService {
       .pipe(map((item: SecretResource) => toModel(item, this.constants)));
   }
 
-  delete(namespace: string, name: string) {
-    return this.http.delete(
+  // responds 409 with the impact while the secret is in use, unless forced
+  delete(namespace: string, name: string, force = false) {
+    return this.http.delete<SecretImpact>(
       `{{API_GATEWAY}}/devops/api/v1/secret/${namespace}/${name}`,
+      { params: force ? { force: 'true' } : {} },
     );
   }
 }
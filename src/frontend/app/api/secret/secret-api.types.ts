@@ -48,3 +48,16 @@ export interface SecretResource {
   stringData?: Dictionary<string>;
   type: SecretType;
 }
+
+export interface SecretImpactResource {
+  name: string;
+  namespace: string;
+  kind: string;
+}
+
+export interface SecretImpact extends SecretIdentity {
+  appName: string;
+  resources: SecretImpactResource[];
+  deleted: boolean;
+  errors: any[];
+}
@@ -1,5 +1,3 @@
-import { TranslateService } from '@alauda/common-snippet';
-import { DialogService, MessageService, NotificationService } from '@alauda/ui';
 import {
   ChangeDetectionStrategy,
   Component,
@@ -14,6 +12,8 @@ import {
   ConfigSecretsItem,
 } from '@app/api';
 
+import { ConfigSecretActions } from '../../services/actions';
+
 const defaultData = (): { items: any[]; length: number } => ({
   items: [],
   length: 0,
@@ -62,11 +62,8 @@ export class ConfigSecretListComponent {
   constructor(
     private readonly route: ActivatedRoute,
     private readonly router: Router,
-    private readonly dialog: DialogService,
-    private readonly message: MessageService,
-    private readonly notifaction: NotificationService,
-    private readonly translate: TranslateService,
     private readonly secretApi: ConfigSecretApiService,
+    private readonly actions: ConfigSecretActions,
   ) {}
 
   tracker(_: number, item: ConfigSecretsItem) {
@@ -87,43 +84,13 @@ export class ConfigSecretListComponent {
   }
 
   delete(secret: ConfigSecretsItem) {
-    this.dialog
-      .confirm({
-        title: this.translate.get('configsecret.secret_delete_confirm', {
-          name: secret.objectMeta.name,
-        }),
-        cancelText: this.translate.get('cancel'),
-        confirmText: this.translate.get('confirm'),
-        beforeConfirm: (resolve, reject) => {
-          this.secretApi
-            .deleteSecret(
-              this.params.cluster,
-              this.params.namespace,
-              secret.objectMeta.name,
-            )
-            .subscribe(
-              () => {
-                this.message.success({
-                  content: this.translate.get(
-                    'configsecret.secret_delete_succ',
-                  ),
-                });
-                resolve();
-              },
-              (err: any) => {
-                this.notifaction.error({
-                  title: this.translate.get('configsecret.secret_delete_fail'),
-                  content: err.error.error || err.error.message,
-                });
-                reject();
-              },
-            );
-        },
-      })
-      .then(() => {
-        this.updated.emit();
-      })
-      .catch(() => {});
+    this.actions
+      .delete(this.params.cluster, this.params.namespace, secret.objectMeta.name)
+      .subscribe(deleted => {
+        if (deleted) {
+          this.updated.emit();
+        }
+      });
   }
 
   refreshList(data: any) {
@@ -1,15 +1,34 @@
-import { DialogService, DialogSize } from '@alauda/ui';
+import { TranslateService } from '@alauda/common-snippet';
+import {
+  ConfirmType,
+  DialogService,
+  DialogSize,
+  MessageService,
+  NotificationService,
+} from '@alauda/ui';
+import { HttpErrorResponse } from '@angular/common/http';
 import { Injectable } from '@angular/core';
-import { Secret, SecretType } from '@app/api';
+import {
+  ConfigSecretApiService,
+  Secret,
+  SecretImpact,
+  SecretType,
+} from '@app/api';
 import { get } from 'lodash-es';
-import { Observable } from 'rxjs';
+import { Observable, Subject } from 'rxjs';
 import { map, take } from 'rxjs/operators';
 
 import { ConfigSecretCreateDialogComponent } from '../components/secret-create-dialog/secret-create-dialog.component';
 
 @Injectable()
 export class ConfigSecretActions {
-  constructor(private dialog: DialogService) {}
+  constructor(
+    private dialog: DialogService,
+    private readonly message: MessageService,
+    private readonly notification: NotificationService,
+    private readonly translate: TranslateService,
+    private readonly secretApi: ConfigSecretApiService,
+  ) {}
 
   create(
     cluster: string,
@@ -40,4 +59,112 @@ export class ConfigSecretActions {
         take(1),
       );
   }
+
+  // emits true once the secret is deleted, a secret in use is only deleted
+  // after confirming its impact
+  delete(
+    cluster: string,
+    namespace: string,
+    name: string,
+  ): Observable<boolean> {
+    const result$ = new Subject<boolean>();
+
+    this.dialog
+      .confirm({
+        title: this.translate.get('configsecret.secret_delete_confirm', {
+          name,
+        }),
+        cancelText: this.translate.get('cancel'),
+        confirmText: this.translate.get('confirm'),
+        beforeConfirm: (resolve, reject) =>
+          this.secretApi.deleteSecret(cluster, namespace, name).subscribe(
+            () => {
+              this.message.success({
+                content: this.translate.get('configsecret.secret_delete_succ'),
+              });
+              result$.next(true);
+              resolve();
+            },
+            (error: HttpErrorResponse) => {
+              if (error.status === 409 && error.error) {
+                resolve();
+                this.forceDelete(
+                  cluster,
+                  namespace,
+                  name,
+                  error.error,
+                ).subscribe(result$);
+                return;
+              }
+              this.notification.error({
+                title: this.translate.get('configsecret.secret_delete_fail'),
+                content: error.error.error || error.error.message,
+              });
+              reject();
+            },
+          ),
+      })
+      .catch(() => {
+        result$.next(false);
+      });
+
+    return result$.pipe(take(1));
+  }
+
+  private forceDelete(
+    cluster: string,
+    namespace: string,
+    name: string,
+    impact: SecretImpact,
+  ): Observable<boolean> {
+    const result$ = new Subject<boolean>();
+    const content = [];
+    if ((impact.resources || []).length) {
+      content.push(
+        this.translate.get('secret.referenced_by', {
+          resources: impact.resources
+            .map(item => `${item.kind} ${item.namespace}/${item.name}`)
+            .join(', '),
+        }),
+      );
+    }
+    if ((impact.errors || []).length) {
+      content.push(this.translate.get('secret.impact_unchecked'));
+    }
+
+    this.dialog
+      .confirm({
+        title: this.translate.get('secret.force_delete_confirm', { name }),
+        content: content.join(' '),
+        confirmType: ConfirmType.Danger,
+        confirmText: this.translate.get('secret.force_delete'),
+        cancelText: this.translate.get('cancel'),
+        beforeConfirm: (resolve, reject) =>
+          this.secretApi
+            .deleteSecret(cluster, namespace, name, true)
+            .subscribe(
+              () => {
+                this.message.success({
+                  content: this.translate.get(
+                    'configsecret.secret_delete_succ',
+                  ),
+                });
+                result$.next(true);
+                resolve();
+              },
+              (error: HttpErrorResponse) => {
+                this.notification.error({
+                  title: this.translate.get('configsecret.secret_delete_fail'),
+                  content: error.error.error || error.error.message,
+                });
+                reject();
+              },
+            ),
+      })
+      .catch(() => {
+        result$.next(false);
+      });
+
+    return result$.pipe(take(1));
+  }
 }
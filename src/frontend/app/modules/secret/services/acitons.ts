@@ -6,8 +6,15 @@ import {
   MessageService,
   NotificationService,
 } from '@alauda/ui';
+import { HttpErrorResponse } from '@angular/common/http';
 import { Injectable } from '@angular/core';
-import { Secret, SecretApiService, SecretIdentity, SecretType } from '@app/api';
+import {
+  Secret,
+  SecretApiService,
+  SecretIdentity,
+  SecretImpact,
+  SecretType,
+} from '@app/api';
 import { ToolChainApiService } from '@app/api/tool-chain/tool-chain-api.service';
 import { flatMap } from 'lodash-es';
 import { Observable, Subject, of } from 'rxjs';
@@ -118,7 +125,12 @@ export class SecretActions {
               result$.next(true);
               resolve();
             },
-            (error: any) => {
+            (error: HttpErrorResponse) => {
+              if (error.status === 409 && error.error) {
+                resolve();
+                this.forceDelete(secret, error.error).subscribe(result$);
+                return;
+              }
               this.notification.error({
                 title: this.translate.get('secret.delete_fail'),
                 content: error.error.error || error.error.message,
@@ -134,6 +146,59 @@ export class SecretActions {
     return result$.pipe(take(1));
   }
 
+  private forceDelete(
+    secret: SecretIdentity,
+    impact: SecretImpact,
+  ): Observable<boolean> {
+    const result$ = new Subject<boolean>();
+    const content = [];
+    if ((impact.resources || []).length) {
+      content.push(
+        this.translate.get('secret.referenced_by', {
+          resources: impact.resources
+            .map(item => `${item.kind} ${item.namespace}/${item.name}`)
+            .join(', '),
+        }),
+      );
+    }
+    if ((impact.errors || []).length) {
+      content.push(this.translate.get('secret.impact_unchecked'));
+    }
+
+    this.dialog
+      .confirm({
+        title: this.translate.get('secret.force_delete_confirm', {
+          name: secret.name,
+        }),
+        content: content.join(' '),
+        confirmType: ConfirmType.Danger,
+        confirmText: this.translate.get('secret.force_delete'),
+        cancelText: this.translate.get('cancel'),
+        beforeConfirm: (resolve, reject) =>
+          this.secretApi
+            .delete(secret.namespace, secret.name, true)
+            .subscribe(
+              () => {
+                this.message.success(this.translate.get('secret.delete_succ'));
+                result$.next(true);
+                resolve();
+              },
+              (error: HttpErrorResponse) => {
+                this.notification.error({
+                  title: this.translate.get('secret.delete_fail'),
+                  content: error.error.error || error.error.message,
+                });
+                reject();
+              },
+            ),
+      })
+      .catch(() => {
+        result$.next(false);
+      });
+
+    return result$.pipe(take(1));
+  }
+
   private getToolChainSecretTips(
     toolKind: string,
     toolType: string,
@@ -1327,6 +1327,12 @@ export const en = {
   'secret.delete_confirm': 'Are you sure to delete secret "{{ name }}"?',
   'secret.delete_succ': 'Secret delete successed',
   'secret.delete_fail': 'Secret delete failed',
+  'secret.force_delete_confirm':
+    'Secret "{{ name }}" may still be in use, are you sure to delete it?',
+  'secret.force_delete': 'Force Delete',
+  'secret.referenced_by': 'It is referenced by {{ resources }}.',
+  'secret.impact_unchecked':
+    'Some resources could not be checked, they may reference it as well.',
   'secret.ssh': 'SSH',
   'secret.ssh_privatekey': 'SSH Private Key',
   'secret.create_document_link': 'DevOps tool corresponding secret type filling method, please refer to',
@@ -1260,6 +1260,12 @@ export const zh = {
   'secret.delete_confirm': '确定删除 凭据 “{{ name }}” 吗？',
   'secret.delete_succ': '凭据删除成功',
   'secret.delete_fail': '凭据删除失败',
+  'secret.force_delete_confirm':
+    '凭据 “{{ name }}” 可能仍在使用中，确定删除吗？',
+  'secret.force_delete': '强制删除',
+  'secret.referenced_by': '它被以下资源引用：{{ resources }}。',
+  'secret.impact_unchecked':
+    '部分资源无法检查，它们也可能引用了此凭据。',
   'secret.ssh': 'SSH',
   'secret.ssh_privatekey': 'SSH 私钥',
   'secret.create_document_link': 'DevOps 工具对应凭据类型填写方式， 请参照',